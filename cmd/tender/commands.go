@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// runCommand runs a maintenance command given on the command line instead of serving HTTP:
+//
+//	tender rebuild-reports   recompute the report aggregates from live data
+//	tender check-reports     compare the report aggregates with live data
+func (app *application) runCommand(name string) error {
+	ctx := context.Background()
+
+	switch name {
+	case "rebuild-reports":
+		if err := app.aggregateService.Rebuild(ctx); err != nil {
+			return fmt.Errorf("rebuild-reports: %w", err)
+		}
+		app.infoLog.Println("Report aggregates rebuilt")
+		return nil
+	case "check-reports":
+		check, err := app.aggregateService.Check(ctx)
+		if err != nil {
+			return fmt.Errorf("check-reports: %w", err)
+		}
+		for _, m := range check.Mismatches {
+			app.infoLog.Printf("%s %d-%02d company=%d user=%d type=%q status=%d %s: live %.2f, aggregate %.2f (pending %t)",
+				m.Source, m.Year, m.Month, m.CompanyID, m.UserID, m.Type, m.Status, m.Field, m.Live, m.Aggregate, m.Pending)
+		}
+		if !check.Consistent {
+			return fmt.Errorf("check-reports: %d mismatches", len(check.Mismatches))
+		}
+		app.infoLog.Println("Report aggregates are consistent")
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q", name)
+	}
+}
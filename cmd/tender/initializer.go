@@ -3,26 +3,32 @@ package main
 import (
 	"context"
 	"database/sql"
-	"firebase.google.com/go"
 	"fmt"
-	"google.golang.org/api/option"
 	"log"
 	"net/http"
+	"net/netip"
+	"tender/internal/config"
+	"tender/internal/events"
 	"tender/internal/handlers"
+	"tender/internal/mail"
+	"tender/internal/models"
+	"tender/internal/notify"
 	"tender/internal/repositories"
 	"tender/internal/services"
+	"time"
 )
 
 type application struct {
 	errorLog                *log.Logger
 	infoLog                 *log.Logger
+	events                  *events.Bus
 	userHandler             *handlers.UserHandler
 	permissionHandler       *handlers.PermissionHandler
 	companyHandler          *handlers.CompanyHandler
 	transactionHandler      *handlers.TransactionHandler
 	expenseHandler          *handlers.PersonalExpenseHandler
 	extraTransactionHandler *handlers.ExtraTransactionHandler
-	fcmHandler              *handlers.FCMHandler
+	notificationHandler     *handlers.NotificationHandler
 	categoryHandler         *handlers.CategoryHandler
 	balanceHistoryHandler   *handlers.BalanceHistoryHandler
 	tenderHandler           *handlers.TenderHandler
@@ -34,31 +40,103 @@ type application struct {
 	personalDebtHandler     *handlers.PersonalDebtHandler
 	debtTrancheHandler      *handlers.DebtTrancheHandler
 	historyHandler          *handlers.HistoryHandler
+	trashHandler            *handlers.TrashHandler
+	auditHandler            *handlers.AuditHandler
+	reportHandler           *handlers.ReportHandler
+	dashboardHandler        *handlers.DashboardHandler
+	exportHandler           *handlers.ExportHandler
+	documentHandler         *handlers.DocumentHandler
+	subscriptionHandler     *handlers.SubscriptionHandler
+	receivableHandler       *handlers.ReceivableHandler
+	streamHandler           *handlers.StreamHandler
+	webhookHandler          *handlers.WebhookHandler
+	idempotencyService      *services.IdempotencyService
+	aggregateService        *services.AggregateService
+	subscriptionService     *services.SubscriptionService
+	reportRefreshInterval   time.Duration
+	notificationService     *services.NotificationService
+	notificationInterval    time.Duration
+	personalDebtService     *services.PersonalDebtService
+	receivableService       *services.ReceivableService
+	streamService           *services.StreamService
+	webhookService          *services.WebhookService
+	webhookInterval         time.Duration
+	sessionService          *services.SessionService
 }
 
-func initializeApp(db *sql.DB, errorLog, infoLog *log.Logger) *application {
+// newNotifier returns the push provider named in the config.
+func newNotifier(cfg config.Config, errorLog, infoLog *log.Logger) notify.Notifier {
+	switch cfg.Notifications.Provider {
+	case "fcm":
+		fcm, err := notify.NewFCM(context.Background(), cfg.Notifications.ProjectID, cfg.Notifications.CredentialsFile)
+		if err != nil {
+			// Firebase credentials are only available in production; without them the server
+			// still starts and logs the notifications it would have sent.
+			errorLog.Printf("Firebase is unavailable, notifications will only be logged: %v\n", err)
+			return &notify.Log{Logger: infoLog}
+		}
+		return fcm
+	case "memory":
+		return &notify.Recorder{}
+	case "", "log":
+		return &notify.Log{Logger: infoLog}
+	}
+	errorLog.Fatalf("Invalid notification provider %q\n", cfg.Notifications.Provider)
+	return nil
+}
 
-	ctx := context.Background()
-	sa := option.WithCredentialsFile("/root/go/src/tender/cmd/tender/serviceAccountKey.json")
+// parseNetwork parses a CIDR range or a single address.
+func parseNetwork(s string) (netip.Prefix, error) {
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	return netip.ParsePrefix(s)
+}
 
-	firebaseApp, err := firebase.NewApp(ctx, &firebase.Config{ProjectID: "tendercommunity-17cd5"}, sa)
-	if err != nil {
-		errorLog.Fatalf("Ошибка в нахождении приложения: %v\n", err)
+func initializeApp(cfg config.Config, db *sql.DB, errorLog, infoLog *log.Logger) *application {
+
+	var err error
+	notifier := newNotifier(cfg, errorLog, infoLog)
+
+	var mailer mail.Sender = &mail.Log{Logger: infoLog}
+	if cfg.Mail.Host != "" {
+		mailer = &mail.SMTP{
+			Host:     cfg.Mail.Host,
+			Port:     cfg.Mail.Port,
+			Username: cfg.Mail.Username,
+			Password: cfg.Mail.Password,
+			From:     cfg.Mail.From,
+		}
 	}
 
-	fcmClient, err := firebaseApp.Messaging(ctx)
-	if err != nil {
-		errorLog.Fatalf("Ошибка при неверном ID устройства: %v\n", err)
+	notificationRepo := &repositories.NotificationRepository{Db: db}
+	tokenTTL := 60 * 24 * time.Hour
+	if cfg.Notifications.TokenTTL != "" {
+		tokenTTL, err = time.ParseDuration(cfg.Notifications.TokenTTL)
+		if err != nil {
+			errorLog.Fatalf("Invalid notification token ttl %q: %v\n", cfg.Notifications.TokenTTL, err)
+		}
 	}
+	notificationService := &services.NotificationService{Repo: notificationRepo, Notifier: notifier, Mailer: mailer, TokenTTL: tokenTTL}
+	notificationHandler := &handlers.NotificationHandler{Service: notificationService}
 
-	fcmHandler := handlers.NewFCMHandler(fcmClient, db)
+	eventBus := &events.Bus{}
+	eventBus.Subscribe("notifications", notificationService.HandleEvent)
 
 	userRepo := &repositories.UserRepository{Db: db}
-	userService := &services.UserService{Repo: userRepo}
-	userHandler := &handlers.UserHandler{Service: userService}
+	userService := &services.UserService{Repo: userRepo, Events: eventBus}
+	sessionTTL := 30 * 24 * time.Hour
+	if cfg.Auth.SessionTTL != "" {
+		sessionTTL, err = time.ParseDuration(cfg.Auth.SessionTTL)
+		if err != nil {
+			errorLog.Fatalf("Invalid session ttl %q: %v\n", cfg.Auth.SessionTTL, err)
+		}
+	}
+	sessionService := &services.SessionService{Repo: &repositories.SessionRepository{Db: db}, TTL: sessionTTL}
+	userHandler := &handlers.UserHandler{Service: userService, Sessions: sessionService}
 
 	permissionRepo := &repositories.PermissionRepository{Db: db}
-	permissionService := &services.PermissionService{Repo: permissionRepo}
+	permissionService := &services.PermissionService{Repo: permissionRepo, Events: eventBus}
 	permissionHandler := &handlers.PermissionHandler{Service: permissionService}
 
 	companyRepo := &repositories.CompanyRepository{Db: db}
@@ -73,8 +151,30 @@ func initializeApp(db *sql.DB, errorLog, infoLog *log.Logger) *application {
 	extraTransactionService := &services.ExtraTransactionService{Repo: extraTransactionRepo}
 	extraTransactionHandler := &handlers.ExtraTransactionHandler{Service: extraTransactionService}
 
+	reportRepo := &repositories.ReportRepository{Db: db}
+	debtTypes := models.DebtTypes{Given: cfg.PersonalDebts.GivenType, Taken: cfg.PersonalDebts.TakenType}
+	if debtTypes.Given == nil || debtTypes.Taken == nil {
+		errorLog.Printf("personal_debts.given_type and taken_type are not both set; debts of other types are left out of the cash-flow forecast\n")
+	}
+	reportService := &services.ReportService{Repo: reportRepo, DebtTypes: debtTypes}
+	aggregateRepo := &repositories.AggregateRepository{Db: db}
+	aggregateService := &services.AggregateService{Repo: aggregateRepo}
+	reportHandler := &handlers.ReportHandler{Service: reportService, Aggregates: aggregateService}
+
+	paymentTerms := models.PaymentTerms{DueDays: 30, EscalateDays: 60}
+	if cfg.Receivables.DueDays != 0 || cfg.Receivables.EscalateDays != 0 {
+		paymentTerms = models.PaymentTerms{DueDays: cfg.Receivables.DueDays, EscalateDays: cfg.Receivables.EscalateDays}
+		if paymentTerms.DueDays < 0 || paymentTerms.EscalateDays <= paymentTerms.DueDays {
+			errorLog.Fatalf("Invalid receivable payment terms: escalate_days (%d) must be greater than due_days (%d)\n",
+				paymentTerms.EscalateDays, paymentTerms.DueDays)
+		}
+	}
+	receivableRepo := &repositories.ReceivableRepository{Db: db}
+	receivableService := &services.ReceivableService{Repo: receivableRepo, Reports: reportRepo, Companies: companyRepo, Events: eventBus, Defaults: paymentTerms}
+	receivableHandler := &handlers.ReceivableHandler{Service: receivableService}
+
 	transactionRepo := &repositories.TransactionRepository{Db: db}
-	transactionService := &services.TransactionService{Repo: transactionRepo}
+	transactionService := &services.TransactionService{Repo: transactionRepo, Reports: reportRepo, Events: eventBus}
 	transactionHandler := &handlers.TransactionHandler{
 		Service:                 transactionService,
 		ExtraTransactionService: extraTransactionService,
@@ -89,7 +189,7 @@ func initializeApp(db *sql.DB, errorLog, infoLog *log.Logger) *application {
 	balanceHistoryHandler := &handlers.BalanceHistoryHandler{Service: balanceHistoryService}
 
 	tenderRepo := &repositories.TenderRepository{Db: db}
-	tenderService := &services.TenderService{Repo: tenderRepo}
+	tenderService := &services.TenderService{Repo: tenderRepo, Events: eventBus}
 	tenderHandler := &handlers.TenderHandler{Service: tenderService}
 
 	sumRepo := &repositories.SumRepository{Db: db}
@@ -101,7 +201,7 @@ func initializeApp(db *sql.DB, errorLog, infoLog *log.Logger) *application {
 	clientHandler := &handlers.ClientHandler{Service: clientService}
 
 	trancheRepo := &repositories.TrancheRepository{Db: db}
-	trancheService := &services.TrancheService{Repo: trancheRepo}
+	trancheService := &services.TrancheService{Repo: trancheRepo, Transactions: transactionRepo, Events: eventBus}
 	trancheHandler := &handlers.TrancheHandler{Service: trancheService}
 
 	changeRepo := &repositories.ChangeRepository{Db: db}
@@ -113,7 +213,7 @@ func initializeApp(db *sql.DB, errorLog, infoLog *log.Logger) *application {
 	balanceCategoryHandler := &handlers.BalanceCategoryHandler{Service: balanceCategoryService}
 
 	personalDebtRepo := &repositories.PersonalDebtRepository{Db: db}
-	personalDebtService := &services.PersonalDebtService{Repo: personalDebtRepo}
+	personalDebtService := &services.PersonalDebtService{Repo: personalDebtRepo, Events: eventBus, ReminderDays: cfg.PersonalDebts.ReminderDays, DebtTypes: debtTypes}
 	personalDebtHandler := &handlers.PersonalDebtHandler{Service: personalDebtService}
 
 	debtTrancheRepo := &repositories.DebtTrancheRepository{Db: db}
@@ -124,16 +224,117 @@ func initializeApp(db *sql.DB, errorLog, infoLog *log.Logger) *application {
 	historyService := &services.HistoryService{Repo: historyRepo}
 	historyHandler := &handlers.HistoryHandler{Service: historyService}
 
+	trashRetention := 30 * 24 * time.Hour
+	if cfg.Trash.Retention != "" {
+		trashRetention, err = time.ParseDuration(cfg.Trash.Retention)
+		if err != nil {
+			errorLog.Fatalf("Invalid trash retention %q: %v\n", cfg.Trash.Retention, err)
+		}
+	}
+	trashRepo := &repositories.TrashRepository{Db: db}
+	trashService := &services.TrashService{Repo: trashRepo, Retention: trashRetention}
+	trashHandler := &handlers.TrashHandler{Service: trashService}
+
+	auditRepo := &repositories.AuditRepository{Db: db}
+	auditService := &services.AuditService{Repo: auditRepo}
+	auditHandler := &handlers.AuditHandler{Service: auditService}
+	streamService := &services.StreamService{Repo: auditRepo, Permissions: permissionRepo}
+	streamHandler := &handlers.StreamHandler{Service: streamService}
+	var webhookNetworks []netip.Prefix
+	for _, network := range cfg.Webhooks.AllowedNetworks {
+		prefix, err := parseNetwork(network)
+		if err != nil {
+			errorLog.Fatalf("Invalid webhook allowed network %q: %v\n", network, err)
+		}
+		webhookNetworks = append(webhookNetworks, prefix)
+	}
+	webhookRepo := &repositories.WebhookRepository{Db: db}
+	webhookService := &services.WebhookService{Repo: webhookRepo, Audit: auditRepo,
+		Client: services.NewWebhookClient(webhookNetworks), AllowedNetworks: webhookNetworks}
+	webhookHandler := &handlers.WebhookHandler{Service: webhookService}
+
+	exportRepo := &repositories.ExportRepository{Db: db}
+	exportService := &services.ExportService{Repo: exportRepo}
+	exportHandler := &handlers.ExportHandler{Service: exportService}
+
+	documentRepo := &repositories.DocumentRepository{Db: db}
+	documentService := &services.DocumentService{
+		Repo:         documentRepo,
+		Transactions: transactionRepo,
+		Tranches:     trancheRepo,
+		Organization: cfg.Documents.Organization,
+	}
+	documentHandler := &handlers.DocumentHandler{Service: documentService}
+
+	subscriptionRepo := &repositories.SubscriptionRepository{Db: db}
+	subscriptionService := &services.SubscriptionService{
+		Repo:    subscriptionRepo,
+		Reports: reportService,
+		Users:   userService,
+		Mailer:  mailer,
+	}
+	subscriptionHandler := &handlers.SubscriptionHandler{Service: subscriptionService}
+
+	dashboardCacheTTL := 30 * time.Second
+	if cfg.Dashboard.CacheTTL != "" {
+		dashboardCacheTTL, err = time.ParseDuration(cfg.Dashboard.CacheTTL)
+		if err != nil {
+			errorLog.Fatalf("Invalid dashboard cache ttl %q: %v\n", cfg.Dashboard.CacheTTL, err)
+		}
+	}
+	dashboardService := &services.DashboardService{
+		Reports:  reportService,
+		History:  historyService,
+		Users:    userService,
+		CacheTTL: dashboardCacheTTL,
+	}
+	dashboardHandler := &handlers.DashboardHandler{Service: dashboardService}
+
+	idempotencyTTL := 24 * time.Hour
+	if cfg.Idempotency.TTL != "" {
+		idempotencyTTL, err = time.ParseDuration(cfg.Idempotency.TTL)
+		if err != nil {
+			errorLog.Fatalf("Invalid idempotency ttl %q: %v\n", cfg.Idempotency.TTL, err)
+		}
+	}
+	reportRefreshInterval := time.Minute
+	if cfg.Reporting.RefreshInterval != "" {
+		reportRefreshInterval, err = time.ParseDuration(cfg.Reporting.RefreshInterval)
+		if err != nil {
+			errorLog.Fatalf("Invalid report refresh interval %q: %v\n", cfg.Reporting.RefreshInterval, err)
+		}
+	}
+
+	notificationInterval := 10 * time.Second
+	if cfg.Notifications.DeliveryInterval != "" {
+		notificationInterval, err = time.ParseDuration(cfg.Notifications.DeliveryInterval)
+		if err != nil {
+			errorLog.Fatalf("Invalid notification delivery interval %q: %v\n", cfg.Notifications.DeliveryInterval, err)
+		}
+	}
+
+	webhookInterval := 10 * time.Second
+	if cfg.Webhooks.DeliveryInterval != "" {
+		webhookInterval, err = time.ParseDuration(cfg.Webhooks.DeliveryInterval)
+		if err != nil {
+			errorLog.Fatalf("Invalid webhook delivery interval %q: %v\n", cfg.Webhooks.DeliveryInterval, err)
+		}
+	}
+
+	idempotencyRepo := &repositories.IdempotencyRepository{Db: db}
+	idempotencyService := &services.IdempotencyService{Repo: idempotencyRepo, TTL: idempotencyTTL}
+
 	return &application{
 		errorLog:                errorLog,
 		infoLog:                 infoLog,
+		events:                  eventBus,
 		userHandler:             userHandler,
 		permissionHandler:       permissionHandler,
 		companyHandler:          companyHandler,
 		transactionHandler:      transactionHandler,
 		expenseHandler:          expenseHandler,
 		extraTransactionHandler: extraTransactionHandler,
-		fcmHandler:              fcmHandler,
+		notificationHandler:     notificationHandler,
 		categoryHandler:         categoryHandler,
 		balanceHistoryHandler:   balanceHistoryHandler,
 		tenderHandler:           tenderHandler,
@@ -145,6 +346,28 @@ func initializeApp(db *sql.DB, errorLog, infoLog *log.Logger) *application {
 		personalDebtHandler:     personalDebtHandler,
 		debtTrancheHandler:      debtTrancheHandler,
 		historyHandler:          historyHandler,
+		trashHandler:            trashHandler,
+		auditHandler:            auditHandler,
+		reportHandler:           reportHandler,
+		dashboardHandler:        dashboardHandler,
+		exportHandler:           exportHandler,
+		documentHandler:         documentHandler,
+		subscriptionHandler:     subscriptionHandler,
+		receivableHandler:       receivableHandler,
+		streamHandler:           streamHandler,
+		webhookHandler:          webhookHandler,
+		idempotencyService:      idempotencyService,
+		aggregateService:        aggregateService,
+		subscriptionService:     subscriptionService,
+		reportRefreshInterval:   reportRefreshInterval,
+		notificationService:     notificationService,
+		notificationInterval:    notificationInterval,
+		personalDebtService:     personalDebtService,
+		receivableService:       receivableService,
+		streamService:           streamService,
+		webhookService:          webhookService,
+		webhookInterval:         webhookInterval,
+		sessionService:          sessionService,
 	}
 }
 
@@ -159,13 +382,11 @@ func openDB(dsn string) (*sql.DB, error) {
 	if err != nil {
 		log.Printf("%v", err)
 		panic("failed to connect to database")
-		return nil, err
 	}
 	db.SetMaxIdleConns(35)
 	if err = db.Ping(); err != nil {
 		log.Printf("%v", err)
 		panic("failed to ping the database")
-		return nil, err
 	}
 	fmt.Println("successfully connected")
 
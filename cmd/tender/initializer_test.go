@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"path/filepath"
+	"strings"
+	"tender/internal/config"
+	"tender/internal/notify"
+	"testing"
+)
+
+func TestNotifierFallsBackToLogWithoutFirebase(t *testing.T) {
+	var cfg config.Config
+	cfg.Notifications.Provider = "fcm"
+	cfg.Notifications.ProjectID = "test"
+	cfg.Notifications.CredentialsFile = filepath.Join(t.TempDir(), "missing.json")
+	var errorLog, info bytes.Buffer
+
+	notifier := newNotifier(cfg, log.New(&errorLog, "", 0), log.New(&info, "", 0))
+
+	if _, ok := notifier.(*notify.Log); !ok {
+		t.Fatalf("got %T, want *notify.Log", notifier)
+	}
+	if !strings.Contains(errorLog.String(), "Firebase is unavailable") {
+		t.Errorf("logged %q, want the reason for falling back", errorLog.String())
+	}
+	if _, err := notifier.Send(context.Background(), notify.Message{Token: "device-token", Title: "title"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(info.String(), "push to device-token") {
+		t.Errorf("logged %q, want the notification", info.String())
+	}
+}
+
+func TestMemoryNotifierRecords(t *testing.T) {
+	var cfg config.Config
+	cfg.Notifications.Provider = "memory"
+
+	notifier := newNotifier(cfg, log.New(&bytes.Buffer{}, "", 0), log.New(&bytes.Buffer{}, "", 0))
+
+	recorder, ok := notifier.(*notify.Recorder)
+	if !ok {
+		t.Fatalf("got %T, want *notify.Recorder", notifier)
+	}
+	notifier.Send(context.Background(), notify.Message{Token: "device-token"})
+	if sent := recorder.Sent(); len(sent) != 1 || sent[0].Token != "device-token" {
+		t.Errorf("recorded %v", sent)
+	}
+}
@@ -0,0 +1,18 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// runPeriodically calls job every interval for the lifetime of the process, logging failures.
+func (app *application) runPeriodically(name string, interval time.Duration, job func(ctx context.Context) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := job(context.Background()); err != nil {
+			app.errorLog.Printf("%s: %v", name, err)
+		}
+	}
+}
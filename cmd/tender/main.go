@@ -1,17 +1,24 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"flag"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/rs/cors"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"tender/internal/config"
 	"time"
 )
 
+// shutdownTimeout bounds how long requests and event handlers may finish after a stop signal.
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	cfg := config.LoadConfig()
 
@@ -38,13 +45,63 @@ func main() {
 		}
 	}(db)
 
-	app := initializeApp(db, errorLog, infoLog)
+	app := initializeApp(cfg, db, errorLog, infoLog)
+
+	if flag.NArg() > 0 {
+		if err := app.runCommand(flag.Arg(0)); err != nil {
+			errorLog.Fatal(err)
+		}
+		return
+	}
+
+	go app.runPeriodically("purge expired idempotency keys", time.Hour, func(ctx context.Context) error {
+		_, err := app.idempotencyService.PurgeExpired(ctx)
+		return err
+	})
+	go app.runPeriodically("refresh report aggregates", app.reportRefreshInterval, func(ctx context.Context) error {
+		_, err := app.aggregateService.RefreshQueued(ctx)
+		return err
+	})
+	go app.runPeriodically("deliver notifications", app.notificationInterval, func(ctx context.Context) error {
+		_, err := app.notificationService.DeliverPending(ctx)
+		return err
+	})
+	go app.runPeriodically("send notification digests", 5*time.Minute, func(ctx context.Context) error {
+		_, err := app.notificationService.SendDigests(ctx, time.Now())
+		return err
+	})
+	go app.runPeriodically("expire device tokens", time.Hour, func(ctx context.Context) error {
+		_, err := app.notificationService.ExpireTokens(ctx, time.Now())
+		return err
+	})
+	go app.runPeriodically("send personal debt reminders", time.Hour, func(ctx context.Context) error {
+		_, err := app.personalDebtService.SendReminders(ctx, time.Now())
+		return err
+	})
+	go app.runPeriodically("send overdue receivable alerts", time.Hour, func(ctx context.Context) error {
+		_, err := app.receivableService.SendAlerts(ctx, time.Now())
+		return err
+	})
+	go app.runPeriodically("follow change stream", time.Second, app.streamService.Poll)
+	go app.runPeriodically("queue webhook events", 5*time.Second, func(ctx context.Context) error {
+		_, err := app.webhookService.QueueEvents(ctx)
+		return err
+	})
+	go app.runPeriodically("deliver webhooks", app.webhookInterval, func(ctx context.Context) error {
+		_, err := app.webhookService.DeliverPending(ctx)
+		return err
+	})
+	go app.runPeriodically("send report digests", time.Minute, func(ctx context.Context) error {
+		_, err := app.subscriptionService.RunDue(ctx, time.Now())
+		return err
+	})
 
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:19006", "exp://192.168.1.219:8081", "exp://192.168.1.82:8081", "timetodo://"},
 		AllowedMethods:   []string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
 		AllowCredentials: true,
-		AllowedHeaders:   []string{"Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "Accept", "Origin", "Cache-Control", "X-Requested-With"},
+		AllowedHeaders:   []string{"Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "Accept", "Origin", "Cache-Control", "X-Requested-With", "If-Match", "Idempotency-Key", "X-User-ID", "X-Request-ID", "Last-Event-ID"},
+		ExposedHeaders:   []string{"ETag", "Idempotent-Replayed", "X-Request-ID", "Content-Disposition"},
 	})
 
 	srv := &http.Server{
@@ -56,10 +113,27 @@ func main() {
 		WriteTimeout: 10 * time.Second,
 	}
 
-	err = srv.ListenAndServe()
-	if err != nil {
-		errorLog.Fatal(err)
+	// Event streams never go idle; they are ended so that Shutdown does not wait for them.
+	srv.RegisterOnShutdown(app.streamService.Close)
+
+	stopped, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		infoLog.Printf("Starting server on %s", *addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errorLog.Fatal(err)
+		}
+	}()
+	<-stopped.Done()
+
+	// Requests finish first, then the events they published are handled.
+	infoLog.Printf("Shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		errorLog.Printf("Shutting down server: %v", err)
+	}
+	if err := app.events.Wait(ctx); err != nil {
+		errorLog.Printf("Events still being handled were lost: %v", err)
 	}
-	infoLog.Printf("Starting server on %s", *addr)
-	select {}
 }
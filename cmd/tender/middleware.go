@@ -1,9 +1,21 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"tender/internal/export"
+	"tender/internal/models"
+	"tender/internal/requestctx"
 	"time"
 )
 
@@ -53,3 +65,183 @@ func (app *application) recoverPanic(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// identifyCaller stores the user making the request in the request context. A caller sending the
+// token issued at login as "Authorization: Bearer <token>" is authenticated; with an expired token
+// the request carries no caller, so that the client can still log in again. The X-User-ID header
+// of older clients is still accepted but taken on trust, so it never grants admin rights.
+func (app *application) identifyCaller(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			userID, err := app.sessionService.Authenticate(r.Context(), token)
+			switch {
+			case err == nil:
+				r = r.WithContext(requestctx.WithAuthenticatedCaller(r.Context(), userID))
+			case !errors.Is(err, models.ErrSessionNotFound):
+				app.serverError(w, err)
+				return
+			}
+		} else if userID, err := strconv.Atoi(r.Header.Get("X-User-ID")); err == nil && userID > 0 {
+			r = r.WithContext(requestctx.WithCaller(r.Context(), userID))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tagRequest gives every request an ID, taken from X-Request-ID when the client sends one, and
+// records it with the client IP so changes can be traced back to the request that made them.
+func (app *application) tagRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := requestctx.RequestInfoFrom(r.Context()); ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requestID := r.Header.Get("X-Request-ID")
+		if requestID == "" || len(requestID) > 64 {
+			buf := make([]byte, 16)
+			rand.Read(buf)
+			requestID = hex.EncodeToString(buf)
+		}
+
+		ip := r.RemoteAddr
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			ip, _, _ = strings.Cut(forwarded, ",")
+		} else if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ip = host
+		}
+
+		w.Header().Set("X-Request-ID", requestID)
+		ctx := requestctx.WithRequestInfo(r.Context(), requestctx.RequestInfo{ID: requestID, IP: strings.TrimSpace(ip)})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// requireAdmin rejects requests that do not come from the admin account, authenticated with its
+// session token.
+func (app *application) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := requestctx.AuthenticatedCallerFrom(r.Context())
+		if !ok {
+			app.clientErrorWithMessage(w, "log in as the administrator and send the session token", http.StatusUnauthorized)
+			return
+		}
+		if userID != models.AdminUserID {
+			app.clientErrorWithMessage(w, "only the administrator can perform this action", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// exportable serves a list as a CSV or XLSX download when the client asks for one with ?format=
+// or the Accept header, and hands JSON requests on to the list's own handler.
+func (app *application) exportable(list string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			format, err := export.Requested(r)
+			if err != nil {
+				app.clientErrorWithMessage(w, "unsupported format, use json, csv or xlsx", http.StatusBadRequest)
+				return
+			}
+			if format == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			app.exportHandler.ExportList(w, r, list, format)
+		})
+	}
+}
+
+// idempotent makes create endpoints safe to retry. The first response for an Idempotency-Key
+// is stored per caller and replayed for retries; reusing the key with another payload is rejected.
+func (app *application) idempotent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > 255 {
+			app.clientErrorWithMessage(w, "Idempotency-Key must not exceed 255 characters", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			app.clientError(w, http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		callerID, _ := requestctx.CallerFrom(r.Context())
+		stored, err := app.idempotencyService.Begin(r.Context(), callerID, key, r.Method, r.URL.Path, body)
+		if err != nil {
+			switch {
+			case errors.Is(err, models.ErrIdempotencyKeyReused):
+				app.clientErrorWithMessage(w, err.Error(), http.StatusUnprocessableEntity)
+			case errors.Is(err, models.ErrIdempotencyKeyInFlight):
+				app.clientErrorWithMessage(w, err.Error(), http.StatusConflict)
+			default:
+				app.serverError(w, err)
+			}
+			return
+		}
+
+		if stored != nil {
+			if stored.ContentType != nil {
+				w.Header().Set("Content-Type", *stored.ContentType)
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.StatusCode)
+			w.Write(stored.ResponseBody)
+			return
+		}
+
+		// The outcome is saved even if the client has already gone away.
+		ctx := context.WithoutCancel(r.Context())
+		rec := &responseRecorder{ResponseWriter: w}
+		completed := false
+		defer func() {
+			if !completed {
+				app.idempotencyService.Release(ctx, callerID, key)
+			}
+		}()
+
+		next.ServeHTTP(rec, r)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		if rec.status >= http.StatusInternalServerError {
+			return
+		}
+		if err := app.idempotencyService.Complete(ctx, callerID, key, rec.status, rec.Header().Get("Content-Type"), rec.body.Bytes()); err != nil {
+			app.errorLog.Printf("storing idempotent response for key %q: %v", key, err)
+			return
+		}
+		completed = true
+	})
+}
+
+// responseRecorder passes a response through while keeping a copy of its status and body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
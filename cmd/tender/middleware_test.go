@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/DATA-DOG/go-sqlmock"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"tender/internal/repositories"
+	"tender/internal/services"
+	"testing"
+)
+
+func TestAdminRoutesRequireASession(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	app := &application{
+		errorLog:       log.New(&bytes.Buffer{}, "", 0),
+		sessionService: &services.SessionService{Repo: &repositories.SessionRepository{Db: db}},
+	}
+	admin := app.identifyCaller(app.requireAdmin(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	mock.ExpectQuery("FROM user_sessions").WithArgs(tokenHashOf("admin-token"), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	mock.ExpectQuery("FROM user_sessions").WithArgs(tokenHashOf("user-token"), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(2))
+	mock.ExpectQuery("FROM user_sessions").WithArgs(tokenHashOf("expired"), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+
+	for _, tc := range []struct {
+		name   string
+		header string
+		value  string
+		want   int
+	}{
+		{"admin session", "Authorization", "Bearer admin-token", http.StatusOK},
+		{"user session", "Authorization", "Bearer user-token", http.StatusForbidden},
+		{"expired session", "Authorization", "Bearer expired", http.StatusUnauthorized},
+		{"claimed admin", "X-User-ID", "1", http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(http.MethodDelete, "/trash", nil)
+		req.Header.Set(tc.header, tc.value)
+		rec := httptest.NewRecorder()
+		admin.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, rec.Code, tc.want)
+		}
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tokenHashOf is the hash a session token is stored under.
+func tokenHashOf(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
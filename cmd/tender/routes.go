@@ -7,40 +7,50 @@ import (
 )
 
 func (app *application) routes() http.Handler {
-	standardMiddleware := alice.New(app.recoverPanic, app.logRequest, secureHeaders, makeResponseJSON)
+	standardMiddleware := alice.New(app.recoverPanic, app.tagRequest, app.logRequest, app.identifyCaller, secureHeaders, makeResponseJSON)
 
 	dynamicMiddleware := alice.New()
 
+	// create endpoints honour the Idempotency-Key header so retried requests are not stored twice
+	createMiddleware := dynamicMiddleware.Append(app.idempotent)
+
 	mux := pat.New()
 
 	// USERS
-	mux.Post("/users/signup", dynamicMiddleware.ThenFunc(app.userHandler.SignUp))                                    // sign up user
-	mux.Post("/users/login", dynamicMiddleware.ThenFunc(app.userHandler.LogIn))                                      // login
+	mux.Post("/users/signup", createMiddleware.ThenFunc(app.userHandler.SignUp))                                     // sign up user
+	mux.Post("/users/login", dynamicMiddleware.ThenFunc(app.userHandler.LogIn))                                      // login; the response holds the session token
+	mux.Post("/users/logout", dynamicMiddleware.ThenFunc(app.userHandler.LogOut))                                    // end the session of the bearer token
 	mux.Get("/users", standardMiddleware.ThenFunc(app.userHandler.GetAllUsers))                                      // get all users
 	mux.Get("/users/details/:id", standardMiddleware.ThenFunc(app.userHandler.GetUserByID))                          // get one user info http://localhost:4000/clients/details/1
 	mux.Del("/users/:id", standardMiddleware.ThenFunc(app.userHandler.DeleteUserByID))                               // delete user by id
 	mux.Put("/users/:id", standardMiddleware.ThenFunc(app.userHandler.UpdateUser))                                   // update user by id
+	mux.Patch("/users/:id", standardMiddleware.ThenFunc(app.userHandler.PatchUser))                                  // partially update user by id (JSON merge patch)
 	mux.Get("/users/balance/:id", standardMiddleware.ThenFunc(app.userHandler.GetBalance))                           // get user balance by id
 	mux.Get("/users/extra_transactions", standardMiddleware.ThenFunc(app.userHandler.GetUserTransactionDifferences)) // get user balance by id
 	mux.Put("/users/balance/update/:id", standardMiddleware.ThenFunc(app.userHandler.UpdateBalance))                 // update user balance
 	mux.Put("/users/password/:id", standardMiddleware.ThenFunc(app.userHandler.ChangePassword))                      // update user balance
 
 	// PERMISSIONS
-	mux.Post("/permissions", dynamicMiddleware.ThenFunc(app.permissionHandler.AddPermission))                        // add a new permission
+	mux.Post("/permissions", createMiddleware.ThenFunc(app.permissionHandler.AddPermission))                         // add a new permission
 	mux.Get("/permissions/user/:user_id", standardMiddleware.ThenFunc(app.permissionHandler.GetPermissionsByUserID)) // get all permissions by user ID
 	mux.Put("/permissions/:id", standardMiddleware.ThenFunc(app.permissionHandler.UpdatePermission))                 // update a permission by id
 	mux.Del("/permissions/:id", standardMiddleware.ThenFunc(app.permissionHandler.DeletePermission))                 // delete a permission by id
 
 	// COMPANY
-	mux.Post("/companies", dynamicMiddleware.ThenFunc(app.companyHandler.CreateCompany))      // Create a new company
-	mux.Get("/companies", standardMiddleware.ThenFunc(app.companyHandler.GetAllCompanies))    // Get all companies
-	mux.Get("/companies/:id", standardMiddleware.ThenFunc(app.companyHandler.GetCompanyByID)) // Get company by ID
-	mux.Put("/companies/:id", standardMiddleware.ThenFunc(app.companyHandler.UpdateCompany))  // Update company by ID
-	mux.Del("/companies/:id", standardMiddleware.ThenFunc(app.companyHandler.DeleteCompany))  // Delete company by ID
+	mux.Post("/companies", createMiddleware.ThenFunc(app.companyHandler.CreateCompany))                                                    // Create a new company
+	mux.Get("/companies", standardMiddleware.ThenFunc(app.companyHandler.GetAllCompanies))                                                 // Get all companies
+	mux.Get("/companies/:id", standardMiddleware.ThenFunc(app.companyHandler.GetCompanyByID))                                              // Get company by ID
+	mux.Get("/companies/:id/reconciliation.pdf", standardMiddleware.ThenFunc(app.documentHandler.Reconciliation))                          // Reconciliation act, ?start_date=&end_date=
+	mux.Get("/companies/:id/payment_terms", standardMiddleware.ThenFunc(app.receivableHandler.GetPaymentTerms))                            // Days to pay after completion and to escalation
+	mux.Put("/companies/:id/payment_terms", dynamicMiddleware.Append(app.requireAdmin).ThenFunc(app.receivableHandler.UpdatePaymentTerms)) // Set the company's payment terms (admin only)
+	mux.Del("/companies/:id/payment_terms", dynamicMiddleware.Append(app.requireAdmin).ThenFunc(app.receivableHandler.ResetPaymentTerms))  // Return the company to the default terms (admin only)
+	mux.Put("/companies/:id", standardMiddleware.ThenFunc(app.companyHandler.UpdateCompany))                                               // Update company by ID
+	mux.Del("/companies/:id", standardMiddleware.ThenFunc(app.companyHandler.DeleteCompany))                                               // Delete company by ID
 
 	// TRANSACTION
-	mux.Post("/transactions", dynamicMiddleware.ThenFunc(app.transactionHandler.CreateTransaction))                                                 // Create a new transaction
-	mux.Get("/transactions", standardMiddleware.ThenFunc(app.transactionHandler.GetAllTransactions))                                                // Get all transactions
+	mux.Post("/transactions", createMiddleware.ThenFunc(app.transactionHandler.CreateTransaction))                                                  // Create a new transaction
+	mux.Get("/transactions", standardMiddleware.Append(app.exportable("transactions")).ThenFunc(app.transactionHandler.GetAllTransactions))         // Get all transactions
+	mux.Get("/transactions/:id/statement.pdf", standardMiddleware.ThenFunc(app.documentHandler.Statement))                                          // Transaction statement with expenses and payments
 	mux.Get("/transactions/:id", standardMiddleware.ThenFunc(app.transactionHandler.GetTransactionByID))                                            // Get transaction by ID 	// Get transaction by ID
 	mux.Get("/transactions/user/:id", standardMiddleware.ThenFunc(app.transactionHandler.GetTransactionsByUser))                                    // Get transaction by user ID
 	mux.Get("/transactions/company/:id", standardMiddleware.ThenFunc(app.transactionHandler.GetTransactionsByCompany))                              // Get transaction by company ID
@@ -53,20 +63,21 @@ func (app *application) routes() http.Handler {
 	mux.Get("/transactions/tranches/company/debt", standardMiddleware.ThenFunc(app.transactionHandler.GetCompanyDebt))                              // Get transaction by user and company ID
 	mux.Get("/transactions/tranches/id/debt/:id", standardMiddleware.ThenFunc(app.transactionHandler.GetCompanyDebtId))                             // Get transaction by user and company ID
 	mux.Put("/transactions/:id", standardMiddleware.ThenFunc(app.transactionHandler.UpdateTransaction))                                             // Update transaction by ID
+	mux.Patch("/transactions/:id", standardMiddleware.ThenFunc(app.transactionHandler.PatchTransaction))                                            // Partially update transaction by ID (JSON merge patch)
 	mux.Del("/transactions/:id", standardMiddleware.ThenFunc(app.transactionHandler.DeleteTransaction))                                             // Delete transaction by ID
 
 	// EXTRA TRANSACTIONS
-	mux.Post("/extra_transactions", dynamicMiddleware.ThenFunc(app.extraTransactionHandler.CreateExtraTransaction))                            // Create a new extra transaction
-	mux.Get("/extra_transactions", standardMiddleware.ThenFunc(app.extraTransactionHandler.GetAllExtraTransactions))                           // Get all extra transactions
-	mux.Get("/extra_transactions/:id", standardMiddleware.ThenFunc(app.extraTransactionHandler.GetExtraTransactionByID))                       // Get extra transaction by ID
-	mux.Get("/extra_transactions/user/:id", standardMiddleware.ThenFunc(app.extraTransactionHandler.GetExtraTransactionsByUser))               // Get extra transactions by user ID
-	mux.Put("/extra_transactions/:id", standardMiddleware.ThenFunc(app.extraTransactionHandler.UpdateExtraTransaction))                        // Update extra transaction by ID
-	mux.Del("/extra_transactions/:id", standardMiddleware.ThenFunc(app.extraTransactionHandler.DeleteExtraTransaction))                        // Delete extra transaction by ID
-	mux.Get("/extra_transactions/realization/:id", standardMiddleware.ThenFunc(app.extraTransactionHandler.GetExtraTransactionCountsByUserID)) // Get extra transactions by user ID
+	mux.Post("/extra_transactions", createMiddleware.ThenFunc(app.extraTransactionHandler.CreateExtraTransaction))                                                // Create a new extra transaction
+	mux.Get("/extra_transactions", standardMiddleware.Append(app.exportable("extra_transactions")).ThenFunc(app.extraTransactionHandler.GetAllExtraTransactions)) // Get all extra transactions
+	mux.Get("/extra_transactions/:id", standardMiddleware.ThenFunc(app.extraTransactionHandler.GetExtraTransactionByID))                                          // Get extra transaction by ID
+	mux.Get("/extra_transactions/user/:id", standardMiddleware.ThenFunc(app.extraTransactionHandler.GetExtraTransactionsByUser))                                  // Get extra transactions by user ID
+	mux.Put("/extra_transactions/:id", standardMiddleware.ThenFunc(app.extraTransactionHandler.UpdateExtraTransaction))                                           // Update extra transaction by ID
+	mux.Del("/extra_transactions/:id", standardMiddleware.ThenFunc(app.extraTransactionHandler.DeleteExtraTransaction))                                           // Delete extra transaction by ID
+	mux.Get("/extra_transactions/realization/:id", standardMiddleware.ThenFunc(app.extraTransactionHandler.GetExtraTransactionCountsByUserID))                    // Get extra transactions by user ID
 
 	// PERSONAL EXPENSES
-	mux.Post("/expenses", dynamicMiddleware.ThenFunc(app.expenseHandler.CreatePersonalExpense))                                         // Create a new expense
-	mux.Get("/expenses", standardMiddleware.ThenFunc(app.expenseHandler.GetAllPersonalExpenses))                                        // Get all expenses
+	mux.Post("/expenses", createMiddleware.ThenFunc(app.expenseHandler.CreatePersonalExpense))                                          // Create a new expense
+	mux.Get("/expenses", standardMiddleware.Append(app.exportable("expenses")).ThenFunc(app.expenseHandler.GetAllPersonalExpenses))     // Get all expenses
 	mux.Get("/expenses/month", standardMiddleware.ThenFunc(app.expenseHandler.GetAllPersonalExpensesSummary))                           // Get all expenses
 	mux.Get("/expenses/month/subcategory/:id", standardMiddleware.ThenFunc(app.expenseHandler.GetPersonalExpensesSummaryBySubCategory)) // Get all expenses
 	mux.Get("/expenses/month/category/:id", standardMiddleware.ThenFunc(app.expenseHandler.GetPersonalExpensesSummaryByCategory))       // Get all expenses
@@ -75,7 +86,25 @@ func (app *application) routes() http.Handler {
 	mux.Put("/expenses/:id", standardMiddleware.ThenFunc(app.expenseHandler.UpdatePersonalExpense))                                     // Update expense by ID
 	mux.Del("/expenses/:id", standardMiddleware.ThenFunc(app.expenseHandler.DeletePersonalExpense))                                     // Delete expense by ID
 
+	// DASHBOARD
+	mux.Get("/dashboard", standardMiddleware.ThenFunc(app.dashboardHandler.GetDashboard)) // Home screen summary for the caller
+
 	// REPORTS
+	mux.Post("/reports/query", standardMiddleware.ThenFunc(app.reportHandler.Query))                                             // Pivot report over transactions
+	mux.Get("/reports/pnl", standardMiddleware.ThenFunc(app.reportHandler.ProfitAndLoss))                                        // Profit and loss, ?format=csv or xlsx to export
+	mux.Get("/receivables/overdue", standardMiddleware.ThenFunc(app.receivableHandler.GetOverdue))                               // Transactions unpaid past their payment terms
+	mux.Get("/receivables/alerts", dynamicMiddleware.Append(app.requireAdmin).ThenFunc(app.receivableHandler.GetAlerts))         // Overdue alerts sent, ?company_id=&limit= (admin only)
+	mux.Get("/reports/aging", standardMiddleware.ThenFunc(app.reportHandler.Aging))                                              // Receivables aging by company
+	mux.Get("/reports/cashflow", standardMiddleware.ThenFunc(app.reportHandler.CashFlowForecast))                                // Weekly or monthly cash-flow forecast
+	mux.Get("/reports/leaderboard", standardMiddleware.ThenFunc(app.reportHandler.Leaderboard))                                  // User ranking with trend
+	mux.Get("/reports/aggregates/check", dynamicMiddleware.Append(app.requireAdmin).ThenFunc(app.reportHandler.CheckAggregates)) // Compare aggregates with live data (admin only)
+
+	// REPORT SUBSCRIPTIONS
+	mux.Get("/subscriptions", standardMiddleware.ThenFunc(app.subscriptionHandler.ListSubscriptions))      // Caller's scheduled report emails
+	mux.Post("/subscriptions", createMiddleware.ThenFunc(app.subscriptionHandler.CreateSubscription))      // Subscribe the caller to a report
+	mux.Get("/subscriptions/:id/runs", standardMiddleware.ThenFunc(app.subscriptionHandler.GetRuns))       // Delivery history of a subscription
+	mux.Del("/subscriptions/:id", standardMiddleware.ThenFunc(app.subscriptionHandler.DeleteSubscription)) // Unsubscribe
+
 	// company month
 	mux.Get("/reports/company/month/global", standardMiddleware.ThenFunc(app.transactionHandler.GetMonthlyAmountsByGlobal))               //global - company - month
 	mux.Get("/reports/company/month/year", standardMiddleware.ThenFunc(app.transactionHandler.GetMonthlyAmountsByYear))                   //year - company - month
@@ -102,18 +131,29 @@ func (app *application) routes() http.Handler {
 	mux.Get("/reports/users/company/user/year/month", standardMiddleware.ThenFunc(app.transactionHandler.GetTotalAmountByCompanyForUserYearAndMonth)) //user and year and company - users - month
 
 	// NOTIFY
-	mux.Post("/notify", dynamicMiddleware.ThenFunc(app.fcmHandler.NotifyChange))
-	mux.Post("/notify/token/create", dynamicMiddleware.ThenFunc(app.fcmHandler.CreateToken))
-	mux.Del("/notify/token/:id", dynamicMiddleware.ThenFunc(app.fcmHandler.DeleteToken))
-	mux.Post("/notify/history", dynamicMiddleware.ThenFunc(app.fcmHandler.ShowNotifyHistory))
-	mux.Del("/notify/history/:id", dynamicMiddleware.ThenFunc(app.fcmHandler.DeleteNotifyHistory))
+	mux.Post("/notify", dynamicMiddleware.ThenFunc(app.notificationHandler.NotifyChange))
+	mux.Get("/notify/outbox", dynamicMiddleware.Append(app.requireAdmin).ThenFunc(app.notificationHandler.GetOutbox)) // Delivery status of queued notifications (admin only)
+	mux.Post("/notify/token/create", createMiddleware.ThenFunc(app.notificationHandler.CreateToken))                  // Register or refresh the caller's device token
+	mux.Get("/notify/tokens", standardMiddleware.ThenFunc(app.notificationHandler.GetTokens))                         // Caller's registered devices
+	mux.Post("/notify/token/logout", dynamicMiddleware.ThenFunc(app.notificationHandler.Logout))                      // Stop pushes to the device signing out, or to all devices
+	mux.Del("/notify/token/:id", dynamicMiddleware.ThenFunc(app.notificationHandler.DeleteToken))                     // Remove one of the caller's device tokens
+	mux.Post("/notify/history", dynamicMiddleware.ThenFunc(app.notificationHandler.ShowNotifyHistory))
+	mux.Del("/notify/history/:id", dynamicMiddleware.ThenFunc(app.notificationHandler.DeleteNotifyHistory))
+
+	// NOTIFICATION INBOX
+	mux.Get("/notifications/preferences", standardMiddleware.ThenFunc(app.notificationHandler.GetPreferences))    // Caller's channels, quiet hours and digest
+	mux.Put("/notifications/preferences", standardMiddleware.ThenFunc(app.notificationHandler.UpdatePreferences)) // Replace the caller's notification settings
+	mux.Get("/notifications/unread_count", standardMiddleware.ThenFunc(app.notificationHandler.GetUnreadCount))   // Number of the caller's unread notifications
+	mux.Post("/notifications/read_all", dynamicMiddleware.ThenFunc(app.notificationHandler.MarkAllRead))          // Mark all of the caller's notifications as read
+	mux.Post("/notifications/:id/read", dynamicMiddleware.ThenFunc(app.notificationHandler.MarkRead))             // Mark one notification as read
+	mux.Get("/notifications", standardMiddleware.ThenFunc(app.notificationHandler.GetInbox))                      // Caller's inbox, ?unread=true&cursor=&limit=
 
 	// PASSWORD RECOVERY
 	mux.Post("/password/recovery", dynamicMiddleware.ThenFunc(app.userHandler.SendRecoveryHandler))
 	mux.Get("/password/recovery/mail", dynamicMiddleware.ThenFunc(app.userHandler.PasswordRecoveryHandler))
 
 	// CATEGORY
-	mux.Post("/categories", dynamicMiddleware.ThenFunc(app.categoryHandler.CreateCategory))                      // Create a new category
+	mux.Post("/categories", createMiddleware.ThenFunc(app.categoryHandler.CreateCategory))                       // Create a new category
 	mux.Get("/categories", standardMiddleware.ThenFunc(app.categoryHandler.GetAllCategories))                    // Get all categories
 	mux.Get("/categories/parent/:id", standardMiddleware.ThenFunc(app.categoryHandler.GetAllCategoriesByParent)) // Get all categories
 	mux.Get("/categories/:id", standardMiddleware.ThenFunc(app.categoryHandler.GetCategoryByID))                 // Get category by ID
@@ -121,23 +161,24 @@ func (app *application) routes() http.Handler {
 	mux.Del("/categories/:id", standardMiddleware.ThenFunc(app.categoryHandler.DeleteCategory))                  // Delete category by ID
 
 	// BALANCE HISTORY
-	mux.Post("/balance-history", dynamicMiddleware.ThenFunc(app.balanceHistoryHandler.CreateBalanceHistory))                       // Create a new balance history record
+	mux.Post("/balance-history", createMiddleware.ThenFunc(app.balanceHistoryHandler.CreateBalanceHistory))                        // Create a new balance history record
 	mux.Get("/balance-history/:id", standardMiddleware.ThenFunc(app.balanceHistoryHandler.GetBalanceHistoryByUserID))              // Get balance history record by user ID
 	mux.Get("/balance-history/category/:id", standardMiddleware.ThenFunc(app.balanceHistoryHandler.GetBalanceHistoryByCategoryID)) // Get balance history record by user ID
 	mux.Put("/balance-history/:id", standardMiddleware.ThenFunc(app.balanceHistoryHandler.UpdateBalanceHistory))                   // Update balance history record by ID
 	mux.Del("/balance-history/:id", standardMiddleware.ThenFunc(app.balanceHistoryHandler.DeleteBalanceHistory))                   // Delete balance history record by ID
 
 	// TENDERS ( GOIK and GOPP)
-	mux.Post("/tenders", dynamicMiddleware.ThenFunc(app.tenderHandler.CreateTender))                                  // Create a new tender
-	mux.Get("/tenders", standardMiddleware.ThenFunc(app.tenderHandler.GetAllTenders))                                 // Get all tenders
-	mux.Get("/tenders/debt/company", standardMiddleware.ThenFunc(app.tenderHandler.GetTotalNetByCompany))             // Get all tenders
-	mux.Get("/tenders/:id", standardMiddleware.ThenFunc(app.tenderHandler.GetTenderByID))                             // Get tender by ID
-	mux.Get("/tenders/user/:id", standardMiddleware.ThenFunc(app.tenderHandler.GetTendersByUserID))                   // Get tender by user ID
-	mux.Get("/tenders/company/:id", standardMiddleware.ThenFunc(app.tenderHandler.GetTendersByCompanyID))             // Get tender by user ID
-	mux.Get("/tenders/realization/sum", standardMiddleware.ThenFunc(app.tenderHandler.GetAllTendersSum))              // Get tender by user ID
-	mux.Get("/tenders/realization/count/:id", standardMiddleware.ThenFunc(app.tenderHandler.GetTenderCountsByUserID)) // Get tender by user ID
-	mux.Put("/tenders/:id", standardMiddleware.ThenFunc(app.tenderHandler.UpdateTender))                              // Update tender by ID
-	mux.Del("/tenders/:id", standardMiddleware.ThenFunc(app.tenderHandler.DeleteTender))                              // Delete tender by ID
+	mux.Post("/tenders", createMiddleware.ThenFunc(app.tenderHandler.CreateTender))                                     // Create a new tender
+	mux.Get("/tenders", standardMiddleware.Append(app.exportable("tenders")).ThenFunc(app.tenderHandler.GetAllTenders)) // Get all tenders
+	mux.Get("/tenders/debt/company", standardMiddleware.ThenFunc(app.tenderHandler.GetTotalNetByCompany))               // Get all tenders
+	mux.Get("/tenders/:id", standardMiddleware.ThenFunc(app.tenderHandler.GetTenderByID))                               // Get tender by ID
+	mux.Get("/tenders/user/:id", standardMiddleware.ThenFunc(app.tenderHandler.GetTendersByUserID))                     // Get tender by user ID
+	mux.Get("/tenders/company/:id", standardMiddleware.ThenFunc(app.tenderHandler.GetTendersByCompanyID))               // Get tender by user ID
+	mux.Get("/tenders/realization/sum", standardMiddleware.ThenFunc(app.tenderHandler.GetAllTendersSum))                // Get tender by user ID
+	mux.Get("/tenders/realization/count/:id", standardMiddleware.ThenFunc(app.tenderHandler.GetTenderCountsByUserID))   // Get tender by user ID
+	mux.Put("/tenders/:id", standardMiddleware.ThenFunc(app.tenderHandler.UpdateTender))                                // Update tender by ID
+	mux.Patch("/tenders/:id", standardMiddleware.ThenFunc(app.tenderHandler.PatchTender))                               // Partially update tender by ID (JSON merge patch)
+	mux.Del("/tenders/:id", standardMiddleware.ThenFunc(app.tenderHandler.DeleteTender))                                // Delete tender by ID
 
 	// SUMS ALL TABLES
 	mux.Get("/sums/all/:id", standardMiddleware.ThenFunc(app.sumHandler.GetSumsByUserID))
@@ -145,7 +186,8 @@ func (app *application) routes() http.Handler {
 
 	mux.Get("/sums/:id", standardMiddleware.ThenFunc(app.clientHandler.GetClientData))
 
-	mux.Post("/history/all", standardMiddleware.ThenFunc(app.historyHandler.GetAllHistory))
+	mux.Post("/history/all", standardMiddleware.ThenFunc(app.historyHandler.GetAllHistory))   // History as an array, paged with offset
+	mux.Post("/history/feed", standardMiddleware.ThenFunc(app.historyHandler.GetHistoryFeed)) // History page with next_cursor, paged with cursor
 
 	//REALIZATION
 	mux.Post("/data/user/:user_id/status/:status", standardMiddleware.ThenFunc(app.transactionHandler.GetAllByUserIDAndStatus))
@@ -157,41 +199,66 @@ func (app *application) routes() http.Handler {
 	mux.Post("/data/extra/date/company", standardMiddleware.ThenFunc(app.extraTransactionHandler.GetAllExtraTransactionsByDateRangeCompany))
 
 	// TRANCHE
-	mux.Post("/tranches", dynamicMiddleware.ThenFunc(app.trancheHandler.CreateTranche))                                             // Create a new tranche
+	mux.Post("/tranches", createMiddleware.ThenFunc(app.trancheHandler.CreateTranche))                                              // Create a new tranche
 	mux.Get("/tranches/:id", standardMiddleware.ThenFunc(app.trancheHandler.GetTrancheByID))                                        // Get tranche by ID
 	mux.Put("/tranches", standardMiddleware.ThenFunc(app.trancheHandler.UpdateTranche))                                             // Update tranche by ID
+	mux.Patch("/tranches/:id", standardMiddleware.ThenFunc(app.trancheHandler.PatchTranche))                                        // Partially update tranche by ID (JSON merge patch)
 	mux.Del("/tranches/:id", standardMiddleware.ThenFunc(app.trancheHandler.DeleteTranche))                                         // Delete tranche by ID
 	mux.Get("/tranches/transaction/:transaction_id", standardMiddleware.ThenFunc(app.trancheHandler.GetAllTranchesByTransactionID)) // Get all tranches by transaction_id
 
 	// DEBT TRANCHE
-	mux.Post("/tranches/debt", dynamicMiddleware.ThenFunc(app.debtTrancheHandler.CreateDebtTranche))                              // Create a new debt tranche
+	mux.Post("/tranches/debt", createMiddleware.ThenFunc(app.debtTrancheHandler.CreateDebtTranche))                               // Create a new debt tranche
 	mux.Get("/tranches/debt/:id", standardMiddleware.ThenFunc(app.debtTrancheHandler.GetDebtTrancheByID))                         // Get debt tranche by ID
 	mux.Put("/tranches/debt", standardMiddleware.ThenFunc(app.debtTrancheHandler.UpdateDebtTranche))                              // Update debt tranche by ID
 	mux.Del("/tranches/debt/:id", standardMiddleware.ThenFunc(app.debtTrancheHandler.DeleteDebtTranche))                          // Delete debt tranche by ID
 	mux.Get("/tranches/all/debt/:debt_id", standardMiddleware.ThenFunc(app.debtTrancheHandler.GetAllDebtTranchesByTransactionID)) // Get all debt tranches by debt_id
 
 	// CHANGE
-	mux.Post("/changes", dynamicMiddleware.ThenFunc(app.changeHandler.CreateChange))                                             // Create a new change
+	mux.Post("/changes", createMiddleware.ThenFunc(app.changeHandler.CreateChange))                                              // Create a new change
 	mux.Get("/changes/:id", standardMiddleware.ThenFunc(app.changeHandler.GetChangeByID))                                        // Get change by ID
 	mux.Put("/changes", standardMiddleware.ThenFunc(app.changeHandler.UpdateChange))                                             // Update change by ID
 	mux.Del("/changes/:id", standardMiddleware.ThenFunc(app.changeHandler.DeleteChange))                                         // Delete change by ID
 	mux.Get("/changes/transaction/:transaction_id", standardMiddleware.ThenFunc(app.changeHandler.GetAllChangesByTransactionID)) // Get all changes by transaction_id
 
 	// BALANCE CATEGORY
-	mux.Post("/balance_categories", dynamicMiddleware.ThenFunc(app.balanceCategoryHandler.CreateBalanceCategory))      // Create a new balance category
+	mux.Post("/balance_categories", createMiddleware.ThenFunc(app.balanceCategoryHandler.CreateBalanceCategory))       // Create a new balance category
 	mux.Get("/balance_categories/:id", standardMiddleware.ThenFunc(app.balanceCategoryHandler.GetBalanceCategoryByID)) // Get balance category by ID
 	mux.Put("/balance_categories", standardMiddleware.ThenFunc(app.balanceCategoryHandler.UpdateBalanceCategory))      // Update balance category by ID
 	mux.Del("/balance_categories/:id", standardMiddleware.ThenFunc(app.balanceCategoryHandler.DeleteBalanceCategory))  // Delete balance category by ID
 	mux.Get("/balance_categories", standardMiddleware.ThenFunc(app.balanceCategoryHandler.GetAllBalanceCategories))    // Get all balance categories
 
 	// PERSONAL DEBTS
-	mux.Post("/personal_debts", dynamicMiddleware.ThenFunc(app.personalDebtHandler.CreatePersonalDebt))                     // Create a new personal debt
-	mux.Get("/personal_debts/:id", standardMiddleware.ThenFunc(app.personalDebtHandler.GetPersonalDebtByID))                // Get personal debt by ID
-	mux.Put("/personal_debts", standardMiddleware.ThenFunc(app.personalDebtHandler.UpdatePersonalDebt))                     // Update personal debt by ID
-	mux.Del("/personal_debts/:id", standardMiddleware.ThenFunc(app.personalDebtHandler.DeletePersonalDebt))                 // Delete personal debt by ID
-	mux.Get("/personal_debts", standardMiddleware.ThenFunc(app.personalDebtHandler.GetAllPersonalDebts))                    // Get all personal debts
-	mux.Get("/personal_debts/status/:id", standardMiddleware.ThenFunc(app.personalDebtHandler.GetAllPersonalDebtsByStatus)) // Get all personal debts
-	mux.Get("/personal_debts/type/:id", standardMiddleware.ThenFunc(app.personalDebtHandler.GetAllPersonalDebtsByType))     // Get all personal debts
+	mux.Post("/personal_debts", createMiddleware.ThenFunc(app.personalDebtHandler.CreatePersonalDebt))                                            // Create a new personal debt
+	mux.Get("/personal_debts/overdue", standardMiddleware.ThenFunc(app.personalDebtHandler.GetOverdueDebts))                                      // Unpaid personal debts past their return date
+	mux.Get("/personal_debts/:id", standardMiddleware.ThenFunc(app.personalDebtHandler.GetPersonalDebtByID))                                      // Get personal debt by ID
+	mux.Put("/personal_debts", standardMiddleware.ThenFunc(app.personalDebtHandler.UpdatePersonalDebt))                                           // Update personal debt by ID
+	mux.Patch("/personal_debts/:id", standardMiddleware.ThenFunc(app.personalDebtHandler.PatchPersonalDebt))                                      // Partially update personal debt by ID (JSON merge patch)
+	mux.Del("/personal_debts/:id", standardMiddleware.ThenFunc(app.personalDebtHandler.DeletePersonalDebt))                                       // Delete personal debt by ID
+	mux.Get("/personal_debts", standardMiddleware.Append(app.exportable("personal_debts")).ThenFunc(app.personalDebtHandler.GetAllPersonalDebts)) // Get all personal debts
+	mux.Get("/personal_debts/status/:id", standardMiddleware.ThenFunc(app.personalDebtHandler.GetAllPersonalDebtsByStatus))                       // Get all personal debts
+	mux.Get("/personal_debts/type/:id", standardMiddleware.ThenFunc(app.personalDebtHandler.GetAllPersonalDebtsByType))                           // Get all personal debts
+
+	// AUDIT
+	mux.Get("/audit", dynamicMiddleware.Append(app.requireAdmin).ThenFunc(app.auditHandler.GetAuditLog)) // Change history of every entity, e.g. /audit?entity=transaction&id=5 (admin only)
+	mux.Get("/events/stream", standardMiddleware.ThenFunc(app.streamHandler.Stream))                     // Live changes the caller may see, as Server-Sent Events; needs the Authorization or X-User-ID header
+
+	// WEBHOOKS (admin only)
+	webhookMiddleware := dynamicMiddleware.Append(app.requireAdmin)
+	mux.Get("/webhooks/events", webhookMiddleware.ThenFunc(app.webhookHandler.GetEventTypes))                             // Event types webhooks can subscribe to
+	mux.Post("/webhooks", webhookMiddleware.ThenFunc(app.webhookHandler.CreateWebhook))                                   // Register a webhook; the response holds its signing secret
+	mux.Get("/webhooks", webhookMiddleware.ThenFunc(app.webhookHandler.ListWebhooks))                                     // All webhooks
+	mux.Get("/webhooks/:id", webhookMiddleware.ThenFunc(app.webhookHandler.GetWebhook))                                   // Get webhook by ID
+	mux.Put("/webhooks/:id", webhookMiddleware.ThenFunc(app.webhookHandler.UpdateWebhook))                                // Update a webhook, or turn it on or off
+	mux.Del("/webhooks/:id", webhookMiddleware.ThenFunc(app.webhookHandler.DeleteWebhook))                                // Delete a webhook with its deliveries
+	mux.Post("/webhooks/:id/ping", webhookMiddleware.ThenFunc(app.webhookHandler.PingWebhook))                            // Send a test event now
+	mux.Get("/webhooks/:id/deliveries", webhookMiddleware.ThenFunc(app.webhookHandler.ListDeliveries))                    // Delivery log, ?status=&before_id=&limit=
+	mux.Get("/webhooks/:id/deliveries/:delivery_id", webhookMiddleware.ThenFunc(app.webhookHandler.GetDelivery))          // Delivery with its payload and attempts
+	mux.Post("/webhooks/:id/deliveries/:delivery_id/redeliver", webhookMiddleware.ThenFunc(app.webhookHandler.Redeliver)) // Send a delivery again now
+
+	// TRASH
+	mux.Get("/trash", standardMiddleware.ThenFunc(app.trashHandler.GetTrash))                      // List deleted records, ?entity= filters by table
+	mux.Del("/trash", dynamicMiddleware.Append(app.requireAdmin).ThenFunc(app.trashHandler.Purge)) // Purge records past the retention period (admin only)
+	mux.Post("/:entity/:id/restore", standardMiddleware.ThenFunc(app.trashHandler.Restore))        // Restore a deleted record; keep this route last
 
 	return standardMiddleware.Then(mux)
 }
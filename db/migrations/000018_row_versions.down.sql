@@ -0,0 +1,5 @@
+ALTER TABLE users DROP COLUMN version;
+ALTER TABLE personal_debts DROP COLUMN version;
+ALTER TABLE tranches DROP COLUMN version, DROP COLUMN updated_at;
+ALTER TABLE tenders DROP COLUMN version, DROP COLUMN updated_at;
+ALTER TABLE transactions DROP COLUMN version, DROP COLUMN updated_at;
@@ -0,0 +1,17 @@
+ALTER TABLE transactions
+    ADD COLUMN version    INT NOT NULL DEFAULT 1,
+    ADD COLUMN updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;
+
+ALTER TABLE tenders
+    ADD COLUMN version    INT NOT NULL DEFAULT 1,
+    ADD COLUMN updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;
+
+ALTER TABLE tranches
+    ADD COLUMN version    INT NOT NULL DEFAULT 1,
+    ADD COLUMN updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;
+
+ALTER TABLE personal_debts
+    ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+ALTER TABLE users
+    ADD COLUMN version INT NOT NULL DEFAULT 1;
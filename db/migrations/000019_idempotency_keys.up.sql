@@ -0,0 +1,16 @@
+CREATE TABLE idempotency_keys
+(
+    id            INT AUTO_INCREMENT PRIMARY KEY,
+    idem_key      VARCHAR(255) NOT NULL,
+    user_id       INT          NOT NULL DEFAULT 0,
+    method        VARCHAR(10)  NOT NULL,
+    path          VARCHAR(255) NOT NULL,
+    request_hash  CHAR(64)     NOT NULL,
+    status_code   INT          NOT NULL DEFAULT 0,
+    content_type  VARCHAR(255),
+    response_body MEDIUMBLOB,
+    created_at    TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at    TIMESTAMP    NOT NULL,
+    UNIQUE KEY uq_idempotency_caller_key (user_id, idem_key),
+    INDEX idx_idempotency_expires_at (expires_at)
+);
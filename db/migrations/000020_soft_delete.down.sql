@@ -0,0 +1,10 @@
+ALTER TABLE users DROP INDEX idx_users_deleted_at, DROP COLUMN deleted_at, DROP COLUMN deleted_by;
+ALTER TABLE balance_history DROP INDEX idx_balance_history_deleted_at, DROP COLUMN deleted_at, DROP COLUMN deleted_by;
+ALTER TABLE debt_tranches DROP INDEX idx_debt_tranches_deleted_at, DROP COLUMN deleted_at, DROP COLUMN deleted_by;
+ALTER TABLE personal_debts DROP INDEX idx_personal_debts_deleted_at, DROP COLUMN deleted_at, DROP COLUMN deleted_by;
+ALTER TABLE personal_expenses DROP INDEX idx_personal_expenses_deleted_at, DROP COLUMN deleted_at, DROP COLUMN deleted_by;
+ALTER TABLE extra_transactions DROP INDEX idx_extra_transactions_deleted_at, DROP COLUMN deleted_at, DROP COLUMN deleted_by;
+ALTER TABLE changes DROP INDEX idx_changes_deleted_at, DROP COLUMN deleted_at, DROP COLUMN deleted_by;
+ALTER TABLE tranches DROP INDEX idx_tranches_deleted_at, DROP COLUMN deleted_at, DROP COLUMN deleted_by;
+ALTER TABLE tenders DROP INDEX idx_tenders_deleted_at, DROP COLUMN deleted_at, DROP COLUMN deleted_by;
+ALTER TABLE transactions DROP INDEX idx_transactions_deleted_at, DROP COLUMN deleted_at, DROP COLUMN deleted_by;
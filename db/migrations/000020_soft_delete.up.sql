@@ -0,0 +1,49 @@
+ALTER TABLE transactions
+    ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL,
+    ADD COLUMN deleted_by INT NULL,
+    ADD INDEX idx_transactions_deleted_at (deleted_at);
+
+ALTER TABLE tenders
+    ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL,
+    ADD COLUMN deleted_by INT NULL,
+    ADD INDEX idx_tenders_deleted_at (deleted_at);
+
+ALTER TABLE tranches
+    ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL,
+    ADD COLUMN deleted_by INT NULL,
+    ADD INDEX idx_tranches_deleted_at (deleted_at);
+
+ALTER TABLE changes
+    ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL,
+    ADD COLUMN deleted_by INT NULL,
+    ADD INDEX idx_changes_deleted_at (deleted_at);
+
+ALTER TABLE extra_transactions
+    ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL,
+    ADD COLUMN deleted_by INT NULL,
+    ADD INDEX idx_extra_transactions_deleted_at (deleted_at);
+
+ALTER TABLE personal_expenses
+    ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL,
+    ADD COLUMN deleted_by INT NULL,
+    ADD INDEX idx_personal_expenses_deleted_at (deleted_at);
+
+ALTER TABLE personal_debts
+    ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL,
+    ADD COLUMN deleted_by INT NULL,
+    ADD INDEX idx_personal_debts_deleted_at (deleted_at);
+
+ALTER TABLE debt_tranches
+    ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL,
+    ADD COLUMN deleted_by INT NULL,
+    ADD INDEX idx_debt_tranches_deleted_at (deleted_at);
+
+ALTER TABLE balance_history
+    ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL,
+    ADD COLUMN deleted_by INT NULL,
+    ADD INDEX idx_balance_history_deleted_at (deleted_at);
+
+ALTER TABLE users
+    ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL,
+    ADD COLUMN deleted_by INT NULL,
+    ADD INDEX idx_users_deleted_at (deleted_at);
@@ -0,0 +1,14 @@
+CREATE TABLE audit_log
+(
+    id         BIGINT AUTO_INCREMENT PRIMARY KEY,
+    actor_id   INT,
+    entity     VARCHAR(50) NOT NULL,
+    entity_id  INT         NOT NULL,
+    action     VARCHAR(20) NOT NULL,
+    changes    JSON        NOT NULL,
+    request_id VARCHAR(64),
+    ip         VARCHAR(45),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_audit_log_entity (entity, entity_id, id),
+    INDEX idx_audit_log_actor (actor_id, id)
+);
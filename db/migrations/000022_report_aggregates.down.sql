@@ -0,0 +1,3 @@
+DROP TABLE report_refresh_queue;
+DROP TABLE tender_monthly;
+DROP TABLE transaction_monthly;
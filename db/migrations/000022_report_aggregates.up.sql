@@ -0,0 +1,56 @@
+CREATE TABLE transaction_monthly
+(
+    year       SMALLINT       NOT NULL,
+    month      TINYINT        NOT NULL,
+    company_id INT            NOT NULL DEFAULT 0,
+    user_id    INT            NOT NULL DEFAULT 0,
+    type       VARCHAR(255)   NOT NULL,
+    status     TINYINT        NOT NULL,
+    deal_count INT            NOT NULL,
+    amount     DECIMAL(15, 2) NOT NULL,
+    total      DECIMAL(15, 2) NOT NULL,
+    sell       DECIMAL(15, 2) NOT NULL,
+    margin     DECIMAL(15, 2) NOT NULL,
+    PRIMARY KEY (year, month, company_id, user_id, type, status),
+    INDEX idx_transaction_monthly_company (company_id, year, month),
+    INDEX idx_transaction_monthly_user (user_id, year, month)
+);
+
+CREATE TABLE tender_monthly
+(
+    year       SMALLINT       NOT NULL,
+    month      TINYINT        NOT NULL,
+    company_id INT            NOT NULL DEFAULT 0,
+    user_id    INT            NOT NULL DEFAULT 0,
+    type       VARCHAR(255)   NOT NULL,
+    status     TINYINT        NOT NULL,
+    deal_count INT            NOT NULL,
+    total      DECIMAL(15, 2) NOT NULL,
+    commission DECIMAL(15, 2) NOT NULL,
+    PRIMARY KEY (year, month, company_id, user_id, type, status),
+    INDEX idx_tender_monthly_company (company_id, year, month),
+    INDEX idx_tender_monthly_user (user_id, year, month)
+);
+
+CREATE TABLE report_refresh_queue
+(
+    source    VARCHAR(50)  NOT NULL,
+    year      SMALLINT     NOT NULL,
+    month     TINYINT      NOT NULL,
+    queued_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    PRIMARY KEY (source, year, month)
+);
+
+INSERT INTO transaction_monthly (year, month, company_id, user_id, type, status, deal_count, amount, total, sell, margin)
+SELECT YEAR(date) AS y, MONTH(date) AS m, COALESCE(company_id, 0) AS c, COALESCE(user_id, 0) AS u, type, status,
+       COUNT(*), SUM(amount), SUM(total), SUM(sell), COALESCE(SUM(margin), 0)
+FROM transactions
+WHERE deleted_at IS NULL AND date IS NOT NULL
+GROUP BY y, m, c, u, type, status;
+
+INSERT INTO tender_monthly (year, month, company_id, user_id, type, status, deal_count, total, commission)
+SELECT YEAR(date) AS y, MONTH(date) AS m, COALESCE(company_id, 0) AS c, COALESCE(user_id, 0) AS u, type, status,
+       COUNT(*), SUM(total), SUM(commission)
+FROM tenders
+WHERE deleted_at IS NULL AND date IS NOT NULL
+GROUP BY y, m, c, u, type, status;
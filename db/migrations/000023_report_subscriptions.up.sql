@@ -0,0 +1,30 @@
+CREATE TABLE report_subscriptions
+(
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    user_id     INT          NOT NULL,
+    report      VARCHAR(20)  NOT NULL,
+    filters     JSON         NOT NULL,
+    schedule    VARCHAR(100) NOT NULL,
+    format      VARCHAR(10)  NOT NULL,
+    email       VARCHAR(255) NOT NULL,
+    active      BOOLEAN      NOT NULL DEFAULT TRUE,
+    last_run_at TIMESTAMP    NULL,
+    next_run_at TIMESTAMP    NOT NULL,
+    created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_report_subscriptions_user (user_id),
+    INDEX idx_report_subscriptions_due (active, next_run_at),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE report_subscription_runs
+(
+    id              BIGINT AUTO_INCREMENT PRIMARY KEY,
+    subscription_id INT          NOT NULL,
+    started_at      TIMESTAMP    NOT NULL,
+    finished_at     TIMESTAMP    NULL,
+    status          VARCHAR(10)  NOT NULL,
+    recipient       VARCHAR(255) NOT NULL,
+    error           TEXT,
+    INDEX idx_report_subscription_runs_subscription (subscription_id, id),
+    FOREIGN KEY (subscription_id) REFERENCES report_subscriptions (id) ON DELETE CASCADE
+);
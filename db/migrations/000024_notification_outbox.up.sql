@@ -0,0 +1,20 @@
+CREATE TABLE notification_outbox
+(
+    id              BIGINT AUTO_INCREMENT PRIMARY KEY,
+    history_id      INT,
+    user_id         INT          NOT NULL,
+    token           VARCHAR(255) NOT NULL,
+    title           VARCHAR(255) NOT NULL,
+    body            VARCHAR(255) NOT NULL,
+    data            JSON         NOT NULL,
+    status          VARCHAR(10)  NOT NULL DEFAULT 'pending',
+    attempts        INT          NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    last_error      TEXT,
+    message_id      VARCHAR(255),
+    created_at      TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    sent_at         TIMESTAMP    NULL,
+    INDEX idx_notification_outbox_due (status, next_attempt_at),
+    INDEX idx_notification_outbox_user (user_id, id),
+    FOREIGN KEY (history_id) REFERENCES notify_history (id) ON DELETE SET NULL
+);
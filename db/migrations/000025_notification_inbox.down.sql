@@ -0,0 +1,7 @@
+ALTER TABLE notify_history
+    DROP INDEX idx_notify_history_unread,
+    DROP INDEX idx_notify_history_inbox,
+    DROP COLUMN read_at,
+    DROP COLUMN created_at,
+    DROP COLUMN data,
+    DROP COLUMN category;
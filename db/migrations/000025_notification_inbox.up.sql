@@ -0,0 +1,7 @@
+ALTER TABLE notify_history
+    ADD COLUMN category   VARCHAR(50) NOT NULL DEFAULT 'message',
+    ADD COLUMN data       JSON,
+    ADD COLUMN created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN read_at    TIMESTAMP NULL DEFAULT NULL,
+    ADD INDEX idx_notify_history_inbox (user_id, id),
+    ADD INDEX idx_notify_history_unread (user_id, read_at, id);
@@ -0,0 +1,4 @@
+ALTER TABLE notification_outbox DROP COLUMN channel, MODIFY body VARCHAR(255) NOT NULL;
+DROP TABLE notification_digest_queue;
+DROP TABLE notification_preferences;
+DROP TABLE notification_settings;
@@ -0,0 +1,38 @@
+CREATE TABLE notification_settings
+(
+    user_id        INT PRIMARY KEY,
+    timezone       VARCHAR(64),
+    quiet_start    CHAR(5),
+    quiet_end      CHAR(5),
+    digest_hour    TINYINT   NOT NULL DEFAULT 9,
+    last_digest_at TIMESTAMP NULL,
+    updated_at     TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE notification_preferences
+(
+    user_id INT         NOT NULL,
+    event   VARCHAR(50) NOT NULL,
+    channel VARCHAR(10) NOT NULL,
+    mode    VARCHAR(10) NOT NULL,
+    PRIMARY KEY (user_id, event, channel),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE notification_digest_queue
+(
+    id         BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id    INT          NOT NULL,
+    channel    VARCHAR(10)  NOT NULL,
+    title      VARCHAR(255) NOT NULL,
+    body       VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_notification_digest_queue_user (user_id, id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- Email digests list many notifications in one message.
+ALTER TABLE notification_outbox
+    ADD COLUMN channel VARCHAR(10) NOT NULL DEFAULT 'push' AFTER user_id,
+    MODIFY body TEXT NOT NULL;
@@ -0,0 +1,10 @@
+ALTER TABLE notify_tokens
+    DROP INDEX idx_notify_tokens_last_seen,
+    DROP INDEX uq_notify_tokens_token,
+    DROP COLUMN last_seen_at,
+    DROP COLUMN created_at,
+    DROP COLUMN locale,
+    DROP COLUMN app_version,
+    DROP COLUMN platform,
+    MODIFY token VARCHAR(255),
+    MODIFY user_id INT;
@@ -0,0 +1,15 @@
+DELETE FROM notify_tokens WHERE token IS NULL OR token = '' OR user_id IS NULL;
+
+DELETE t FROM notify_tokens t
+JOIN notify_tokens newer ON newer.token = t.token AND newer.id > t.id;
+
+ALTER TABLE notify_tokens
+    MODIFY user_id INT NOT NULL,
+    MODIFY token VARCHAR(255) NOT NULL,
+    ADD COLUMN platform     VARCHAR(10),
+    ADD COLUMN app_version  VARCHAR(50),
+    ADD COLUMN locale       VARCHAR(20),
+    ADD COLUMN created_at   TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD UNIQUE INDEX uq_notify_tokens_token (token),
+    ADD INDEX idx_notify_tokens_last_seen (last_seen_at);
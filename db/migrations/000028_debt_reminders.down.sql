@@ -0,0 +1,5 @@
+DROP TABLE personal_debt_reminders;
+
+ALTER TABLE personal_debts
+    DROP INDEX idx_personal_debts_return_date,
+    DROP COLUMN overdue_at;
@@ -0,0 +1,13 @@
+ALTER TABLE personal_debts
+    ADD COLUMN overdue_at TIMESTAMP NULL DEFAULT NULL,
+    ADD INDEX idx_personal_debts_return_date (return_date);
+
+CREATE TABLE personal_debt_reminders
+(
+    debt_id     INT         NOT NULL,
+    kind        VARCHAR(10) NOT NULL,
+    return_date DATE        NOT NULL,
+    sent_at     TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (debt_id, kind, return_date),
+    FOREIGN KEY (debt_id) REFERENCES personal_debts (id)
+);
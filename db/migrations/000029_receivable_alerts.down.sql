@@ -0,0 +1,2 @@
+DROP TABLE receivable_alerts;
+DROP TABLE company_payment_terms;
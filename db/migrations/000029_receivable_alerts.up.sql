@@ -0,0 +1,22 @@
+CREATE TABLE company_payment_terms
+(
+    company_id    INT PRIMARY KEY,
+    due_days      INT NOT NULL,
+    escalate_days INT NOT NULL,
+    updated_at    TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    FOREIGN KEY (company_id) REFERENCES companies (id) ON DELETE CASCADE
+);
+
+CREATE TABLE receivable_alerts
+(
+    transaction_id INT         NOT NULL,
+    level          VARCHAR(10) NOT NULL,
+    company_id     INT,
+    user_id        INT,
+    due_date       DATE        NOT NULL,
+    outstanding    DOUBLE      NOT NULL,
+    sent_at        TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (transaction_id, level),
+    INDEX idx_receivable_alerts_company (company_id, sent_at),
+    FOREIGN KEY (transaction_id) REFERENCES transactions (id)
+);
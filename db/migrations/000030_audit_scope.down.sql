@@ -0,0 +1,3 @@
+ALTER TABLE audit_log
+    DROP COLUMN company_id,
+    DROP COLUMN user_id;
@@ -0,0 +1,3 @@
+ALTER TABLE audit_log
+    ADD COLUMN user_id    INT NULL,
+    ADD COLUMN company_id INT NULL;
@@ -0,0 +1,55 @@
+CREATE TABLE webhooks
+(
+    id              INT AUTO_INCREMENT PRIMARY KEY,
+    url             VARCHAR(2048) NOT NULL,
+    secret          VARCHAR(100)  NOT NULL,
+    events          JSON          NOT NULL,
+    description     VARCHAR(255),
+    active          BOOLEAN       NOT NULL DEFAULT TRUE,
+    failure_count   INT           NOT NULL DEFAULT 0,
+    disabled_at     TIMESTAMP     NULL,
+    disabled_reason TEXT,
+    created_by      INT,
+    created_at      TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at      TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+CREATE TABLE webhook_deliveries
+(
+    id              BIGINT AUTO_INCREMENT PRIMARY KEY,
+    webhook_id      INT          NOT NULL,
+    audit_id        BIGINT,
+    event_type      VARCHAR(100) NOT NULL,
+    payload         JSON         NOT NULL,
+    status          VARCHAR(10)  NOT NULL DEFAULT 'pending',
+    attempts        INT          NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    response_status INT,
+    last_error      TEXT,
+    created_at      TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    delivered_at    TIMESTAMP    NULL,
+    UNIQUE INDEX uq_webhook_deliveries_event (webhook_id, audit_id, event_type),
+    INDEX idx_webhook_deliveries_due (status, next_attempt_at),
+    INDEX idx_webhook_deliveries_webhook (webhook_id, id),
+    FOREIGN KEY (webhook_id) REFERENCES webhooks (id) ON DELETE CASCADE
+);
+
+CREATE TABLE webhook_attempts
+(
+    id              BIGINT AUTO_INCREMENT PRIMARY KEY,
+    delivery_id     BIGINT NOT NULL,
+    response_status INT,
+    response_body   TEXT,
+    error           TEXT,
+    duration_ms     INT    NOT NULL,
+    created_at      TIMESTAMP(6) DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_webhook_attempts_delivery (delivery_id, id),
+    FOREIGN KEY (delivery_id) REFERENCES webhook_deliveries (id) ON DELETE CASCADE
+);
+
+-- The audit log entry webhook events were last queued from.
+CREATE TABLE webhook_cursor
+(
+    id            TINYINT PRIMARY KEY,
+    last_audit_id BIGINT NOT NULL
+);
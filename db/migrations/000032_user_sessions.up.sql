@@ -0,0 +1,11 @@
+CREATE TABLE user_sessions
+(
+    id         INT AUTO_INCREMENT PRIMARY KEY,
+    user_id    INT       NOT NULL,
+    token_hash CHAR(64)  NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    UNIQUE KEY uq_user_sessions_token (token_hash),
+    INDEX idx_user_sessions_expires_at (expires_at),
+    FOREIGN KEY (user_id) REFERENCES users (id)
+);
@@ -15,6 +15,71 @@ type Config struct {
 		Driver string `yaml:"driver"`
 		URL    string `yaml:"url"`
 	} `yaml:"database"`
+	Idempotency struct {
+		// TTL is how long a stored response can be replayed, e.g. "24h".
+		TTL string `yaml:"ttl"`
+	} `yaml:"idempotency"`
+	Auth struct {
+		// SessionTTL is how long the token issued at login stays valid, e.g. "720h".
+		SessionTTL string `yaml:"session_ttl"`
+	} `yaml:"auth"`
+	Trash struct {
+		// Retention is how long deleted records are kept before an admin may purge them, e.g. "720h".
+		Retention string `yaml:"retention"`
+	} `yaml:"trash"`
+	Dashboard struct {
+		// CacheTTL is how long a computed dashboard is reused, e.g. "30s".
+		CacheTTL string `yaml:"cache_ttl"`
+	} `yaml:"dashboard"`
+	Reporting struct {
+		// RefreshInterval is how often months changed by writes are re-aggregated, e.g. "1m".
+		RefreshInterval string `yaml:"refresh_interval"`
+	} `yaml:"reporting"`
+	Receivables struct {
+		// DueDays is how many days after completion a company has to pay for a transaction,
+		// unless it has payment terms of its own; EscalateDays is when an unpaid one escalates.
+		DueDays      int `yaml:"due_days"`
+		EscalateDays int `yaml:"escalate_days"`
+	} `yaml:"receivables"`
+	PersonalDebts struct {
+		// ReminderDays is how many days before its return date an unpaid debt is reminded of.
+		ReminderDays int `yaml:"reminder_days"`
+		// GivenType and TakenType are the personal_debts.type values of money lent out and money
+		// borrowed. Debts of any other type are left out of the cash-flow forecast.
+		GivenType *int `yaml:"given_type"`
+		TakenType *int `yaml:"taken_type"`
+	} `yaml:"personal_debts"`
+	Documents struct {
+		// Organization is our name as printed on reconciliation acts and statements.
+		Organization string `yaml:"organization"`
+	} `yaml:"documents"`
+	Notifications struct {
+		// Provider delivers push notifications: fcm, log (the default) or memory. When fcm
+		// cannot be initialised, notifications are logged instead.
+		Provider        string `yaml:"provider"`
+		ProjectID       string `yaml:"project_id"`
+		CredentialsFile string `yaml:"credentials_file"`
+		// DeliveryInterval is how often queued notifications are sent, e.g. "10s".
+		DeliveryInterval string `yaml:"delivery_interval"`
+		// TokenTTL is how long a device token is kept when its app is not opened, e.g. "1440h".
+		TokenTTL string `yaml:"token_ttl"`
+	} `yaml:"notifications"`
+	Webhooks struct {
+		// DeliveryInterval is how often due webhook deliveries are sent, e.g. "10s".
+		DeliveryInterval string `yaml:"delivery_interval"`
+		// AllowedNetworks lists the addresses or CIDR ranges, e.g. "10.0.5.0/24", of local
+		// receivers. Webhooks are never delivered to other loopback, private or link-local addresses.
+		AllowedNetworks []string `yaml:"allowed_networks"`
+	} `yaml:"webhooks"`
+	Mail struct {
+		// Host is the SMTP server report digests and email notifications are sent through. When
+		// it is empty, mail is only logged.
+		Host     string `yaml:"host"`
+		Port     int    `yaml:"port"`
+		Username string `yaml:"username"`
+		Password string `yaml:"password"`
+		From     string `yaml:"from"`
+	} `yaml:"mail"`
 }
 
 // LoadConfig loads the configuration from config.yaml
@@ -0,0 +1,367 @@
+// Package documents renders printable PDF documents. The wording of every document lives in
+// templates/*.tmpl and the fonts it is set in are bundled, so documents print the same
+// everywhere and Cyrillic text needs nothing installed on the server.
+//
+// Templates produce plain lines with a little markup:
+//
+//	# text           title, bold and centred
+//	## text          bold line
+//	| left | right   two columns, used for signatures
+//	(empty line)     vertical space
+//
+// Any other line is a paragraph. Tables are drawn between the templates by the document itself.
+package documents
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"github.com/go-pdf/fpdf"
+	"io"
+	"math"
+	"strings"
+	"tender/internal/models"
+	"text/template"
+	"time"
+)
+
+//go:embed fonts/*.ttf
+var fonts embed.FS
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+var templates = template.Must(template.New("documents").Funcs(template.FuncMap{
+	"date":   formatDate,
+	"money":  formatMoney,
+	"org":    organizationName,
+	"neg":    func(v float64) float64 { return -v },
+	"deref":  func(s *string) string { return derefString(s) },
+	"number": transactionNumber,
+}).ParseFS(templateFiles, "templates/*.tmpl"))
+
+const (
+	fontFamily = "DejaVu"
+	lineHeight = 5.0
+)
+
+// Reconciliation renders the reconciliation act between organization and the act's company.
+func Reconciliation(w io.Writer, act models.ReconciliationAct, organization string) error {
+	data := struct {
+		models.ReconciliationAct
+		Organization string
+	}{act, organization}
+
+	doc, err := newDocument()
+	if err != nil {
+		return err
+	}
+	if err := doc.text("reconciliation.header", data); err != nil {
+		return err
+	}
+
+	table := documentTable{
+		widths: []float64{22, 98, 30, 30},
+		align:  []string{"C", "L", "R", "R"},
+		header: []string{"Дата", "Документ", "Дебет", "Кредит"},
+	}
+	debit, credit := splitBalance(act.OpeningBalance)
+	table.rows = append(table.rows, tableRow{bold: true, cells: []string{"", "Сальдо на " + formatDate(act.StartDate), debit, credit}})
+	for _, entry := range act.Entries {
+		table.rows = append(table.rows, tableRow{cells: []string{
+			formatDate(entry.Date), entryDocument(entry), optionalMoney(entry.Debit), optionalMoney(entry.Credit),
+		}})
+	}
+	table.rows = append(table.rows, tableRow{bold: true, cells: []string{"", "Обороты за период", formatMoney(act.Sales), formatMoney(act.Payments)}})
+	debit, credit = splitBalance(act.ClosingBalance)
+	table.rows = append(table.rows, tableRow{bold: true, cells: []string{"", "Сальдо на " + formatDate(act.EndDate), debit, credit}})
+	doc.table(table)
+
+	if err := doc.text("reconciliation.footer", data); err != nil {
+		return err
+	}
+	return doc.pdf.Output(w)
+}
+
+// Statement renders a transaction with its additional expenses and payments.
+func Statement(w io.Writer, statement models.TransactionStatement, organization string, today time.Time) error {
+	data := struct {
+		models.TransactionStatement
+		Organization string
+		Today        time.Time
+	}{statement, organization, today}
+
+	doc, err := newDocument()
+	if err != nil {
+		return err
+	}
+	if err := doc.text("statement.header", data); err != nil {
+		return err
+	}
+
+	if len(statement.Transaction.Expenses) > 0 {
+		doc.heading("Дополнительные расходы")
+		expenses := documentTable{widths: []float64{150, 30}, align: []string{"L", "R"}, header: []string{"Наименование", "Сумма"}}
+		for _, expense := range statement.Transaction.Expenses {
+			expenses.rows = append(expenses.rows, tableRow{cells: []string{expense.Name, formatMoney(expense.Amount)}})
+		}
+		expenses.rows = append(expenses.rows, tableRow{bold: true, cells: []string{"Итого", formatMoney(statement.ExpensesTotal)}})
+		doc.table(expenses)
+	}
+
+	doc.heading("Оплаты")
+	payments := documentTable{widths: []float64{25, 125, 30}, align: []string{"C", "L", "R"}, header: []string{"Дата", "Описание", "Сумма"}}
+	for _, payment := range statement.Payments {
+		payments.rows = append(payments.rows, tableRow{cells: []string{
+			formatDate(payment.Date), derefString(payment.Description), formatMoney(float64(payment.Amount)),
+		}})
+	}
+	if len(statement.Payments) == 0 {
+		payments.rows = append(payments.rows, tableRow{cells: []string{"", "Оплат не поступало", ""}})
+	}
+	doc.table(payments)
+
+	if err := doc.text("statement.footer", data); err != nil {
+		return err
+	}
+	return doc.pdf.Output(w)
+}
+
+func entryDocument(entry models.ReconciliationEntry) string {
+	number := fmt.Sprint(entry.TransactionID)
+	if entry.TenderNumber != nil && *entry.TenderNumber != "" {
+		number = *entry.TenderNumber
+	}
+	label := "Реализация, сделка № " + number
+	if entry.Kind == "payment" {
+		label = "Оплата, сделка № " + number
+	}
+	if entry.Description != nil && *entry.Description != "" {
+		label += " (" + *entry.Description + ")"
+	}
+	return label
+}
+
+// splitBalance puts what the company owes in the debit column and a prepayment in the credit one.
+func splitBalance(balance float64) (debit, credit string) {
+	if balance < 0 {
+		return "", formatMoney(-balance)
+	}
+	return formatMoney(balance), ""
+}
+
+// document is an A4 page set in the bundled fonts.
+type document struct {
+	pdf *fpdf.Fpdf
+}
+
+func newDocument() (*document, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	for style, file := range map[string]string{"": "fonts/DejaVuSansCondensed.ttf", "B": "fonts/DejaVuSansCondensed-Bold.ttf"} {
+		data, err := fonts.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		pdf.AddUTF8FontFromBytes(fontFamily, style, data)
+	}
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont(fontFamily, "", 8)
+		pdf.CellFormat(0, 4, fmt.Sprintf("Страница %d из {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AddPage()
+	pdf.SetFont(fontFamily, "", 10)
+	return &document{pdf: pdf}, pdf.Error()
+}
+
+// text renders a template and lays out its lines.
+func (d *document) text(name string, data interface{}) error {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, data); err != nil {
+		return err
+	}
+
+	pdf := d.pdf
+	width, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	column := (width - left - right) / 2
+	for _, line := range strings.Split(buf.String(), "\n") {
+		line = strings.TrimRight(line, " ")
+		switch {
+		case line == "":
+			pdf.Ln(lineHeight)
+		case strings.HasPrefix(line, "# "):
+			pdf.SetFont(fontFamily, "B", 13)
+			pdf.MultiCell(0, 7, line[2:], "", "C", false)
+		case strings.HasPrefix(line, "## "):
+			pdf.SetFont(fontFamily, "B", 10)
+			pdf.MultiCell(0, lineHeight, line[3:], "", "L", false)
+		case strings.HasPrefix(line, "|"):
+			pdf.SetFont(fontFamily, "", 10)
+			cells := strings.SplitN(strings.TrimPrefix(line, "|"), "|", 2)
+			for len(cells) < 2 {
+				cells = append(cells, "")
+			}
+			y := pdf.GetY()
+			pdf.MultiCell(column, lineHeight, strings.TrimSpace(cells[0]), "", "L", false)
+			bottom := pdf.GetY()
+			pdf.SetXY(left+column, y)
+			pdf.MultiCell(column, lineHeight, strings.TrimSpace(cells[1]), "", "L", false)
+			pdf.SetY(math.Max(bottom, pdf.GetY()))
+		default:
+			pdf.SetFont(fontFamily, "", 10)
+			pdf.MultiCell(0, lineHeight, line, "", "J", false)
+		}
+	}
+	return pdf.Error()
+}
+
+func (d *document) heading(title string) {
+	d.pdf.Ln(lineHeight / 2)
+	d.pdf.SetFont(fontFamily, "B", 10)
+	d.pdf.MultiCell(0, lineHeight, title, "", "L", false)
+}
+
+type tableRow struct {
+	bold  bool
+	cells []string
+}
+
+type documentTable struct {
+	widths []float64
+	align  []string
+	header []string
+	rows   []tableRow
+}
+
+// table draws a bordered table whose cells wrap; the header is repeated on every page.
+func (d *document) table(t documentTable) {
+	pdf := d.pdf
+	pdf.Ln(lineHeight / 2)
+	d.tableRow(t, tableRow{bold: true, cells: t.header}, true)
+	for _, row := range t.rows {
+		d.tableRow(t, row, false)
+	}
+	pdf.Ln(lineHeight / 2)
+}
+
+func (d *document) tableRow(t documentTable, row tableRow, header bool) {
+	pdf := d.pdf
+	style := ""
+	if row.bold {
+		style = "B"
+	}
+	pdf.SetFont(fontFamily, style, 9)
+
+	lines := 1
+	for i, cell := range row.cells {
+		if n := len(pdf.SplitText(cell, t.widths[i])); n > lines {
+			lines = n
+		}
+	}
+	height := float64(lines) * lineHeight
+
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	if pdf.GetY()+height > pageHeight-bottom {
+		pdf.AddPage()
+		if !header {
+			d.tableRow(t, tableRow{bold: true, cells: t.header}, true)
+			pdf.SetFont(fontFamily, style, 9)
+		}
+	}
+
+	x, y := pdf.GetX(), pdf.GetY()
+	for i, cell := range row.cells {
+		align := t.align[i]
+		if header {
+			align = "C"
+		}
+		pdf.Rect(x, y, t.widths[i], height, "D")
+		pdf.SetXY(x, y)
+		pdf.MultiCell(t.widths[i], lineHeight, cell, "", align, false)
+		x += t.widths[i]
+	}
+	pdf.SetXY(pdf.GetX(), y+height)
+}
+
+// formatDate writes a date as DD.MM.YYYY. It accepts times and the date strings used in models.
+func formatDate(v interface{}) string {
+	switch v := v.(type) {
+	case time.Time:
+		return v.Format("02.01.2006")
+	case *string:
+		return formatDate(derefString(v))
+	case string:
+		for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04:05"} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t.Format("02.01.2006")
+			}
+		}
+		return v
+	}
+	return fmt.Sprint(v)
+}
+
+// formatMoney writes an amount with a space between thousands and a decimal comma: 1 234,50.
+func formatMoney(v interface{}) string {
+	var amount float64
+	switch v := v.(type) {
+	case float64:
+		amount = v
+	case *float64:
+		if v != nil {
+			amount = *v
+		}
+	case int:
+		amount = float64(v)
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	s := fmt.Sprintf("%.2f", amount)
+	whole, fraction := s[:len(s)-3], s[len(s)-2:]
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteByte(' ')
+		}
+		grouped.WriteRune(digit)
+	}
+	return sign + grouped.String() + "," + fraction
+}
+
+// optionalMoney leaves zero amounts blank, as in the debit or credit column of an entry.
+func optionalMoney(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return formatMoney(v)
+}
+
+// organizationName leaves a line to fill in by hand when no organization is configured.
+func organizationName(name string) string {
+	if name == "" {
+		return "____________________"
+	}
+	return name
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func transactionNumber(t models.Transaction) string {
+	if t.TransactionNumber != nil && *t.TransactionNumber != "" {
+		return *t.TransactionNumber
+	}
+	return fmt.Sprint(t.ID)
+}
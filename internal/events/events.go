@@ -0,0 +1,140 @@
+// Package events lets services announce domain changes to the parts of the application that
+// react to them, such as notifications.
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"tender/internal/requestctx"
+	"time"
+)
+
+// Event types.
+const (
+	TransactionStatusChanged = "transaction.status_changed"
+	TrancheReceived          = "tranche.received"
+	TenderCompleted          = "tender.completed"
+	BalanceUpdated           = "balance.updated"
+	PermissionGranted        = "permission.granted"
+	PersonalDebtDue          = "personal_debt.due"
+	PersonalDebtOverdue      = "personal_debt.overdue"
+	ReceivableOverdue        = "receivable.overdue"
+	ReceivableEscalated      = "receivable.escalated"
+)
+
+// Event describes one change.
+type Event struct {
+	Type string `json:"type"`
+	// ActorID is the user who made the change, or 0 when it was not made by a request.
+	ActorID int `json:"actor_id,omitempty"`
+	// UserID and CompanyID are the user and the company the changed record belongs to.
+	UserID    *int `json:"user_id,omitempty"`
+	CompanyID *int `json:"company_id,omitempty"`
+	EntityID  int  `json:"entity_id"`
+	// Data holds the details of the change used by message templates.
+	Data map[string]interface{} `json:"data,omitempty"`
+	At   time.Time              `json:"at"`
+}
+
+// Handler reacts to an event.
+type Handler func(ctx context.Context, e Event) error
+
+// Bus delivers published events to every subscribed handler. Handlers run in the background,
+// so a slow or failing handler never delays or fails the change that published the event.
+// A nil *Bus discards events.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []namedHandler
+	wg       sync.WaitGroup
+}
+
+type namedHandler struct {
+	name string
+	fn   Handler
+}
+
+func (b *Bus) Subscribe(name string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, namedHandler{name: name, fn: h})
+}
+
+// Publish stamps e with the time and the caller of ctx and hands it to every handler.
+func (b *Bus) Publish(ctx context.Context, e Event) {
+	if b == nil {
+		return
+	}
+	e = stamp(ctx, e)
+	// The request that published the event may finish before the handlers do.
+	ctx = context.WithoutCancel(ctx)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, h := range b.handlers {
+		b.wg.Add(1)
+		go func(h namedHandler) {
+			defer b.wg.Done()
+			if err := h.handle(ctx, e); err != nil {
+				log.Print(err)
+			}
+		}(h)
+	}
+}
+
+// Deliver is Publish for background jobs: it runs every handler before returning and reports
+// their failures, so that a job can note an event as sent only once it was handled.
+func (b *Bus) Deliver(ctx context.Context, e Event) error {
+	if b == nil {
+		return nil
+	}
+	e = stamp(ctx, e)
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	var errs []error
+	for _, h := range b.handlers {
+		errs = append(errs, h.handle(ctx, e))
+	}
+	return errors.Join(errs...)
+}
+
+func stamp(ctx context.Context, e Event) Event {
+	if e.At.IsZero() {
+		e.At = time.Now()
+	}
+	if e.ActorID == 0 {
+		e.ActorID, _ = requestctx.CallerFrom(ctx)
+	}
+	return e
+}
+
+func (h namedHandler) handle(ctx context.Context, e Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("event handler %s panicked on %s: %v", h.name, e.Type, r)
+		}
+	}()
+	if err := h.fn(ctx, e); err != nil {
+		return fmt.Errorf("event handler %s failed on %s: %w", h.name, e.Type, err)
+	}
+	return nil
+}
+
+// Wait blocks until every handler started so far has returned, or until ctx is done. Events
+// still being handled then are lost.
+func (b *Bus) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
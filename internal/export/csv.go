@@ -0,0 +1,98 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type csvWriter struct {
+	out     *csv.Writer
+	locale  Locale
+	columns []Column
+	record  []string
+	rows    int
+	totals  []float64
+}
+
+func newCSVWriter(w io.Writer, locale Locale, columns []Column) (*csvWriter, error) {
+	// The byte order mark makes spreadsheets read the file as UTF-8 rather than the local code page.
+	if locale.BOM {
+		if _, err := io.WriteString(w, "\ufeff"); err != nil {
+			return nil, err
+		}
+	}
+	out := csv.NewWriter(w)
+	out.Comma = locale.Separator
+
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = locale.Header(column.Key)
+	}
+	if err := out.Write(header); err != nil {
+		return nil, err
+	}
+	return &csvWriter{out: out, locale: locale, columns: columns, record: make([]string, len(columns)), totals: make([]float64, len(columns))}, nil
+}
+
+func (w *csvWriter) Write(values ...interface{}) error {
+	for i, column := range w.columns {
+		w.record[i] = ""
+		if i < len(values) {
+			value := normalize(column.Kind, values[i])
+			if n, ok := numeric(value); ok && column.Total {
+				w.totals[i] += n
+			}
+			w.record[i] = w.format(column.Kind, value)
+		}
+	}
+	w.rows++
+	return w.out.Write(w.record)
+}
+
+func (w *csvWriter) format(kind Kind, v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if kind == Timestamp {
+			return v.Format(w.locale.timestampLayout())
+		}
+		return v.Format(w.locale.DateLayout)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		precision := 2
+		if kind != Number {
+			precision = -1
+		}
+		return strings.Replace(strconv.FormatFloat(v, 'f', precision, 64), ".", w.locale.Decimal, 1)
+	}
+	return ""
+}
+
+func (w *csvWriter) Close() error {
+	if hasTotals(w.columns) && w.rows > 0 {
+		for i, column := range w.columns {
+			switch {
+			case column.Kind == Integer && column.Total:
+				w.record[i] = w.format(Integer, int64(w.totals[i]))
+			case column.Total:
+				w.record[i] = w.format(Number, w.totals[i])
+			case i == 0:
+				w.record[i] = w.locale.Header("total_row")
+			default:
+				w.record[i] = ""
+			}
+		}
+		if err := w.out.Write(w.record); err != nil {
+			return err
+		}
+	}
+	w.out.Flush()
+	return w.out.Error()
+}
@@ -0,0 +1,213 @@
+// Package export writes lists and reports as CSV or XLSX files. Rows are written as they are
+// produced, so an export never holds the whole data set in memory.
+package export
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"tender/internal/models"
+	"time"
+)
+
+type Format string
+
+const (
+	CSV  Format = "csv"
+	XLSX Format = "xlsx"
+)
+
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// Requested returns the format asked for by ?format= or, failing that, by the Accept header.
+// It returns "" when the client wants JSON.
+func Requested(r *http.Request) (Format, error) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+	case string(CSV), string(XLSX):
+		return Format(format), nil
+	default:
+		return "", models.ErrUnsupportedFormat
+	}
+
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case "text/csv":
+			return CSV, nil
+		case xlsxContentType:
+			return XLSX, nil
+		case "application/json":
+			return "", nil
+		}
+	}
+	return "", nil
+}
+
+func (f Format) ContentType() string {
+	if f == XLSX {
+		return xlsxContentType
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Filename names a download after its contents and today's date.
+func (f Format) Filename(name string) string {
+	return fmt.Sprintf("%s_%s.%s", name, time.Now().Format("2006-01-02"), f)
+}
+
+// Kind tells how a column's values are formatted.
+type Kind int
+
+const (
+	Text Kind = iota
+	Integer
+	Number // money, written with two decimals
+	Date
+	Timestamp
+)
+
+type Column struct {
+	Key  string // also the key of the column's header in the locale's labels
+	Kind Kind
+	// Total adds the column up in the totals row written after the last row.
+	Total bool
+}
+
+// Table is a data set small enough to hold in memory, such as a report.
+type Table struct {
+	Name    string // names the file and the XLSX sheet
+	Columns []Column
+	Rows    [][]interface{}
+}
+
+// WriteTable writes a whole table as a file.
+func WriteTable(w io.Writer, format Format, locale Locale, t Table) error {
+	out, err := NewWriter(w, format, locale, t.Name, t.Columns)
+	if err != nil {
+		return err
+	}
+	for _, row := range t.Rows {
+		if err := out.Write(row...); err != nil {
+			return err
+		}
+	}
+	return out.Close()
+}
+
+// Writer writes one row per call. Values may be nil, strings, integers, floats, times, pointers
+// to those or their sql.Null types; strings in Date and Timestamp columns are parsed when they
+// hold a date.
+type Writer interface {
+	Write(values ...interface{}) error
+	// Close finishes the file. An XLSX file is unreadable until it is closed.
+	Close() error
+}
+
+// NewWriter starts a file with a header row. sheet names the XLSX worksheet.
+func NewWriter(w io.Writer, format Format, locale Locale, sheet string, columns []Column) (Writer, error) {
+	switch format {
+	case CSV:
+		return newCSVWriter(w, locale, columns)
+	case XLSX:
+		return newXLSXWriter(w, locale, sheet, columns)
+	}
+	return nil, models.ErrUnsupportedFormat
+}
+
+var dateLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02", "2006-01"}
+
+// normalize reduces a value to nil, string, int64, float64 or time.Time.
+func normalize(kind Kind, v interface{}) interface{} {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case *string:
+		if v == nil {
+			return nil
+		}
+		return normalize(kind, *v)
+	case *int:
+		if v == nil {
+			return nil
+		}
+		return int64(*v)
+	case *float64:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case *time.Time:
+		if v == nil {
+			return nil
+		}
+		return *v
+	case sql.NullString:
+		if !v.Valid {
+			return nil
+		}
+		return normalize(kind, v.String)
+	case sql.NullInt64:
+		if !v.Valid {
+			return nil
+		}
+		return v.Int64
+	case sql.NullFloat64:
+		if !v.Valid {
+			return nil
+		}
+		return v.Float64
+	case sql.NullTime:
+		if !v.Valid {
+			return nil
+		}
+		return v.Time
+	case int:
+		return int64(v)
+	case int64, float64, time.Time:
+		return v
+	case string:
+		if kind == Date || kind == Timestamp {
+			for _, layout := range dateLayouts {
+				if t, err := time.Parse(layout, v); err == nil {
+					return t
+				}
+			}
+		}
+		return v
+	case []byte:
+		return normalize(kind, string(v))
+	case bool:
+		if v {
+			return int64(1)
+		}
+		return int64(0)
+	}
+	return fmt.Sprint(v)
+}
+
+func hasTotals(columns []Column) bool {
+	for _, column := range columns {
+		if column.Total {
+			return true
+		}
+	}
+	return false
+}
+
+// numeric returns the value of an integer or float, and false for anything else.
+func numeric(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
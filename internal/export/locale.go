@@ -0,0 +1,205 @@
+package export
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Locale decides the language of headers and how numbers and dates are written.
+type Locale struct {
+	Lang string
+	// Separator separates CSV fields. Spreadsheets expect ";" where the decimal mark is a comma.
+	Separator  rune
+	Decimal    string
+	BOM        bool   // starts CSV files with a UTF-8 byte order mark
+	DateLayout string // Go layout for dates in CSV files
+	DateFormat string // Excel number format for dates in XLSX files
+}
+
+var locales = map[string]Locale{
+	"ru": {Lang: "ru", Separator: ';', Decimal: ",", BOM: true, DateLayout: "02.01.2006", DateFormat: "dd.mm.yyyy"},
+	"en": {Lang: "en", Separator: ',', Decimal: ".", DateLayout: "2006-01-02", DateFormat: "yyyy-mm-dd"},
+}
+
+// DefaultLocale is used when the client asks for no supported language. It keeps CSV files
+// comma separated, as they were before exports were localised.
+var DefaultLocale = locales["en"]
+
+// LocaleNamed returns the locale of a language, or DefaultLocale when it is not supported.
+func LocaleNamed(lang string) Locale {
+	if locale, ok := locales[lang]; ok {
+		return locale
+	}
+	return DefaultLocale
+}
+
+// LocaleFor picks the locale from ?lang= or the Accept-Language header.
+func LocaleFor(r *http.Request) Locale {
+	if locale, ok := locales[r.URL.Query().Get("lang")]; ok {
+		return locale
+	}
+	for _, tag := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, _, _ = strings.Cut(strings.TrimSpace(tag), ";")
+		lang, _, _ := strings.Cut(tag, "-")
+		if locale, ok := locales[strings.ToLower(lang)]; ok {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+// Header is the column title for key, falling back to English and then to the key itself.
+func (l Locale) Header(key string) string {
+	if label, ok := labels[l.Lang][key]; ok {
+		return label
+	}
+	if label, ok := labels["en"][key]; ok {
+		return label
+	}
+	return key
+}
+
+func (l Locale) timestampLayout() string {
+	return l.DateLayout + " 15:04"
+}
+
+func (l Locale) timestampFormat() string {
+	return l.DateFormat + " hh:mm"
+}
+
+var labels = map[string]map[string]string{
+	"en": {
+		"total_row":           "Total",
+		"id":                  "ID",
+		"transaction_id":      "Transaction",
+		"transaction_number":  "Transaction number",
+		"type":                "Type",
+		"tender_number":       "Tender number",
+		"company":             "Company",
+		"company_id":          "Company ID",
+		"company_name":        "Company",
+		"user":                "User",
+		"user_id":             "User ID",
+		"user_name":           "User",
+		"organization":        "Organization",
+		"product_name":        "Product",
+		"name":                "Name",
+		"description":         "Description",
+		"reason":              "Reason",
+		"category":            "Category",
+		"source":              "Source",
+		"amount":              "Amount",
+		"total":               "Total",
+		"sell":                "Sell",
+		"margin":              "Margin",
+		"commission":          "Commission",
+		"count":               "Count",
+		"status":              "Status",
+		"date":                "Date",
+		"completed_date":      "Completed",
+		"get_date":            "Taken on",
+		"return_date":         "Due on",
+		"created_at":          "Created",
+		"year":                "Year",
+		"quarter":             "Quarter",
+		"month":               "Month",
+		"revenue":             "Revenue",
+		"cost":                "Cost",
+		"additional_expenses": "Additional expenses",
+		"gross_margin":        "Gross margin",
+		"tender_commissions":  "Tender commissions",
+		"extra_transactions":  "Extra transactions",
+		"personal_expenses":   "Personal expenses",
+		"net_margin":          "Net margin",
+		"paid":                "Paid",
+		"outstanding":         "Outstanding",
+		"days_outstanding":    "Days outstanding",
+		"bucket":              "Bucket",
+		"last_payment_date":   "Last payment",
+		"start":               "From",
+		"end":                 "To",
+		"receivables":         "Receivables",
+		"planned_tranches":    "Planned tranches",
+		"debts_in":            "Debts returned",
+		"debts_out":           "Debts repaid",
+		"recurring_expenses":  "Recurring expenses",
+		"inflows":             "Inflows",
+		"outflows":            "Outflows",
+		"net":                 "Net",
+		"balance":             "Balance",
+		"rank":                "Rank",
+		"closed_deals":        "Closed deals",
+		"avg_cycle_days":      "Avg. cycle, days",
+		"collection_rate":     "Collection rate",
+		"tender_commission":   "Tender commission",
+		"previous_rank":       "Previous rank",
+		"trend":               "Trend",
+	},
+	"ru": {
+		"total_row":           "Итого",
+		"id":                  "ID",
+		"transaction_id":      "Сделка",
+		"transaction_number":  "Номер сделки",
+		"type":                "Тип",
+		"tender_number":       "Номер тендера",
+		"company":             "Компания",
+		"company_id":          "ID компании",
+		"company_name":        "Компания",
+		"user":                "Сотрудник",
+		"user_id":             "ID сотрудника",
+		"user_name":           "Сотрудник",
+		"organization":        "Организация",
+		"product_name":        "Товар",
+		"name":                "Имя",
+		"description":         "Описание",
+		"reason":              "Причина",
+		"category":            "Категория",
+		"source":              "Источник",
+		"amount":              "Сумма",
+		"total":               "Итого",
+		"sell":                "Продажа",
+		"margin":              "Маржа",
+		"commission":          "Комиссия",
+		"count":               "Количество",
+		"status":              "Статус",
+		"date":                "Дата",
+		"completed_date":      "Дата завершения",
+		"get_date":            "Дата получения",
+		"return_date":         "Дата возврата",
+		"created_at":          "Создано",
+		"year":                "Год",
+		"quarter":             "Квартал",
+		"month":               "Месяц",
+		"revenue":             "Выручка",
+		"cost":                "Себестоимость",
+		"additional_expenses": "Доп. расходы",
+		"gross_margin":        "Валовая маржа",
+		"tender_commissions":  "Комиссии по тендерам",
+		"extra_transactions":  "Доп. операции",
+		"personal_expenses":   "Личные расходы",
+		"net_margin":          "Чистая маржа",
+		"paid":                "Оплачено",
+		"outstanding":         "Задолженность",
+		"days_outstanding":    "Дней просрочки",
+		"bucket":              "Период",
+		"last_payment_date":   "Последняя оплата",
+		"start":               "С",
+		"end":                 "По",
+		"receivables":         "Дебиторка",
+		"planned_tranches":    "Плановые транши",
+		"debts_in":            "Возврат долгов",
+		"debts_out":           "Погашение долгов",
+		"recurring_expenses":  "Регулярные расходы",
+		"inflows":             "Поступления",
+		"outflows":            "Выплаты",
+		"net":                 "Сальдо",
+		"balance":             "Остаток",
+		"rank":                "Место",
+		"closed_deals":        "Закрыто сделок",
+		"avg_cycle_days":      "Средний цикл, дней",
+		"collection_rate":     "Доля оплат",
+		"tender_commission":   "Комиссия по тендерам",
+		"previous_rank":       "Прошлое место",
+		"trend":               "Динамика",
+	},
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"tender/internal/models"
+	"tender/internal/services"
+)
+
+type AuditHandler struct {
+	Service *services.AuditService
+}
+
+// GetAuditLog returns the change history filtered by ?entity=, ?id=, ?actor_id=, ?before_id= and ?limit=.
+// The entries hold the full diffs of every entity, so the route is for the admin only.
+func (h *AuditHandler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{Entity: query.Get("entity")}
+
+	for name, target := range map[string]*int{"id": &filter.EntityID, "actor_id": &filter.ActorID, "limit": &filter.Limit} {
+		if value := query.Get(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				http.Error(w, "Invalid "+name, http.StatusBadRequest)
+				return
+			}
+			*target = parsed
+		}
+	}
+	if value := query.Get("before_id"); value != "" {
+		beforeID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid before_id", http.StatusBadRequest)
+			return
+		}
+		filter.BeforeID = beforeID
+	}
+	if filter.EntityID != 0 && filter.Entity == "" {
+		http.Error(w, "entity is required when filtering by id", http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.Service.GetAuditLog(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, models.ErrUnknownAuditEntity) {
+			http.Error(w, "Unknown entity type", http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error fetching audit log: %v", err)
+		http.Error(w, "Failed to fetch audit log", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+}
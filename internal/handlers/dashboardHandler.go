@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"tender/internal/models"
+	"tender/internal/requestctx"
+	"tender/internal/services"
+)
+
+type DashboardHandler struct {
+	Service *services.DashboardService
+}
+
+// GetDashboard returns the home screen summary for the calling user.
+func (h *DashboardHandler) GetDashboard(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestctx.CallerFrom(r.Context())
+	if !ok {
+		http.Error(w, "X-User-ID header is required", http.StatusUnauthorized)
+		return
+	}
+
+	dashboard, err := h.Service.GetDashboard(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, models.ErrUserNotFound) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error building dashboard for user %d: %v", userID, err)
+		http.Error(w, "Failed to build dashboard", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dashboard)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"tender/internal/models"
+	"tender/internal/services"
+)
+
+type DocumentHandler struct {
+	Service *services.DocumentService
+}
+
+// Reconciliation downloads the reconciliation act with a company for ?start_date=&end_date=.
+func (h *DocumentHandler) Reconciliation(w http.ResponseWriter, r *http.Request) {
+	companyID, err := strconv.Atoi(r.URL.Query().Get(":id"))
+	if err != nil {
+		http.Error(w, "Invalid company ID", http.StatusBadRequest)
+		return
+	}
+
+	pdf, act, err := h.Service.ReconciliationPDF(r.Context(), companyID, r.URL.Query().Get("start_date"), r.URL.Query().Get("end_date"))
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrCompanyNotFound):
+			http.Error(w, "Company not found", http.StatusNotFound)
+		case errors.Is(err, models.ErrInvalidReport):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Printf("Error building reconciliation act: %v", err)
+			http.Error(w, "Failed to build reconciliation act", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writePDF(w, fmt.Sprintf("reconciliation_%d_%s_%s.pdf", companyID, act.StartDate, act.EndDate), pdf)
+}
+
+// Statement downloads the statement of a transaction.
+func (h *DocumentHandler) Statement(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Query().Get(":id"))
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	pdf, _, err := h.Service.StatementPDF(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, models.ErrTransactionNotFound) {
+			http.Error(w, "Transaction not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error building transaction statement: %v", err)
+		http.Error(w, "Failed to build transaction statement", http.StatusInternalServerError)
+		return
+	}
+
+	writePDF(w, fmt.Sprintf("statement_%d.pdf", id), pdf)
+}
+
+func writePDF(w http.ResponseWriter, filename string, pdf []byte) {
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="%s"`, filename))
+	w.Header().Set("Content-Length", strconv.Itoa(len(pdf)))
+	w.Write(pdf)
+}
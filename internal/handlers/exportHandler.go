@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"tender/internal/export"
+	"tender/internal/models"
+	"tender/internal/services"
+)
+
+type ExportHandler struct {
+	Service *services.ExportService
+}
+
+// ExportList downloads a list, optionally narrowed by ?start_date=, ?end_date=, ?user_id= and
+// ?company_id=.
+func (h *ExportHandler) ExportList(w http.ResponseWriter, r *http.Request, list string, format export.Format) {
+	query := r.URL.Query()
+	filter := models.ExportFilter{StartDate: query.Get("start_date"), EndDate: query.Get("end_date")}
+	for name, target := range map[string]**int{"user_id": &filter.UserID, "company_id": &filter.CompanyID} {
+		if value := query.Get(name); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				http.Error(w, "Invalid "+name, http.StatusBadRequest)
+				return
+			}
+			*target = &id
+		}
+	}
+
+	columns, err := h.Service.Columns(list)
+	if err != nil {
+		log.Printf("Error exporting %s: %v", list, err)
+		http.Error(w, "Failed to export "+list, http.StatusInternalServerError)
+		return
+	}
+	err = writeExport(w, r, format, list, columns, func(write func(values ...interface{}) error) error {
+		return h.Service.Stream(r.Context(), list, filter, func(values []interface{}) error {
+			return write(values...)
+		})
+	})
+	if err != nil {
+		log.Printf("Error exporting %s: %v", list, err)
+		http.Error(w, "Failed to export "+list, http.StatusInternalServerError)
+	}
+}
+
+// requestedFormat returns the export format the client asked for, or "" for JSON. It answers
+// 400 and returns false when ?format= names a format that is not supported.
+func requestedFormat(w http.ResponseWriter, r *http.Request) (export.Format, bool) {
+	format, err := export.Requested(r)
+	if errors.Is(err, models.ErrUnsupportedFormat) {
+		http.Error(w, "Unsupported format, use json, csv or xlsx", http.StatusBadRequest)
+		return "", false
+	}
+	return format, true
+}
+
+// writeExport sends the rows produced by rows as a file download named after name. Nothing is
+// sent until the first row or the end of the rows, so an error returned before that is handed
+// back for the caller to answer. Once the download has started the status can no longer change,
+// so later failures are only logged and leave the file truncated.
+func writeExport(w http.ResponseWriter, r *http.Request, format export.Format, name string, columns []export.Column, rows func(write func(values ...interface{}) error) error) error {
+	var out export.Writer
+	start := func() error {
+		if out != nil {
+			return nil
+		}
+		w.Header().Set("Content-Type", format.ContentType())
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, format.Filename(name)))
+		var err error
+		out, err = export.NewWriter(w, format, export.LocaleFor(r), name, columns)
+		return err
+	}
+
+	err := rows(func(values ...interface{}) error {
+		if err := start(); err != nil {
+			return err
+		}
+		return out.Write(values...)
+	})
+	if err == nil {
+		err = start()
+	}
+	if err != nil {
+		if out == nil {
+			return err
+		}
+		log.Printf("Error exporting %s: %v", name, err)
+		return nil
+	}
+	if err := out.Close(); err != nil {
+		log.Printf("Error finishing %s export of %s: %v", format, name, err)
+	}
+	return nil
+}
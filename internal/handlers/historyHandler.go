@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"tender/internal/export"
 	"tender/internal/models"
 	"tender/internal/services"
 )
@@ -11,26 +13,45 @@ type HistoryHandler struct {
 	Service *services.HistoryService
 }
 
+// GetAllHistory returns a page of the history as a JSON array, paged with offset.
 func (h *HistoryHandler) GetAllHistory(w http.ResponseWriter, r *http.Request) {
-	// Decode the JSON body
-	var req models.HistoryRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	req, ok := h.decodeHistoryRequest(w, r)
+	if !ok {
 		return
 	}
 
-	if req.Limit == 0 {
-		req.Limit = 50
+	history, err := h.Service.GetAllHistory(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, models.ErrUnknownHistorySource) {
+			http.Error(w, "Unknown history source", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
-	if req.Offset == 0 {
-		req.Offset = 0
-	} else {
-		req.Offset = req.Offset - 1
+
+	// Write the response
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(history)
+}
+
+// GetHistoryFeed returns a page of the history with the cursor of the next one.
+func (h *HistoryHandler) GetHistoryFeed(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decodeHistoryRequest(w, r)
+	if !ok {
+		return
 	}
 
-	history, err := h.Service.GetAllHistory(r.Context(), req.Source, req.StartDate, req.EndDate, req.Limit, req.Offset)
+	history, err := h.Service.GetHistoryPage(r.Context(), req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		switch {
+		case errors.Is(err, models.ErrUnknownHistorySource):
+			http.Error(w, "Unknown history source", http.StatusBadRequest)
+		case errors.Is(err, models.ErrInvalidCursor):
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
 		return
 	}
 
@@ -38,3 +59,44 @@ func (h *HistoryHandler) GetAllHistory(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(history)
 }
+
+// decodeHistoryRequest reads the request body. A request for a CSV or XLSX download is answered
+// here, and ok is false.
+func (h *HistoryHandler) decodeHistoryRequest(w http.ResponseWriter, r *http.Request) (req models.HistoryRequest, ok bool) {
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return req, false
+	}
+
+	format, ok := requestedFormat(w, r)
+	if !ok {
+		return req, false
+	}
+	if format != "" {
+		h.exportHistory(w, r, req, format)
+		return req, false
+	}
+	return req, true
+}
+
+var historyColumns = []export.Column{
+	{Key: "date", Kind: export.Timestamp}, {Key: "source"}, {Key: "id", Kind: export.Integer},
+	{Key: "description"}, {Key: "amount", Kind: export.Number}, {Key: "total", Kind: export.Number},
+	{Key: "status", Kind: export.Integer}, {Key: "user_id", Kind: export.Integer}, {Key: "company_id", Kind: export.Integer},
+}
+
+// exportHistory downloads every item matching the request; limit, offset and cursor are ignored.
+func (h *HistoryHandler) exportHistory(w http.ResponseWriter, r *http.Request, req models.HistoryRequest, format export.Format) {
+	err := writeExport(w, r, format, "history", historyColumns, func(write func(values ...interface{}) error) error {
+		return h.Service.StreamHistory(r.Context(), req, func(a models.CombinedAction) error {
+			return write(a.Date, a.Source, a.ID, a.Description, a.Amount, a.Total, a.Status, a.UserID, a.CompanyID)
+		})
+	})
+	if err != nil {
+		if errors.Is(err, models.ErrUnknownHistorySource) {
+			http.Error(w, "Unknown history source", http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,386 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"tender/internal/models"
+	"tender/internal/requestctx"
+	"tender/internal/services"
+)
+
+type NotificationHandler struct {
+	Service *services.NotificationService
+}
+
+// NotifyChange queues a notification for every device of the request's user.
+func (h *NotificationHandler) NotifyChange(w http.ResponseWriter, r *http.Request) {
+	var req models.NotificationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+
+	log.Printf("Received notification request: %+v", req)
+
+	if err := h.Service.Notify(r.Context(), req); err != nil {
+		log.Printf("Error queueing notification: %v", err)
+		http.Error(w, "Failed to queue notification", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Notification queued"))
+}
+
+// GetOutbox lists queued and delivered notifications, filtered by ?status=, ?user_id=,
+// ?before_id= and ?limit=.
+func (h *NotificationHandler) GetOutbox(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := models.OutboxFilter{Status: query.Get("status")}
+	switch filter.Status {
+	case "", models.OutboxPending, models.OutboxSent, models.OutboxFailed:
+	default:
+		http.Error(w, "status must be pending, sent or failed", http.StatusBadRequest)
+		return
+	}
+
+	for name, target := range map[string]*int{"user_id": &filter.UserID, "limit": &filter.Limit} {
+		if value := query.Get(name); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil {
+				http.Error(w, "Invalid "+name, http.StatusBadRequest)
+				return
+			}
+			*target = parsed
+		}
+	}
+	if value := query.Get("before_id"); value != "" {
+		beforeID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid before_id", http.StatusBadRequest)
+			return
+		}
+		filter.BeforeID = beforeID
+	}
+
+	page, err := h.Service.GetOutbox(r.Context(), filter)
+	if err != nil {
+		log.Printf("Error fetching notification outbox: %v", err)
+		http.Error(w, "Failed to fetch notification outbox", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// CreateToken registers the caller's device token with its platform, app version and locale,
+// or refreshes it when it is already registered. Older apps send no caller and name the user in
+// the body's user_id, as the route always accepted.
+func (h *NotificationHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	var newToken models.Token
+	if err := json.NewDecoder(r.Body).Decode(&newToken); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := requestctx.CallerFrom(r.Context())
+	if !ok {
+		if newToken.UserId == 0 {
+			http.Error(w, "X-User-ID header or user_id is required", http.StatusUnauthorized)
+			return
+		}
+		userID = newToken.UserId
+	}
+	if newToken.UserId != 0 && newToken.UserId != userID {
+		http.Error(w, "Tokens can only be registered for yourself", http.StatusForbidden)
+		return
+	}
+	newToken.UserId = userID
+
+	token, err := h.Service.RegisterToken(r.Context(), newToken)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidDeviceToken) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error registering token of user %d: %v", userID, err)
+		http.Error(w, "Failed to register token", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(token)
+}
+
+// GetTokens lists the caller's registered devices.
+func (h *NotificationHandler) GetTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestctx.CallerFrom(r.Context())
+	if !ok {
+		http.Error(w, "X-User-ID header is required", http.StatusUnauthorized)
+		return
+	}
+
+	tokens, err := h.Service.Devices(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error fetching tokens of user %d: %v", userID, err)
+		http.Error(w, "Failed to fetch tokens", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// DeleteToken removes the caller's device token :id.
+func (h *NotificationHandler) DeleteToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestctx.CallerFrom(r.Context())
+	if !ok {
+		http.Error(w, "X-User-ID header is required", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(r.URL.Query().Get(":id"))
+	if err != nil {
+		http.Error(w, "Invalid token ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.DeleteToken(r.Context(), id, userID); err != nil {
+		if errors.Is(err, models.ErrDeviceTokenNotFound) {
+			http.Error(w, "Token not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error deleting token %d: %v", id, err)
+		http.Error(w, "Failed to delete token", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// Logout removes the token of the device the caller signs out of, given as {"token": "..."}, or
+// all of their tokens when the body has none.
+func (h *NotificationHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestctx.CallerFrom(r.Context())
+	if !ok {
+		http.Error(w, "X-User-ID header is required", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Token string `json:"token"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	removed, err := h.Service.Logout(r.Context(), userID, req.Token)
+	if err != nil {
+		log.Printf("Error removing tokens of user %d: %v", userID, err)
+		http.Error(w, "Failed to remove tokens", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"removed": removed})
+}
+
+// ShowNotifyHistory returns the notifications sent to the user in the request body.
+func (h *NotificationHandler) ShowNotifyHistory(w http.ResponseWriter, r *http.Request) {
+	var newNotify models.Notify
+	if err := json.NewDecoder(r.Body).Decode(&newNotify); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	notify, err := h.Service.GetHistory(r.Context(), newNotify.UserID)
+	if err != nil {
+		log.Printf("Error fetching notifications: %v", err)
+		http.Error(w, "Failed to fetch notification", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(notify)
+}
+
+// GetInbox returns a page of the caller's notifications, newest first. ?unread=true leaves out
+// read ones; ?cursor= continues from the next_cursor of the previous page.
+func (h *NotificationHandler) GetInbox(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestctx.CallerFrom(r.Context())
+	if !ok {
+		http.Error(w, "X-User-ID header is required", http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	req := models.InboxRequest{UserID: userID, Cursor: query.Get("cursor")}
+	if value := query.Get("unread"); value != "" {
+		unread, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, "Invalid unread", http.StatusBadRequest)
+			return
+		}
+		req.UnreadOnly = unread
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		req.Limit = limit
+	}
+
+	page, err := h.Service.Inbox(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidCursor) {
+			http.Error(w, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error fetching inbox of user %d: %v", userID, err)
+		http.Error(w, "Failed to fetch notifications", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(page)
+}
+
+// GetUnreadCount returns how many of the caller's notifications are unread.
+func (h *NotificationHandler) GetUnreadCount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestctx.CallerFrom(r.Context())
+	if !ok {
+		http.Error(w, "X-User-ID header is required", http.StatusUnauthorized)
+		return
+	}
+
+	count, err := h.Service.UnreadCount(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error counting unread notifications of user %d: %v", userID, err)
+		http.Error(w, "Failed to count notifications", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"unread": count})
+}
+
+// MarkRead marks one of the caller's notifications as read.
+func (h *NotificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestctx.CallerFrom(r.Context())
+	if !ok {
+		http.Error(w, "X-User-ID header is required", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(r.URL.Query().Get(":id"))
+	if err != nil {
+		http.Error(w, "Invalid notification ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.MarkRead(r.Context(), id, userID); err != nil {
+		if errors.Is(err, models.ErrNotificationNotFound) {
+			http.Error(w, "Notification not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error marking notification %d as read: %v", id, err)
+		http.Error(w, "Failed to mark notification as read", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MarkAllRead marks every notification of the caller as read.
+func (h *NotificationHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestctx.CallerFrom(r.Context())
+	if !ok {
+		http.Error(w, "X-User-ID header is required", http.StatusUnauthorized)
+		return
+	}
+
+	marked, err := h.Service.MarkAllRead(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error marking notifications of user %d as read: %v", userID, err)
+		http.Error(w, "Failed to mark notifications as read", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"marked": marked})
+}
+
+// GetPreferences returns the caller's notification settings.
+func (h *NotificationHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestctx.CallerFrom(r.Context())
+	if !ok {
+		http.Error(w, "X-User-ID header is required", http.StatusUnauthorized)
+		return
+	}
+
+	settings, err := h.Service.GetSettings(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error fetching notification settings of user %d: %v", userID, err)
+		http.Error(w, "Failed to fetch notification settings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// UpdatePreferences replaces the caller's notification settings.
+func (h *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestctx.CallerFrom(r.Context())
+	if !ok {
+		http.Error(w, "X-User-ID header is required", http.StatusUnauthorized)
+		return
+	}
+
+	var settings models.NotificationSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	settings.UserID = userID
+
+	settings, err := h.Service.SaveSettings(r.Context(), settings)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidPreferences) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error saving notification settings of user %d: %v", userID, err)
+		http.Error(w, "Failed to save notification settings", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// DeleteNotifyHistory removes one history entry.
+func (h *NotificationHandler) DeleteNotifyHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Query().Get(":id"))
+	if err != nil {
+		http.Error(w, "Invalid notification ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.DeleteHistory(r.Context(), id); err != nil {
+		log.Printf("Error deleting notification: %v", err)
+		http.Error(w, "Failed to delete notification", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -2,11 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
 	"tender/internal/models"
 	"tender/internal/services"
+	"time"
 )
 
 type PersonalDebtHandler struct {
@@ -58,9 +61,24 @@ func (h *PersonalDebtHandler) GetPersonalDebtByID(w http.ResponseWriter, r *http
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	setETag(w, debt.Version)
 	json.NewEncoder(w).Encode(debt)
 }
 
+// GetOverdueDebts lists the unpaid personal debts whose return date has passed, with the amount
+// still to be returned.
+func (h *PersonalDebtHandler) GetOverdueDebts(w http.ResponseWriter, r *http.Request) {
+	debts, err := h.Service.GetOverdueDebts(r.Context(), time.Now())
+	if err != nil {
+		log.Printf("Error fetching overdue personal debts: %v", err)
+		http.Error(w, "Failed to fetch overdue personal debts", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(debts)
+}
+
 // Update a personal debt by ID
 func (h *PersonalDebtHandler) UpdatePersonalDebt(w http.ResponseWriter, r *http.Request) {
 	var debt models.PersonalDebt
@@ -69,14 +87,65 @@ func (h *PersonalDebtHandler) UpdatePersonalDebt(w http.ResponseWriter, r *http.
 		return
 	}
 
+	if version := ifMatchVersion(r); version != 0 {
+		debt.Version = version
+	}
+
 	updatedDebt, err := h.Service.UpdatePersonalDebt(r.Context(), &debt)
 	if err != nil {
+		if errors.Is(err, models.ErrVersionConflict) {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
 		log.Printf("Error updating personal debt: %v", err)
 		http.Error(w, "Failed to update personal debt", http.StatusInternalServerError)
 		return
 	}
+	if updatedDebt == nil {
+		http.Error(w, "Personal debt not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	setETag(w, updatedDebt.Version)
+	json.NewEncoder(w).Encode(updatedDebt)
+}
+
+// Patch a personal debt by ID with a JSON merge patch
+func (h *PersonalDebtHandler) PatchPersonalDebt(w http.ResponseWriter, r *http.Request) {
+	idStr := r.URL.Query().Get(":id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || idStr == "" {
+		http.Error(w, "Invalid or missing personal debt ID", http.StatusBadRequest)
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	updatedDebt, err := h.Service.PatchPersonalDebt(r.Context(), id, ifMatchVersion(r), patch)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidPatch):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, models.ErrVersionConflict):
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		default:
+			log.Printf("Error patching personal debt: %v", err)
+			http.Error(w, "Failed to update personal debt", http.StatusInternalServerError)
+		}
+		return
+	}
+	if updatedDebt == nil {
+		http.Error(w, "Personal debt not found", http.StatusNotFound)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
+	setETag(w, updatedDebt.Version)
 	json.NewEncoder(w).Encode(updatedDebt)
 }
 
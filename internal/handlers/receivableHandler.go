@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"tender/internal/models"
+	"tender/internal/services"
+	"time"
+)
+
+type ReceivableHandler struct {
+	Service *services.ReceivableService
+}
+
+// GetOverdue lists the completed transactions unpaid past their company's payment terms.
+func (h *ReceivableHandler) GetOverdue(w http.ResponseWriter, r *http.Request) {
+	overdue, err := h.Service.Overdue(r.Context(), time.Now())
+	if err != nil {
+		log.Printf("Error computing overdue receivables: %v", err)
+		http.Error(w, "Failed to compute overdue receivables", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(overdue)
+}
+
+// GetAlerts lists the overdue alerts sent, newest first, filtered by ?company_id= and ?limit=.
+func (h *ReceivableHandler) GetAlerts(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var companyID *int
+	if value := query.Get("company_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid company_id", http.StatusBadRequest)
+			return
+		}
+		companyID = &id
+	}
+	limit := 0
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	alerts, err := h.Service.Alerts(r.Context(), companyID, limit)
+	if err != nil {
+		log.Printf("Error fetching receivable alerts: %v", err)
+		http.Error(w, "Failed to fetch receivable alerts", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alerts)
+}
+
+// GetPaymentTerms returns the payment terms of the company :id.
+func (h *ReceivableHandler) GetPaymentTerms(w http.ResponseWriter, r *http.Request) {
+	companyID, err := strconv.Atoi(r.URL.Query().Get(":id"))
+	if err != nil {
+		http.Error(w, "Invalid company ID", http.StatusBadRequest)
+		return
+	}
+
+	terms, err := h.Service.GetPaymentTerms(r.Context(), companyID)
+	h.writeTerms(w, companyID, terms, err)
+}
+
+// UpdatePaymentTerms sets the payment terms of the company :id.
+func (h *ReceivableHandler) UpdatePaymentTerms(w http.ResponseWriter, r *http.Request) {
+	companyID, err := strconv.Atoi(r.URL.Query().Get(":id"))
+	if err != nil {
+		http.Error(w, "Invalid company ID", http.StatusBadRequest)
+		return
+	}
+
+	var terms models.PaymentTerms
+	if err := json.NewDecoder(r.Body).Decode(&terms); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	terms.CompanyID = companyID
+
+	terms, err = h.Service.SavePaymentTerms(r.Context(), terms)
+	h.writeTerms(w, companyID, terms, err)
+}
+
+// ResetPaymentTerms returns the company :id to the default payment terms.
+func (h *ReceivableHandler) ResetPaymentTerms(w http.ResponseWriter, r *http.Request) {
+	companyID, err := strconv.Atoi(r.URL.Query().Get(":id"))
+	if err != nil {
+		http.Error(w, "Invalid company ID", http.StatusBadRequest)
+		return
+	}
+
+	terms, err := h.Service.ResetPaymentTerms(r.Context(), companyID)
+	h.writeTerms(w, companyID, terms, err)
+}
+
+func (h *ReceivableHandler) writeTerms(w http.ResponseWriter, companyID int, terms models.PaymentTerms, err error) {
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrCompanyNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, models.ErrInvalidPaymentTerms):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			log.Printf("Error handling payment terms of company %d: %v", companyID, err)
+			http.Error(w, "Failed to handle payment terms", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(terms)
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"tender/internal/export"
+	"tender/internal/models"
+	"tender/internal/services"
+)
+
+type ReportHandler struct {
+	Service    *services.ReportService
+	Aggregates *services.AggregateService
+}
+
+// Query runs the pivot report described by the request body.
+func (h *ReportHandler) Query(w http.ResponseWriter, r *http.Request) {
+	format, ok := requestedFormat(w, r)
+	if !ok {
+		return
+	}
+	var q models.ReportQuery
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.Service.Query(r.Context(), q)
+	if err != nil {
+		if errors.Is(err, models.ErrUnknownDimension) || errors.Is(err, models.ErrUnknownMeasure) || errors.Is(err, models.ErrInvalidReport) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error running report: %v", err)
+		http.Error(w, "Failed to run report", http.StatusInternalServerError)
+		return
+	}
+
+	if format != "" {
+		exportReport(w, r, format, services.QueryTable(result))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// exportReport downloads a report built in memory; see writeExport.
+func exportReport(w http.ResponseWriter, r *http.Request, format export.Format, table export.Table) {
+	err := writeExport(w, r, format, table.Name, table.Columns, func(write func(values ...interface{}) error) error {
+		for _, row := range table.Rows {
+			if err := write(row...); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Error exporting %s: %v", table.Name, err)
+		http.Error(w, "Failed to export report", http.StatusInternalServerError)
+	}
+}
+
+// ProfitAndLoss returns the P&L for ?start_date=&end_date=, optionally scoped by ?company_id=
+// and ?user_id=.
+func (h *ReportHandler) ProfitAndLoss(w http.ResponseWriter, r *http.Request) {
+	format, ok := requestedFormat(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	filter := models.PnLFilter{StartDate: query.Get("start_date"), EndDate: query.Get("end_date")}
+	for name, target := range map[string]**int{"company_id": &filter.CompanyID, "user_id": &filter.UserID} {
+		if value := query.Get(name); value != "" {
+			id, err := strconv.Atoi(value)
+			if err != nil {
+				http.Error(w, "Invalid "+name, http.StatusBadRequest)
+				return
+			}
+			*target = &id
+		}
+	}
+
+	report, err := h.Service.ProfitAndLoss(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidReport) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error building P&L report: %v", err)
+		http.Error(w, "Failed to build P&L report", http.StatusInternalServerError)
+		return
+	}
+
+	if format != "" {
+		exportReport(w, r, format, services.PnLTable(report))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// Aging returns outstanding receivables per company bucketed by age, optionally for ?user_id=.
+func (h *ReportHandler) Aging(w http.ResponseWriter, r *http.Request) {
+	format, ok := requestedFormat(w, r)
+	if !ok {
+		return
+	}
+	var userID *int
+	if value := r.URL.Query().Get("user_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid user_id", http.StatusBadRequest)
+			return
+		}
+		userID = &id
+	}
+
+	report, err := h.Service.Aging(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error building aging report: %v", err)
+		http.Error(w, "Failed to build aging report", http.StatusInternalServerError)
+		return
+	}
+
+	if format != "" {
+		exportReport(w, r, format, services.AgingTable(report))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// CashFlowForecast projects the balance for ?interval=week|month (default week) over ?periods=
+// (default 12 weeks or 6 months).
+func (h *ReportHandler) CashFlowForecast(w http.ResponseWriter, r *http.Request) {
+	format, ok := requestedFormat(w, r)
+	if !ok {
+		return
+	}
+	interval := r.URL.Query().Get("interval")
+	if interval == "" {
+		interval = "week"
+	}
+	periods := 12
+	if interval == "month" {
+		periods = 6
+	}
+	if value := r.URL.Query().Get("periods"); value != "" {
+		var err error
+		if periods, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "Invalid periods", http.StatusBadRequest)
+			return
+		}
+	}
+
+	forecast, err := h.Service.CashFlowForecast(r.Context(), interval, periods)
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidReport) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error building cash-flow forecast: %v", err)
+		http.Error(w, "Failed to build cash-flow forecast", http.StatusInternalServerError)
+		return
+	}
+
+	if format != "" {
+		exportReport(w, r, format, services.CashFlowTable(forecast))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(forecast)
+}
+
+// Leaderboard ranks users for ?start_date=&end_date= by ?sort_by= (closed_deals, revenue,
+// margin, avg_cycle_days, collection_rate or tender_commission), optionally for ?company_id=.
+func (h *ReportHandler) Leaderboard(w http.ResponseWriter, r *http.Request) {
+	format, ok := requestedFormat(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	var companyID *int
+	if value := query.Get("company_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid company_id", http.StatusBadRequest)
+			return
+		}
+		companyID = &id
+	}
+
+	board, err := h.Service.Leaderboard(r.Context(), query.Get("start_date"), query.Get("end_date"), companyID, query.Get("sort_by"))
+	if err != nil {
+		if errors.Is(err, models.ErrInvalidReport) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error building leaderboard: %v", err)
+		http.Error(w, "Failed to build leaderboard", http.StatusInternalServerError)
+		return
+	}
+
+	if format != "" {
+		exportReport(w, r, format, services.LeaderboardTable(board))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(board)
+}
+
+// CheckAggregates compares the pre-aggregated report tables with live data.
+func (h *ReportHandler) CheckAggregates(w http.ResponseWriter, r *http.Request) {
+	check, err := h.Aggregates.Check(r.Context())
+	if err != nil {
+		log.Printf("Error checking report aggregates: %v", err)
+		http.Error(w, "Failed to check report aggregates", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(check)
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"tender/internal/models"
+	"tender/internal/requestctx"
+	"tender/internal/services"
+	"time"
+)
+
+// streamHeartbeat is how often an idle stream sends a comment, keeping proxies from closing it.
+var streamHeartbeat = 15 * time.Second
+
+type StreamHandler struct {
+	Service *services.StreamService
+}
+
+// Stream sends the caller the changes they may see as Server-Sent Events named
+// "<entity>.<action>", e.g. "transaction.update". A client reconnecting with Last-Event-ID (or
+// ?last_event_id=) first receives the changes it missed, or a "reset" event when they are no
+// longer available and it must reload its data.
+//
+// The caller is identified by the Authorization or X-User-ID header, which the browser's
+// EventSource cannot send: web clients need an SSE client built on fetch. The mobile app sends
+// the headers itself.
+func (h *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestctx.CallerFrom(r.Context())
+	if !ok {
+		http.Error(w, "X-User-ID header is required", http.StatusUnauthorized)
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var after int64
+	if lastEventID != "" {
+		parsed, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || parsed < 0 {
+			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		after = parsed
+	}
+
+	viewer, err := h.Service.Viewer(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error loading stream permissions of user %d: %v", userID, err)
+		http.Error(w, "Failed to open event stream", http.StatusInternalServerError)
+		return
+	}
+
+	// The stream outlives the server's read and write timeouts. Once the read deadline passes,
+	// the server would cancel the request even though the client sends nothing more.
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(time.Time{}); err != nil {
+		log.Printf("Error extending event stream read deadline: %v", err)
+	}
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Error extending event stream write deadline: %v", err)
+	}
+
+	missed, complete, events, unsubscribe := h.Service.Subscribe(after)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, "retry: 3000\n\n")
+	if !complete {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, e := range missed {
+		if viewer.CanSee(e) {
+			writeChangeEvent(w, e)
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		log.Printf("Error flushing event stream: %v", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case e, ok := <-events:
+			if !ok {
+				// Fell behind or the server is stopping; the client reconnects and replays
+				// from the buffer.
+				return
+			}
+			if viewer.AffectsPermissions(e) {
+				if reloaded, err := h.Service.Viewer(r.Context(), userID); err == nil {
+					viewer = reloaded
+				} else {
+					log.Printf("Error reloading stream permissions of user %d: %v", userID, err)
+				}
+			}
+			if !viewer.CanSee(e) {
+				continue
+			}
+			writeChangeEvent(w, e)
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeChangeEvent(w http.ResponseWriter, e models.ChangeEvent) {
+	data, _ := json.Marshal(e)
+	fmt.Fprintf(w, "id: %d\nevent: %s.%s\ndata: %s\n\n", e.ID, e.Entity, e.Action, data)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"tender/internal/models"
+	"tender/internal/requestctx"
+	"tender/internal/services"
+	"testing"
+	"time"
+)
+
+// A stream stays open past the server's read timeout and keeps sending heartbeats, and ends when
+// the service is closed.
+func TestStreamOutlivesReadTimeout(t *testing.T) {
+	defer func(d time.Duration) { streamHeartbeat = d }(streamHeartbeat)
+	streamHeartbeat = 150 * time.Millisecond
+
+	service := &services.StreamService{}
+	h := &StreamHandler{Service: service}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.Stream(w, r.WithContext(requestctx.WithCaller(r.Context(), models.AdminUserID)))
+	}))
+	srv.Config.ReadTimeout = 100 * time.Millisecond
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d, want 200", resp.StatusCode)
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	started := time.Now()
+	heartbeats := 0
+	for heartbeats < 3 && lines.Scan() {
+		if strings.HasPrefix(lines.Text(), ": heartbeat") {
+			heartbeats++
+		}
+	}
+	if heartbeats < 3 {
+		t.Fatalf("stream closed after %v and %d heartbeats: %v", time.Since(started), heartbeats, lines.Err())
+	}
+
+	service.Close()
+	for lines.Scan() {
+	}
+	if err := lines.Err(); err != nil {
+		t.Errorf("stream did not end cleanly on close: %v", err)
+	}
+}
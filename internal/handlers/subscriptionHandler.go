@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"tender/internal/models"
+	"tender/internal/requestctx"
+	"tender/internal/services"
+)
+
+type SubscriptionHandler struct {
+	Service *services.SubscriptionService
+}
+
+// ListSubscriptions returns the calling user's report subscriptions.
+func (h *SubscriptionHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestctx.CallerFrom(r.Context())
+	if !ok {
+		http.Error(w, "X-User-ID header is required", http.StatusUnauthorized)
+		return
+	}
+
+	subs, err := h.Service.List(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error fetching subscriptions of user %d: %v", userID, err)
+		http.Error(w, "Failed to fetch subscriptions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(subs)
+}
+
+// CreateSubscription subscribes the calling user to a report.
+func (h *SubscriptionHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestctx.CallerFrom(r.Context())
+	if !ok {
+		http.Error(w, "X-User-ID header is required", http.StatusUnauthorized)
+		return
+	}
+
+	var sub models.ReportSubscription
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	sub.UserID = userID
+
+	sub, err := h.Service.Create(r.Context(), sub)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidSubscription):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, models.ErrUserNotFound):
+			http.Error(w, "User not found", http.StatusNotFound)
+		default:
+			log.Printf("Error creating subscription: %v", err)
+			http.Error(w, "Failed to create subscription", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(sub)
+}
+
+// DeleteSubscription removes one of the calling user's subscriptions.
+func (h *SubscriptionHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := subscriptionParams(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.Delete(r.Context(), id, userID); err != nil {
+		if errors.Is(err, models.ErrSubscriptionNotFound) {
+			http.Error(w, "Subscription not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error deleting subscription %d: %v", id, err)
+		http.Error(w, "Failed to delete subscription", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetRuns returns the delivery history of one of the calling user's subscriptions.
+func (h *SubscriptionHandler) GetRuns(w http.ResponseWriter, r *http.Request) {
+	userID, id, ok := subscriptionParams(w, r)
+	if !ok {
+		return
+	}
+
+	runs, err := h.Service.Runs(r.Context(), id, userID)
+	if err != nil {
+		if errors.Is(err, models.ErrSubscriptionNotFound) {
+			http.Error(w, "Subscription not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("Error fetching runs of subscription %d: %v", id, err)
+		http.Error(w, "Failed to fetch subscription runs", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runs)
+}
+
+// subscriptionParams reads the caller and the :id of a subscription route, answering the
+// request itself when either is missing.
+func subscriptionParams(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	userID, ok := requestctx.CallerFrom(r.Context())
+	if !ok {
+		http.Error(w, "X-User-ID header is required", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	id, err := strconv.Atoi(r.URL.Query().Get(":id"))
+	if err != nil {
+		http.Error(w, "Invalid subscription ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return userID, id, true
+}
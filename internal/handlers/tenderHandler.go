@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
@@ -83,6 +84,9 @@ func (h *TenderHandler) UpdateTender(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	tender.ID = id
+	if version := ifMatchVersion(r); version != 0 {
+		tender.Version = version
+	}
 
 	updatedTender, err := h.Service.UpdateTender(r.Context(), tender)
 	if err != nil {
@@ -90,11 +94,56 @@ func (h *TenderHandler) UpdateTender(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
 		}
+		if errors.Is(err, models.ErrVersionConflict) {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	setETag(w, updatedTender.Version)
+	json.NewEncoder(w).Encode(updatedTender)
+}
+
+// PatchTender applies a JSON merge patch to a tender.
+func (h *TenderHandler) PatchTender(w http.ResponseWriter, r *http.Request) {
+	idStr := r.URL.Query().Get(":id")
+	if idStr == "" {
+		http.Error(w, "Missing tender ID", http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid tender ID", http.StatusBadRequest)
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	updatedTender, err := h.Service.PatchTender(r.Context(), id, ifMatchVersion(r), patch)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrTenderNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, models.ErrInvalidPatch):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, models.ErrVersionConflict):
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	setETag(w, updatedTender.Version)
 	json.NewEncoder(w).Encode(updatedTender)
 }
 
@@ -123,6 +172,7 @@ func (h *TenderHandler) GetTenderByID(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	setETag(w, tender.Version)
 	json.NewEncoder(w).Encode(tender)
 }
 
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
@@ -58,6 +60,7 @@ func (h *TrancheHandler) GetTrancheByID(w http.ResponseWriter, r *http.Request)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	setETag(w, tranche.Version)
 	json.NewEncoder(w).Encode(tranche)
 }
 
@@ -68,14 +71,65 @@ func (h *TrancheHandler) UpdateTranche(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if version := ifMatchVersion(r); version != 0 {
+		tranche.Version = version
+	}
+
 	updatedTranche, err := h.Service.UpdateTranche(r.Context(), &tranche)
 	if err != nil {
+		if errors.Is(err, models.ErrVersionConflict) {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
 		log.Printf("Error updating tranche: %v", err)
 		http.Error(w, "Failed to update tranche", http.StatusInternalServerError)
 		return
 	}
+	if updatedTranche == nil {
+		http.Error(w, "Tranche not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	setETag(w, updatedTranche.Version)
+	json.NewEncoder(w).Encode(updatedTranche)
+}
+
+// Patch a tranche by ID with a JSON merge patch
+func (h *TrancheHandler) PatchTranche(w http.ResponseWriter, r *http.Request) {
+	idStr := r.URL.Query().Get(":id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil || idStr == "" {
+		http.Error(w, "Invalid or missing tranche ID", http.StatusBadRequest)
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	updatedTranche, err := h.Service.PatchTranche(r.Context(), id, ifMatchVersion(r), patch)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrInvalidPatch):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, models.ErrVersionConflict):
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		default:
+			log.Printf("Error patching tranche: %v", err)
+			http.Error(w, "Failed to update tranche", http.StatusInternalServerError)
+		}
+		return
+	}
+	if updatedTranche == nil {
+		http.Error(w, "Tranche not found", http.StatusNotFound)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
+	setETag(w, updatedTranche.Version)
 	json.NewEncoder(w).Encode(updatedTranche)
 }
 
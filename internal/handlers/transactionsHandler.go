@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
@@ -61,6 +62,7 @@ func (h *TransactionHandler) GetTransactionByID(w http.ResponseWriter, r *http.R
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	setETag(w, transaction.Version)
 	json.NewEncoder(w).Encode(transaction)
 }
 
@@ -296,6 +298,9 @@ func (h *TransactionHandler) UpdateTransaction(w http.ResponseWriter, r *http.Re
 		return
 	}
 	transaction.ID = id
+	if version := ifMatchVersion(r); version != 0 {
+		transaction.Version = version
+	}
 
 	updatedTransaction, err := h.Service.UpdateTransaction(r.Context(), transaction)
 	if err != nil {
@@ -303,15 +308,61 @@ func (h *TransactionHandler) UpdateTransaction(w http.ResponseWriter, r *http.Re
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
 		}
+		if errors.Is(err, models.ErrVersionConflict) {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	setETag(w, updatedTransaction.Version)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(updatedTransaction)
 }
 
+// PatchTransaction applies a JSON merge patch to a transaction. Fields absent from the
+// patch keep their values, fields set to null are cleared.
+func (h *TransactionHandler) PatchTransaction(w http.ResponseWriter, r *http.Request) {
+	idStr := r.URL.Query().Get(":id")
+	if idStr == "" {
+		http.Error(w, "Missing transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	updatedTransaction, err := h.Service.PatchTransaction(r.Context(), id, ifMatchVersion(r), patch)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrTransactionNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, models.ErrInvalidPatch):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, models.ErrVersionConflict):
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	setETag(w, updatedTransaction.Version)
+	json.NewEncoder(w).Encode(updatedTransaction)
+}
+
 // DeleteTransaction deletes a transaction and its expenses by ID.
 func (h *TransactionHandler) DeleteTransaction(w http.ResponseWriter, r *http.Request) {
 	idStr := r.URL.Query().Get(":id")
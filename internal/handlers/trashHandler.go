@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"tender/internal/models"
+	"tender/internal/services"
+)
+
+type TrashHandler struct {
+	Service *services.TrashService
+}
+
+// GetTrash lists soft-deleted records. ?entity= limits the listing to one table.
+func (h *TrashHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	items, err := h.Service.GetTrash(r.Context(), r.URL.Query().Get("entity"))
+	if err != nil {
+		if errors.Is(err, models.ErrUnknownEntity) {
+			http.Error(w, "Unknown entity type", http.StatusBadRequest)
+			return
+		}
+		log.Printf("Error fetching trash: %v", err)
+		http.Error(w, "Failed to fetch trash", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items)
+}
+
+// Restore takes the record at /:entity/:id out of the trash.
+func (h *TrashHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	entity := r.URL.Query().Get(":entity")
+	id, err := strconv.Atoi(r.URL.Query().Get(":id"))
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	err = h.Service.Restore(r.Context(), entity, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUnknownEntity):
+			http.Error(w, "Unknown entity type", http.StatusNotFound)
+		case errors.Is(err, models.ErrNotInTrash):
+			http.Error(w, "Record not found in trash", http.StatusNotFound)
+		default:
+			log.Printf("Error restoring %s %d: %v", entity, id, err)
+			http.Error(w, "Failed to restore record", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Purge permanently removes records older than the retention period.
+func (h *TrashHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	result, err := h.Service.Purge(r.Context())
+	if err != nil {
+		log.Printf("Error purging trash: %v", err)
+		http.Error(w, "Failed to purge trash", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
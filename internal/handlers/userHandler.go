@@ -15,7 +15,8 @@ import (
 )
 
 type UserHandler struct {
-	Service *services.UserService
+	Service  *services.UserService
+	Sessions *services.SessionService
 }
 
 type BalanceUpdateRequest struct {
@@ -74,12 +75,32 @@ func (h *UserHandler) LogIn(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	userInfo.Token, err = h.Sessions.Start(r.Context(), userInfo.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 
 	json.NewEncoder(w).Encode(userInfo)
 }
 
+// LogOut ends the session of the token the request is authenticated with.
+func (h *UserHandler) LogOut(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		http.Error(w, "Authorization header is required", http.StatusUnauthorized)
+		return
+	}
+	if err := h.Sessions.End(r.Context(), token); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 	idStr := r.URL.Query().Get(":id")
 	if idStr == "" {
@@ -104,6 +125,7 @@ func (h *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	setETag(w, user.Version)
 	json.NewEncoder(w).Encode(user)
 }
 
@@ -238,6 +260,9 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	user.ID = id
+	if version := ifMatchVersion(r); version != 0 {
+		user.Version = version
+	}
 
 	updatedUser, err := h.Service.UpdateUser(r.Context(), user)
 	if err != nil {
@@ -245,15 +270,60 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, err.Error(), http.StatusNotFound)
 			return
 		}
+		if errors.Is(err, models.ErrVersionConflict) {
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	setETag(w, updatedUser.Version)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(updatedUser)
 }
 
+// PatchUser applies a JSON merge patch to a user, so fields can be set to zero values explicitly.
+func (h *UserHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	idStr := r.URL.Query().Get(":id")
+	if idStr == "" {
+		http.Error(w, "Missing user ID", http.StatusBadRequest)
+		return
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	updatedUser, err := h.Service.PatchUser(r.Context(), id, ifMatchVersion(r), patch)
+	if err != nil {
+		switch {
+		case errors.Is(err, models.ErrUserNotFound):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case errors.Is(err, models.ErrInvalidPatch):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, models.ErrVersionConflict):
+			http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	setETag(w, updatedUser.Version)
+	json.NewEncoder(w).Encode(updatedUser)
+}
+
 func (h *UserHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	idStr := r.URL.Query().Get(":id")
 	if idStr == "" {
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"tender/internal/models"
+	"tender/internal/requestctx"
+	"tender/internal/services"
+)
+
+type WebhookHandler struct {
+	Service *services.WebhookService
+}
+
+// writeWebhookError maps webhook errors to responses.
+func writeWebhookError(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, models.ErrWebhookNotFound):
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+	case errors.Is(err, models.ErrWebhookDeliveryNotFound):
+		http.Error(w, "Webhook delivery not found", http.StatusNotFound)
+	case errors.Is(err, models.ErrInvalidWebhook):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("Error trying to %s: %v", action, err)
+		http.Error(w, "Failed to "+action, http.StatusInternalServerError)
+	}
+}
+
+func webhookID(r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.URL.Query().Get(":id"))
+	return id, err == nil
+}
+
+// GetEventTypes lists the event types webhooks can subscribe to.
+func (h *WebhookHandler) GetEventTypes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Service.EventTypes())
+}
+
+// CreateWebhook registers a webhook from {"url", "events", "description"}. The response holds
+// the secret payloads are signed with; it is not shown again.
+func (h *WebhookHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requestctx.CallerFrom(r.Context())
+	if !ok {
+		http.Error(w, "X-User-ID header is required", http.StatusUnauthorized)
+		return
+	}
+
+	var webhook models.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.Service.Create(r.Context(), webhook, userID)
+	if err != nil {
+		writeWebhookError(w, err, "create webhook")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+func (h *WebhookHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := h.Service.List(r.Context())
+	if err != nil {
+		writeWebhookError(w, err, "fetch webhooks")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhooks)
+}
+
+func (h *WebhookHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(r)
+	if !ok {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := h.Service.Get(r.Context(), id)
+	if err != nil {
+		writeWebhookError(w, err, "fetch webhook")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(webhook)
+}
+
+// UpdateWebhook replaces a webhook's url, events and description and turns it on or off with
+// "active". Turning a disabled webhook on resumes its pending deliveries.
+func (h *WebhookHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(r)
+	if !ok {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	var webhook models.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	webhook.ID = id
+
+	updated, err := h.Service.Update(r.Context(), webhook)
+	if err != nil {
+		writeWebhookError(w, err, "update webhook")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(updated)
+}
+
+func (h *WebhookHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(r)
+	if !ok {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.Delete(r.Context(), id); err != nil {
+		writeWebhookError(w, err, "delete webhook")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PingWebhook posts a test "ping" event to a webhook and returns the delivery with its attempt.
+func (h *WebhookHandler) PingWebhook(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(r)
+	if !ok {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := h.Service.Ping(r.Context(), id)
+	if err != nil {
+		writeWebhookError(w, err, "ping webhook")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// ListDeliveries lists a webhook's deliveries, newest first, filtered by ?status=, ?before_id=
+// and ?limit=.
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, ok := webhookID(r)
+	if !ok {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := models.WebhookDeliveryFilter{WebhookID: id, Status: query.Get("status")}
+	switch filter.Status {
+	case "", models.OutboxPending, models.OutboxSent, models.OutboxFailed:
+	default:
+		http.Error(w, "status must be pending, sent or failed", http.StatusBadRequest)
+		return
+	}
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+	if value := query.Get("before_id"); value != "" {
+		beforeID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid before_id", http.StatusBadRequest)
+			return
+		}
+		filter.BeforeID = beforeID
+	}
+
+	deliveries, err := h.Service.Deliveries(r.Context(), filter)
+	if err != nil {
+		writeWebhookError(w, err, "fetch webhook deliveries")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+func deliveryParams(w http.ResponseWriter, r *http.Request) (int, int64, bool) {
+	id, ok := webhookID(r)
+	if !ok {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	deliveryID, err := strconv.ParseInt(r.URL.Query().Get(":delivery_id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid delivery ID", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return id, deliveryID, true
+}
+
+// GetDelivery returns a delivery with its payload and the log of its attempts.
+func (h *WebhookHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	id, deliveryID, ok := deliveryParams(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.Service.Delivery(r.Context(), id, deliveryID)
+	if err != nil {
+		writeWebhookError(w, err, "fetch webhook delivery")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// Redeliver posts a delivery again right away and returns it with its attempt log.
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	id, deliveryID, ok := deliveryParams(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.Service.Redeliver(r.Context(), id, deliveryID)
+	if err != nil {
+		writeWebhookError(w, err, "redeliver webhook delivery")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
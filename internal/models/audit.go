@@ -0,0 +1,40 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+var ErrUnknownAuditEntity = errors.New("models: unknown audit entity")
+
+// Audit actions.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+)
+
+// AuditEntry records one change to one row. Changes maps each changed column to its
+// {"before": ..., "after": ...} values.
+type AuditEntry struct {
+	ID        int64           `json:"id"`
+	ActorID   *int            `json:"actor_id"`
+	Entity    string          `json:"entity"`
+	EntityID  int             `json:"entity_id"`
+	Action    string          `json:"action"`
+	Changes   json.RawMessage `json:"changes"`
+	RequestID *string         `json:"request_id"`
+	IP        *string         `json:"ip"`
+	CreatedAt string          `json:"created_at"`
+}
+
+// AuditFilter selects audit entries. Zero values match everything; BeforeID pages backwards.
+type AuditFilter struct {
+	Entity   string
+	EntityID int
+	ActorID  int
+	BeforeID int64
+	Limit    int
+}
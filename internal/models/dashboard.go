@@ -0,0 +1,32 @@
+package models
+
+// Dashboard is the home screen summary. Admins see the whole business, other users only
+// their own deals.
+type Dashboard struct {
+	UserID         *int              `json:"user_id"` // nil for the whole business
+	DealsByStatus  []StatusCount     `json:"deals_by_status"`
+	Revenue        RevenueComparison `json:"revenue"`
+	Receivables    float64           `json:"receivables"`
+	TopDebtors     []Debtor          `json:"top_debtors"`
+	Balance        float64           `json:"balance"`
+	RecentActivity []CombinedAction  `json:"recent_activity"`
+	GeneratedAt    string            `json:"generated_at"`
+}
+
+type StatusCount struct {
+	Status int `json:"status"`
+	Count  int `json:"count"`
+}
+
+// RevenueComparison compares the sell total of this month with the previous one.
+type RevenueComparison struct {
+	ThisMonth float64  `json:"this_month"`
+	LastMonth float64  `json:"last_month"`
+	ChangePct *float64 `json:"change_pct"` // nil when last month had no revenue
+}
+
+type Debtor struct {
+	CompanyID   *int    `json:"company_id"`
+	CompanyName *string `json:"company_name"`
+	Outstanding float64 `json:"outstanding"`
+}
@@ -0,0 +1,36 @@
+package models
+
+// ReconciliationEntry is a line of a reconciliation act. A sale raises what the company owes
+// (debit), a payment lowers it (credit).
+type ReconciliationEntry struct {
+	Date          string  `json:"date"` // YYYY-MM-DD
+	Kind          string  `json:"kind"` // sale or payment
+	TransactionID int     `json:"transaction_id"`
+	TenderNumber  *string `json:"tender_number"`
+	Description   *string `json:"description"`
+	Debit         float64 `json:"debit"`
+	Credit        float64 `json:"credit"`
+}
+
+// ReconciliationAct settles the balance with a company over a period. Balances are what the
+// company owes; a negative balance is a prepayment.
+type ReconciliationAct struct {
+	CompanyID      int                   `json:"company_id"`
+	CompanyName    string                `json:"company_name"`
+	StartDate      string                `json:"start_date"`
+	EndDate        string                `json:"end_date"`
+	OpeningBalance float64               `json:"opening_balance"`
+	Entries        []ReconciliationEntry `json:"entries"`
+	Sales          float64               `json:"sales"`
+	Payments       float64               `json:"payments"`
+	ClosingBalance float64               `json:"closing_balance"`
+}
+
+// TransactionStatement lists a transaction with its additional expenses and the tranches paid.
+type TransactionStatement struct {
+	Transaction   Transaction `json:"transaction"`
+	Payments      []Tranche   `json:"payments"`
+	ExpensesTotal float64     `json:"expenses_total"`
+	Paid          float64     `json:"paid"`
+	Outstanding   float64     `json:"outstanding"`
+}
@@ -15,4 +15,6 @@ var (
 	ErrExpenseNotFound        = errors.New("personal expense not found")
 	ErrCategoryNotFound       = errors.New("category not found")
 	ErrBalanceHistoryNotFound = errors.New("balance history not found")
+	ErrVersionConflict        = errors.New("models: record was modified by another request")
+	ErrInvalidPatch           = errors.New("models: invalid merge patch document")
 )
@@ -0,0 +1,13 @@
+package models
+
+import "errors"
+
+var ErrUnsupportedFormat = errors.New("models: unsupported export format")
+
+// ExportFilter narrows a list export. Fields a list has no column for are ignored.
+type ExportFilter struct {
+	StartDate string
+	EndDate   string
+	UserID    *int
+	CompanyID *int
+}
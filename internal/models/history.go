@@ -1,17 +1,41 @@
 package models
 
+import "errors"
+
+var (
+	ErrUnknownHistorySource = errors.New("models: unknown history source")
+	ErrInvalidCursor        = errors.New("models: invalid cursor")
+)
+
 type CombinedAction struct {
-	ID     int      `json:"id"`
-	Source string   `json:"source"` // Table name or source
-	Amount float64  `json:"amount"`
-	Total  *float64 `json:"total,omitempty"` // Nullable
-	Date   string   `json:"date"`
+	ID          int      `json:"id"`
+	Source      string   `json:"source"` // Table name or source
+	Amount      float64  `json:"amount"`
+	Total       *float64 `json:"total,omitempty"` // Nullable
+	Description *string  `json:"description"`
+	Status      *int     `json:"status"`     // Nullable, only for sources that track a status
+	UserID      *int     `json:"user_id"`    // Nullable, personal records have no user
+	CompanyID   *int     `json:"company_id"` // Nullable
+	Date        string   `json:"date"`
+	// Record is the underlying row, included when the request asks for expand.
+	Record map[string]interface{} `json:"record,omitempty"`
 }
 
 type HistoryRequest struct {
-	Source    *string `json:"source,omitempty"` // Filter by source (optional)
-	StartDate string  `json:"start_date"`       // Start date (required)
-	EndDate   string  `json:"end_date"`         // End date (required)
-	Limit     int     `json:"limit,omitempty"`  // Number of records to return (default: 10)
-	Offset    int     `json:"offset,omitempty"` // Number of records to skip (default: 0)
+	Source    *string  `json:"source,omitempty"`     // Filter by a single source (optional, kept for older clients)
+	Sources   []string `json:"sources,omitempty"`    // Filter by several sources (optional)
+	UserID    *int     `json:"user_id,omitempty"`    // Filter by user (optional)
+	CompanyID *int     `json:"company_id,omitempty"` // Filter by company (optional)
+	StartDate string   `json:"start_date"`           // Start date (optional)
+	EndDate   string   `json:"end_date"`             // End date (optional)
+	Limit     int      `json:"limit,omitempty"`      // Number of records to return (default: 50)
+	Offset    int      `json:"offset,omitempty"`     // Position of the page, from 1, on /history/all
+	Cursor    string   `json:"cursor,omitempty"`     // next_cursor of the previous page, on /history/feed
+	Expand    bool     `json:"expand,omitempty"`     // Embed the underlying record in each item
+}
+
+type HistoryPage struct {
+	Items []CombinedAction `json:"items"`
+	// NextCursor fetches the following page; it is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
 }
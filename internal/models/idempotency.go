@@ -0,0 +1,27 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrIdempotencyKeyReused   = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyKeyInFlight = errors.New("a request with this idempotency key is still being processed")
+)
+
+// IdempotencyRecord stores the outcome of a create request keyed by the caller's Idempotency-Key.
+// A StatusCode of 0 marks a request that is still being processed.
+type IdempotencyRecord struct {
+	ID           int       `json:"id"`
+	Key          string    `json:"key"`
+	UserID       int       `json:"user_id"`
+	Method       string    `json:"method"`
+	Path         string    `json:"path"`
+	RequestHash  string    `json:"request_hash"`
+	StatusCode   int       `json:"status_code"`
+	ContentType  *string   `json:"content_type,omitempty"`
+	ResponseBody []byte    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
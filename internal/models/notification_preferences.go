@@ -0,0 +1,74 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInvalidPreferences = errors.New("models: invalid notification preferences")
+
+// Notification channels.
+const (
+	ChannelPush  = "push"
+	ChannelEmail = "email"
+	ChannelInApp = "in_app"
+)
+
+// Delivery modes of a channel.
+const (
+	ModeInstant = "instant"
+	ModeDigest  = "digest" // batched into the daily digest; in-app notifications are never batched
+	ModeOff     = "off"
+)
+
+// AllEvents is the event of a preference that applies to every event without its own.
+const AllEvents = "*"
+
+// NotificationPreference sets how one channel delivers one event type, e.g.
+// {"event": "tranche.received", "channel": "push", "mode": "digest"}. Notifications sent
+// through POST /notify have the event type "message".
+type NotificationPreference struct {
+	Event   string `json:"event"`
+	Channel string `json:"channel"`
+	Mode    string `json:"mode"`
+}
+
+// NotificationSettings are a user's notification preferences. Without a preference, push and
+// in-app notifications are instant and email is off.
+type NotificationSettings struct {
+	UserID int `json:"user_id"`
+	// Timezone is an IANA name such as "Europe/Moscow"; empty means the server's time zone.
+	Timezone string `json:"timezone"`
+	// QuietStart and QuietEnd ("22:00", "08:00") hold back push notifications in between until
+	// QuietEnd, in Timezone. Both are empty when quiet hours are off.
+	QuietStart  string                   `json:"quiet_start"`
+	QuietEnd    string                   `json:"quiet_end"`
+	DigestHour  int                      `json:"digest_hour"` // local hour the daily digest is sent at
+	Preferences []NotificationPreference `json:"preferences"`
+}
+
+// DigestItem is a notification held back for a user's daily digest.
+type DigestItem struct {
+	ID        int64
+	Channel   string
+	Title     string
+	Body      string
+	CreatedAt string
+}
+
+// DigestRecipient is a user with notifications waiting for the daily digest.
+type DigestRecipient struct {
+	UserID       int
+	Timezone     string
+	DigestHour   int
+	LastDigestAt *string
+}
+
+// NotificationDelivery is how one notification reaches its user, as decided by their settings.
+type NotificationDelivery struct {
+	InApp bool
+	Push  string // a delivery mode
+	// PushAfter holds back instant push delivery until the end of quiet hours.
+	PushAfter time.Time
+	Email     string // a delivery mode
+}
@@ -1,5 +1,14 @@
 package models
 
+import "errors"
+
+var (
+	ErrNotificationNotFound = errors.New("models: notification not found")
+	ErrDeviceTokenNotFound  = errors.New("models: device token not found")
+	ErrInvalidDeviceToken   = errors.New("models: invalid device token")
+)
+
+// Notify is a notification in a user's inbox.
 type Notify struct {
 	ID       int    `json:"id"`
 	UserID   int    `json:"user_id"`
@@ -7,4 +16,102 @@ type Notify struct {
 	Body     string `json:"body"`
 	Sender   int    `json:"sender"`
 	Receiver int    `json:"receiver"`
+	// Category groups notifications in the app: transaction, tranche, tender, balance, company,
+	// personal_debt, receivable, or message for notifications sent through POST /notify.
+	Category string `json:"category"`
+	// Data is the deep link sent with the push: link, param1 and param2.
+	Data      map[string]string `json:"data"`
+	CreatedAt string            `json:"created_at"`
+	ReadAt    *string           `json:"read_at"`
+}
+
+// InboxRequest selects a page of the caller's inbox, newest first.
+type InboxRequest struct {
+	UserID     int
+	UnreadOnly bool
+	Cursor     string
+	Limit      int
+}
+
+type InboxPage struct {
+	Items       []Notify `json:"items"`
+	NextCursor  string   `json:"next_cursor,omitempty"`
+	UnreadCount int      `json:"unread_count"`
+}
+
+type NotificationRequest struct {
+	Id       int    `json:"id"`
+	UserId   int    `json:"user_id"`
+	Token    string `json:"token"`
+	Title    string `json:"title"`
+	Body     string `json:"body"`
+	Sender   int    `json:"sender"`
+	Receiver int    `json:"receiver"`
+	Link     string `json:"link"`
+	Param1   string `json:"param1"`
+	Param2   string `json:"param2"`
+	Category string `json:"category,omitempty"`
+	// Event is the event type preferences are looked up by; "message" when empty.
+	Event string `json:"-"`
+	// Urgent notifications skip the daily digest.
+	Urgent bool `json:"-"`
+}
+
+// Device platforms a token can be registered for.
+const (
+	PlatformAndroid = "android"
+	PlatformIOS     = "ios"
+	PlatformWeb     = "web"
+)
+
+// Token is a push token of one of a user's devices. A token belongs to a single user: when another
+// user signs in on the device, registering it again moves it to them.
+type Token struct {
+	ID         int     `json:"id"`
+	UserId     int     `json:"user_id"`
+	Token      string  `json:"token"`
+	Platform   *string `json:"platform"`
+	AppVersion *string `json:"app_version"`
+	Locale     *string `json:"locale"`
+	CreatedAt  string  `json:"created_at"`
+	LastSeenAt string  `json:"last_seen_at"`
+}
+
+// Outbox delivery statuses.
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxFailed  = "failed"
+)
+
+// OutboxMessage is a notification waiting for, or done with, delivery to one device.
+type OutboxMessage struct {
+	ID            int64             `json:"id"`
+	HistoryID     *int              `json:"history_id"`
+	UserID        int               `json:"user_id"`
+	Channel       string            `json:"channel"`
+	Token         string            `json:"token"` // device token, or email address for the email channel
+	Title         string            `json:"title"`
+	Body          string            `json:"body"`
+	Data          map[string]string `json:"data"`
+	Status        string            `json:"status"`
+	Attempts      int               `json:"attempts"`
+	NextAttemptAt string            `json:"next_attempt_at"`
+	LastError     *string           `json:"last_error"`
+	MessageID     *string           `json:"message_id"`
+	CreatedAt     string            `json:"created_at"`
+	SentAt        *string           `json:"sent_at"`
+}
+
+type OutboxFilter struct {
+	Status   string
+	UserID   int
+	BeforeID int64
+	Limit    int
+}
+
+// OutboxPage is a page of the outbox, newest first, with the number of messages in each status.
+type OutboxPage struct {
+	Items  []OutboxMessage `json:"items"`
+	Counts map[string]int  `json:"counts"`
 }
@@ -1,13 +1,56 @@
 package models
 
+import "time"
+
 type PersonalDebt struct {
 	ID         int     `json:"id"`
 	Name       string  `json:"name"`
 	Amount     float64 `json:"amount"`
-	Type       int     `json:"type"`
+	Type       int     `json:"type"` // set by the app; which values are given or taken is configured as DebtTypes
 	GetDate    *string `json:"get_date,omitempty"`
 	ReturnDate *string `json:"return_date,omitempty"`
 	Status     int     `json:"status"`
 	CreatedAt  string  `json:"created_at"`
 	UpdatedAt  string  `json:"updated_at"`
+	Version    int     `json:"version"`
+}
+
+// Directions of a personal debt.
+const (
+	DebtGiven = "given" // money lent out, expected back
+	DebtTaken = "taken" // money borrowed, to be repaid
+)
+
+// DebtTypes maps the values of PersonalDebt.Type to a direction. A nil value is not configured.
+type DebtTypes struct {
+	Given *int
+	Taken *int
+}
+
+// Direction returns DebtGiven or DebtTaken for a debt type, or "" when the type is not configured.
+func (t DebtTypes) Direction(debtType int) string {
+	switch {
+	case t.Given != nil && *t.Given == debtType:
+		return DebtGiven
+	case t.Taken != nil && *t.Taken == debtType:
+		return DebtTaken
+	}
+	return ""
+}
+
+// Personal debt reminder kinds.
+const (
+	DebtReminderUpcoming = "upcoming" // the return date is near
+	DebtReminderOverdue  = "overdue"  // the return date has passed
+)
+
+// UnpaidDebt is a personal debt with a return date that has not been fully paid back.
+type UnpaidDebt struct {
+	PersonalDebt
+	Paid      float64 `json:"paid"`
+	Remaining float64 `json:"remaining"`
+	// DaysOverdue is how many days ago the return date was; 0 when it has not passed.
+	DaysOverdue int       `json:"days_overdue"`
+	OverdueAt   *string   `json:"overdue_at"`
+	Due         time.Time `json:"-"`
 }
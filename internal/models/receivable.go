@@ -0,0 +1,46 @@
+package models
+
+import "errors"
+
+var ErrInvalidPaymentTerms = errors.New("models: invalid payment terms")
+
+// Receivable alert levels.
+const (
+	AlertOverdue   = "overdue"   // unpaid past the company's payment terms
+	AlertEscalated = "escalated" // unpaid past the escalation threshold
+)
+
+// PaymentTerms is how many days after completion a company has to pay for a transaction, and
+// after how many days an unpaid transaction is escalated.
+type PaymentTerms struct {
+	CompanyID    int  `json:"company_id"`
+	DueDays      int  `json:"due_days"`
+	EscalateDays int  `json:"escalate_days"`
+	Default      bool `json:"default"` // the company has no terms of its own
+}
+
+// OverdueReceivable is a completed transaction still unpaid past its company's payment terms.
+type OverdueReceivable struct {
+	TransactionID int     `json:"transaction_id"`
+	CompanyID     *int    `json:"company_id"`
+	CompanyName   *string `json:"company_name"`
+	UserID        *int    `json:"user_id"`
+	TenderNumber  *string `json:"tender_number"`
+	ProductName   *string `json:"product_name"`
+	Outstanding   float64 `json:"outstanding"`
+	CompletedDate string  `json:"completed_date"`
+	DueDate       string  `json:"due_date"`
+	DaysOverdue   int     `json:"days_overdue"`
+	Level         string  `json:"level"`
+}
+
+// ReceivableAlert records an alert sent for an overdue transaction; each level is sent once.
+type ReceivableAlert struct {
+	TransactionID int     `json:"transaction_id"`
+	Level         string  `json:"level"`
+	CompanyID     *int    `json:"company_id"`
+	UserID        *int    `json:"user_id"`
+	DueDate       string  `json:"due_date"`
+	Outstanding   float64 `json:"outstanding"`
+	SentAt        string  `json:"sent_at"`
+}
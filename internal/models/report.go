@@ -0,0 +1,238 @@
+package models
+
+import "errors"
+
+var (
+	ErrUnknownDimension = errors.New("models: unknown report dimension")
+	ErrUnknownMeasure   = errors.New("models: unknown report measure")
+	ErrInvalidReport    = errors.New("models: invalid report query")
+)
+
+// ReportQuery describes a pivot over transactions: rows are grouped by Dimensions
+// (year, month, quarter, company, user, type, status) and aggregated into Measures
+// (amount, total, sell, margin, count).
+type ReportQuery struct {
+	Dimensions []string      `json:"dimensions"`
+	Measures   []string      `json:"measures"`
+	Filters    ReportFilters `json:"filters"`
+	// OrderBy lists dimensions or measures to sort by; a leading "-" sorts descending.
+	OrderBy []string `json:"order_by,omitempty"`
+	Limit   int      `json:"limit,omitempty"`
+}
+
+type ReportFilters struct {
+	Year      *int    `json:"year,omitempty"`
+	Month     *int    `json:"month,omitempty"`
+	Quarter   *int    `json:"quarter,omitempty"`
+	CompanyID *int    `json:"company_id,omitempty"`
+	UserID    *int    `json:"user_id,omitempty"`
+	Type      *string `json:"type,omitempty"`
+	Status    *int    `json:"status,omitempty"`
+	StartDate string  `json:"start_date,omitempty"`
+	EndDate   string  `json:"end_date,omitempty"`
+}
+
+// ReportRow maps dimension and measure names to values. The company and user dimensions
+// add company_id/company_name and user_id/user_name.
+type ReportRow map[string]interface{}
+
+type ReportResult struct {
+	Dimensions []string    `json:"dimensions"`
+	Measures   []string    `json:"measures"`
+	Rows       []ReportRow `json:"rows"`
+}
+
+// PnLFilter selects the period and scope of a profit and loss report. Dates are YYYY-MM-DD
+// and inclusive.
+type PnLFilter struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	CompanyID *int   `json:"company_id,omitempty"`
+	UserID    *int   `json:"user_id,omitempty"`
+}
+
+// PnLFigures are the profit and loss lines of a month or of the whole period.
+type PnLFigures struct {
+	Revenue            float64 `json:"revenue"`             // transactions.sell
+	Cost               float64 `json:"cost"`                // transactions.total, the purchase cost
+	AdditionalExpenses float64 `json:"additional_expenses"` // additional_expenses of those transactions
+	GrossMargin        float64 `json:"gross_margin"`
+	// TenderCommissions is keyed by tender type (ГОИК, ГОПП).
+	TenderCommissions map[string]float64 `json:"tender_commissions"`
+	ExtraTransactions float64            `json:"extra_transactions"`
+	// PersonalExpenses is keyed by category name. Personal expenses belong to no user or
+	// company, so they are left out when the report is scoped to one.
+	PersonalExpenses map[string]float64 `json:"personal_expenses"`
+	NetMargin        float64            `json:"net_margin"`
+}
+
+// TenderCommissionTotal returns the tender commissions of all tender types together.
+func (f PnLFigures) TenderCommissionTotal() float64 {
+	return sumAmounts(f.TenderCommissions)
+}
+
+// PersonalExpenseTotal returns the personal expenses of all categories together.
+func (f PnLFigures) PersonalExpenseTotal() float64 {
+	return sumAmounts(f.PersonalExpenses)
+}
+
+func sumAmounts(m map[string]float64) float64 {
+	var sum float64
+	for _, v := range m {
+		sum += v
+	}
+	return sum
+}
+
+// PnLChange compares a month with the one before it. Percentages are nil when the
+// previous value is zero.
+type PnLChange struct {
+	Revenue        float64  `json:"revenue"`
+	RevenuePct     *float64 `json:"revenue_pct"`
+	GrossMargin    float64  `json:"gross_margin"`
+	GrossMarginPct *float64 `json:"gross_margin_pct"`
+	NetMargin      float64  `json:"net_margin"`
+	NetMarginPct   *float64 `json:"net_margin_pct"`
+}
+
+type PnLMonth struct {
+	Month string `json:"month"` // YYYY-MM
+	PnLFigures
+	Change *PnLChange `json:"change,omitempty"` // nil for the first month of the period
+}
+
+type PnLReport struct {
+	PnLFilter
+	Months []PnLMonth `json:"months"`
+	Total  PnLFigures `json:"total"`
+}
+
+// AgingBuckets splits outstanding debt by days since the transaction was completed. Debt of
+// transactions with neither a completed date nor a date cannot be aged; it is kept in Undated and
+// left out of Total.
+type AgingBuckets struct {
+	Days0To30  float64 `json:"0_30"`
+	Days31To60 float64 `json:"31_60"`
+	Days61To90 float64 `json:"61_90"`
+	Days90Plus float64 `json:"90_plus"`
+	Total      float64 `json:"total"`
+	Undated    float64 `json:"undated"`
+}
+
+// AgingItem is a completed transaction that has not been fully paid with tranches.
+type AgingItem struct {
+	TransactionID   int     `json:"transaction_id"`
+	TenderNumber    *string `json:"tender_number"`
+	ProductName     *string `json:"product_name"`
+	Sell            float64 `json:"sell"`
+	Paid            float64 `json:"paid"`
+	Outstanding     float64 `json:"outstanding"`
+	CompletedDate   *string `json:"completed_date"`   // nil when the transaction has no date
+	DaysOutstanding *int    `json:"days_outstanding"` // nil when the transaction has no date
+	Bucket          string  `json:"bucket"`
+	LastPaymentDate *string `json:"last_payment_date"`
+}
+
+type AgingCompany struct {
+	CompanyID       *int         `json:"company_id"`
+	CompanyName     *string      `json:"company_name"`
+	Buckets         AgingBuckets `json:"buckets"`
+	OldestUnpaid    *AgingItem   `json:"oldest_unpaid"`
+	LastPaymentDate *string      `json:"last_payment_date"`
+	Items           []AgingItem  `json:"items"`
+}
+
+type AgingReport struct {
+	AsOf      string         `json:"as_of"`
+	UserID    *int           `json:"user_id,omitempty"`
+	Companies []AgingCompany `json:"companies"`
+	Totals    AgingBuckets   `json:"totals"`
+}
+
+type CashFlowInflows struct {
+	Receivables     float64 `json:"receivables"`      // unpaid sell not covered by a planned tranche, due now
+	PlannedTranches float64 `json:"planned_tranches"` // tranches dated in the future
+	PersonalDebts   float64 `json:"personal_debts"`   // money lent out coming back
+	Total           float64 `json:"total"`
+}
+
+type CashFlowOutflows struct {
+	PersonalDebts     float64 `json:"personal_debts"`     // borrowed money to be repaid
+	RecurringExpenses float64 `json:"recurring_expenses"` // personal expenses at their recent monthly average
+	Total             float64 `json:"total"`
+}
+
+type CashFlowPeriod struct {
+	Start    string           `json:"start"`
+	End      string           `json:"end"` // exclusive
+	Inflows  CashFlowInflows  `json:"inflows"`
+	Outflows CashFlowOutflows `json:"outflows"`
+	Net      float64          `json:"net"`
+	Balance  float64          `json:"balance"`  // projected balance at the end of the period
+	Negative bool             `json:"negative"` // the projected balance is below zero
+}
+
+type CashFlowForecast struct {
+	Interval       string           `json:"interval"` // week or month
+	OpeningBalance float64          `json:"opening_balance"`
+	Periods        []CashFlowPeriod `json:"periods"`
+	// NegativePeriods lists the start of every period whose projected balance is below zero.
+	NegativePeriods []string `json:"negative_periods"`
+	// UnclassifiedDebts is what is left of personal debts in the forecast window whose type is
+	// not configured as given or taken; it is not counted in any period.
+	UnclassifiedDebts float64 `json:"unclassified_debts"`
+}
+
+// LeaderboardMetrics describe the deals a user closed in a period.
+type LeaderboardMetrics struct {
+	ClosedDeals    int      `json:"closed_deals"`
+	Revenue        float64  `json:"revenue"`
+	Margin         float64  `json:"margin"`
+	AvgCycleDays   *float64 `json:"avg_cycle_days"`  // average days from date to completed_date
+	CollectionRate *float64 `json:"collection_rate"` // tranches received / sell, nil when nothing was sold
+	// TenderCommission is the commission of the user's tenders completed in the period.
+	TenderCommission float64 `json:"tender_commission"`
+}
+
+type LeaderboardEntry struct {
+	Rank     int    `json:"rank"`
+	UserID   int    `json:"user_id"`
+	UserName string `json:"user_name"`
+	LeaderboardMetrics
+	// Previous holds the metrics of the preceding period of the same length.
+	Previous     *LeaderboardMetrics `json:"previous"`
+	PreviousRank *int                `json:"previous_rank"`
+	Trend        string              `json:"trend"` // up, down, same or new
+}
+
+type Leaderboard struct {
+	StartDate     string             `json:"start_date"`
+	EndDate       string             `json:"end_date"`
+	PreviousStart string             `json:"previous_start"`
+	PreviousEnd   string             `json:"previous_end"`
+	CompanyID     *int               `json:"company_id,omitempty"`
+	SortBy        string             `json:"sort_by"`
+	Entries       []LeaderboardEntry `json:"entries"`
+}
+
+// AggregateMismatch is a month whose pre-aggregated figures differ from live data.
+type AggregateMismatch struct {
+	Source    string  `json:"source"`
+	Year      int     `json:"year"`
+	Month     int     `json:"month"`
+	CompanyID int     `json:"company_id"` // 0 when the rows have no company
+	UserID    int     `json:"user_id"`    // 0 when the rows have no user
+	Type      string  `json:"type"`
+	Status    int     `json:"status"`
+	Field     string  `json:"field"`
+	Live      float64 `json:"live"`
+	Aggregate float64 `json:"aggregate"`
+	// Pending is set when the month is queued for a refresh, so the difference is expected.
+	Pending bool `json:"pending"`
+}
+
+type AggregateCheck struct {
+	CheckedAt  string              `json:"checked_at"`
+	Consistent bool                `json:"consistent"`
+	Mismatches []AggregateMismatch `json:"mismatches"`
+}
@@ -0,0 +1,5 @@
+package models
+
+import "errors"
+
+var ErrSessionNotFound = errors.New("models: session not found or expired")
@@ -0,0 +1,15 @@
+package models
+
+// ChangeEvent announces that a row was created, updated or deleted. It is read from the audit log,
+// whose ID orders the events and identifies them to reconnecting clients.
+type ChangeEvent struct {
+	ID       int64  `json:"id"`
+	Entity   string `json:"entity"`
+	EntityID int    `json:"entity_id"`
+	Action   string `json:"action"`
+	ActorID  *int   `json:"actor_id"`
+	// UserID and CompanyID are the user and the company the row belongs to.
+	UserID    *int   `json:"user_id"`
+	CompanyID *int   `json:"company_id"`
+	At        string `json:"at"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "errors"
+
+var (
+	ErrSubscriptionNotFound = errors.New("models: subscription not found")
+	ErrInvalidSubscription  = errors.New("models: invalid subscription")
+)
+
+// ReportSubscription emails a report to its owner on a schedule. Only the admin may subscribe to
+// cashflow and leaderboard or send to another address; other users' reports cover their own
+// transactions only.
+type ReportSubscription struct {
+	ID     int    `json:"id"`
+	UserID int    `json:"user_id"`
+	Report string `json:"report"` // pnl, aging, cashflow or leaderboard
+	// Filters are applied every time the report is rendered.
+	Filters SubscriptionFilters `json:"filters"`
+	// Schedule is a five-field cron expression in server time, e.g. "0 8 * * 1" for Mondays at
+	// 08:00, or one of @hourly, @daily, @weekly and @monthly.
+	Schedule  string  `json:"schedule"`
+	Format    string  `json:"format"` // csv or xlsx
+	Email     string  `json:"email"`  // defaults to the owner's email
+	Active    bool    `json:"active"`
+	LastRunAt *string `json:"last_run_at"`
+	NextRunAt string  `json:"next_run_at"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// SubscriptionFilters scope a subscribed report. Period is relative to each run: previous_week
+// (the default), previous_month, month_to_date or year_to_date; it applies to pnl and leaderboard.
+type SubscriptionFilters struct {
+	Period    string `json:"period,omitempty"`
+	CompanyID *int   `json:"company_id,omitempty"` // pnl, leaderboard
+	UserID    *int   `json:"user_id,omitempty"`    // pnl, aging
+	Interval  string `json:"interval,omitempty"`   // cashflow: week or month
+	Periods   int    `json:"periods,omitempty"`    // cashflow
+	SortBy    string `json:"sort_by,omitempty"`    // leaderboard
+}
+
+// SubscriptionRun records one delivery of a subscription.
+type SubscriptionRun struct {
+	ID             int64   `json:"id"`
+	SubscriptionID int     `json:"subscription_id"`
+	StartedAt      string  `json:"started_at"`
+	FinishedAt     *string `json:"finished_at"`
+	Status         string  `json:"status"` // sent or failed
+	Recipient      string  `json:"recipient"`
+	Error          *string `json:"error"`
+}
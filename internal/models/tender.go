@@ -22,6 +22,8 @@ type Tender struct {
 	UserName      *string   `json:"username,omitempty"`
 	CompanyName   *string   `json:"companyname,omitempty"`
 	Debt          *float64  `json:"debt,omitempty"`
+	Version       int       `json:"version"`
+	UpdatedAt     *string   `json:"updated_at,omitempty"`
 }
 
 type TenderDebt struct {
@@ -6,4 +6,6 @@ type Tranche struct {
 	Amount        int     `json:"amount"`
 	Description   *string `json:"description,omitempty"`
 	Date          string  `json:"date"`
+	Version       int     `json:"version"`
+	UpdatedAt     *string `json:"updated_at,omitempty"`
 }
@@ -1,5 +1,8 @@
 package models
 
+// DealCompleted is the status of a completed transaction or tender.
+const DealCompleted = 2
+
 type Transaction struct {
 	ID                int       `json:"id"`
 	TransactionNumber *string   `json:"transaction_number,omitempty"`
@@ -20,6 +23,8 @@ type Transaction struct {
 	CompanyName       *string   `json:"companyname,omitempty"`
 	Debt              float64   `json:"debt,omitempty"`
 	Margin            *float64  `json:"margin,omitempty"`
+	Version           int       `json:"version"`
+	UpdatedAt         *string   `json:"updated_at,omitempty"`
 }
 
 type Expense struct {
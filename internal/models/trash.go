@@ -0,0 +1,24 @@
+package models
+
+import "errors"
+
+var (
+	ErrUnknownEntity = errors.New("models: unknown entity type")
+	ErrNotInTrash    = errors.New("models: record is not in the trash")
+)
+
+// TrashItem describes a soft-deleted record. Entity is the table the record belongs to.
+type TrashItem struct {
+	Entity    string   `json:"entity"`
+	ID        int      `json:"id"`
+	Summary   *string  `json:"summary"`
+	Amount    *float64 `json:"amount,omitempty"`
+	DeletedAt string   `json:"deleted_at"`
+	DeletedBy *int     `json:"deleted_by"`
+}
+
+// TrashPurgeResult reports how many records each entity type lost in a purge.
+type TrashPurgeResult struct {
+	DeletedBefore string           `json:"deleted_before"`
+	Purged        map[string]int64 `json:"purged"`
+}
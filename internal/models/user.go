@@ -1,5 +1,8 @@
 package models
 
+// AdminUserID is the account that holds the company balance and may run administrative actions.
+const AdminUserID = 1
+
 type User struct {
 	ID       int     `json:"id"`
 	Name     string  `json:"name"`
@@ -10,6 +13,10 @@ type User struct {
 	Balance  float64 `json:"balance"`
 	Password string  `json:"password"`
 	Status   int     `json:"status"`
+	Version  int     `json:"version"`
+	// Token authenticates the user's requests as "Authorization: Bearer <token>". It is only
+	// returned at login.
+	Token string `json:"token,omitempty"`
 }
 
 type UserTransactionDifference struct {
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("models: webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("models: webhook delivery not found")
+	ErrInvalidWebhook          = errors.New("models: invalid webhook")
+)
+
+// WebhookAllEvents subscribes a webhook to every event type.
+const WebhookAllEvents = "*"
+
+// WebhookPing is the event type of the test delivery sent by POST /webhooks/:id/ping.
+const WebhookPing = "ping"
+
+// Webhook is an endpoint of another system that receives events as signed JSON POSTs. It is
+// disabled after too many failed deliveries in a row.
+type Webhook struct {
+	ID          int      `json:"id"`
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description *string  `json:"description"`
+	Active      bool     `json:"active"`
+	// Secret signs the payloads. It is only returned when the webhook is created.
+	Secret         string  `json:"secret,omitempty"`
+	FailureCount   int     `json:"failure_count"`
+	DisabledAt     *string `json:"disabled_at"`
+	DisabledReason *string `json:"disabled_reason"`
+	CreatedBy      *int    `json:"created_by"`
+	CreatedAt      string  `json:"created_at"`
+	UpdatedAt      string  `json:"updated_at"`
+}
+
+// WebhookEvent is the JSON body posted to a webhook.
+type WebhookEvent struct {
+	// ID identifies the event; it is the same on every delivery attempt of it.
+	ID         string           `json:"id"`
+	Type       string           `json:"type"`
+	OccurredAt string           `json:"occurred_at"`
+	Data       WebhookEventData `json:"data"`
+}
+
+// WebhookEventData describes the change an event announces. Changes holds the
+// {"before": ..., "after": ...} values of every changed column.
+type WebhookEventData struct {
+	Entity    string          `json:"entity"`
+	EntityID  int             `json:"entity_id"`
+	Action    string          `json:"action"`
+	ActorID   *int            `json:"actor_id"`
+	UserID    *int            `json:"user_id"`
+	CompanyID *int            `json:"company_id"`
+	Changes   json.RawMessage `json:"changes"`
+}
+
+// WebhookDelivery is one event queued for, or done with, delivery to a webhook. It shares the
+// statuses of the notification outbox.
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int             `json:"webhook_id"`
+	AuditID        *int64          `json:"audit_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  string          `json:"next_attempt_at"`
+	ResponseStatus *int            `json:"response_status"`
+	LastError      *string         `json:"last_error"`
+	CreatedAt      string          `json:"created_at"`
+	DeliveredAt    *string         `json:"delivered_at"`
+	// AttemptLog lists every attempt, oldest first, when a single delivery is fetched.
+	AttemptLog []WebhookAttempt `json:"attempt_log,omitempty"`
+}
+
+// WebhookAttempt records one HTTP request made to deliver an event.
+type WebhookAttempt struct {
+	ID             int64   `json:"id"`
+	DeliveryID     int64   `json:"delivery_id"`
+	ResponseStatus *int    `json:"response_status"`
+	ResponseBody   *string `json:"response_body"`
+	Error          *string `json:"error"`
+	DurationMs     int     `json:"duration_ms"`
+	CreatedAt      string  `json:"created_at"`
+}
+
+// WebhookDeliveryFilter selects a page of a webhook's deliveries, newest first.
+type WebhookDeliveryFilter struct {
+	WebhookID int
+	Status    string
+	BeforeID  int64
+	Limit     int
+}
+
+// AuditChange is an audit log entry with its changes, from which webhook events are made.
+type AuditChange struct {
+	ChangeEvent
+	Changes json.RawMessage
+}
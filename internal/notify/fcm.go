@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"context"
+	"firebase.google.com/go"
+	"firebase.google.com/go/messaging"
+	"fmt"
+	"google.golang.org/api/option"
+)
+
+// FCM delivers notifications through Firebase Cloud Messaging.
+type FCM struct {
+	Client *messaging.Client
+}
+
+// NewFCM connects to Firebase with a service account key file.
+func NewFCM(ctx context.Context, projectID, credentialsFile string) (*FCM, error) {
+	app, err := firebase.NewApp(ctx, &firebase.Config{ProjectID: projectID}, option.WithCredentialsFile(credentialsFile))
+	if err != nil {
+		return nil, err
+	}
+	client, err := app.Messaging(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &FCM{Client: client}, nil
+}
+
+func (f *FCM) Send(ctx context.Context, msg Message) (string, error) {
+	id, err := f.Client.Send(ctx, &messaging.Message{
+		Token: msg.Token,
+		Notification: &messaging.Notification{
+			Title: msg.Title,
+			Body:  msg.Body,
+		},
+		Data: msg.Data,
+		Android: &messaging.AndroidConfig{
+			Priority: "high",
+			Notification: &messaging.AndroidNotification{
+				ChannelID: "high_priority_channel",
+			},
+		},
+		APNS: &messaging.APNSConfig{
+			Headers: map[string]string{
+				"apns-priority": "10",
+			},
+			Payload: &messaging.APNSPayload{
+				Aps: &messaging.Aps{
+					Alert: &messaging.ApsAlert{
+						Title: msg.Title,
+						Body:  msg.Body,
+					},
+					Sound: "default",
+				},
+			},
+		},
+	})
+	if err != nil && messaging.IsRegistrationTokenNotRegistered(err) {
+		return "", fmt.Errorf("%w: %v", ErrUnregistered, err)
+	}
+	return id, err
+}
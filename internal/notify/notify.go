@@ -0,0 +1,65 @@
+// Package notify delivers push notifications to devices.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// ErrUnregistered is returned for a device token the provider no longer accepts, e.g. because
+// the app was uninstalled. Such tokens should be forgotten.
+var ErrUnregistered = errors.New("notify: device token is not registered")
+
+// Message is a push notification for one device token.
+type Message struct {
+	Token string
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// Notifier delivers push notifications. Send returns an identifier of the delivered message.
+type Notifier interface {
+	Send(ctx context.Context, msg Message) (string, error)
+}
+
+// Log writes a line per notification instead of delivering it, for running without push
+// credentials.
+type Log struct {
+	Logger *log.Logger
+}
+
+func (l *Log) Send(ctx context.Context, msg Message) (string, error) {
+	l.Logger.Printf("push to %s: %q %q %v", msg.Token, msg.Title, msg.Body, msg.Data)
+	return "log", nil
+}
+
+// Recorder keeps the notifications it is given in memory, so that they can be inspected.
+type Recorder struct {
+	mu   sync.Mutex
+	sent []Message
+}
+
+func (r *Recorder) Send(ctx context.Context, msg Message) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, msg)
+	return fmt.Sprintf("recorded-%d", len(r.sent)), nil
+}
+
+// Sent returns the notifications recorded so far, oldest first.
+func (r *Recorder) Sent() []Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Message(nil), r.sent...)
+}
+
+// Reset forgets every recorded notification.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = nil
+}
@@ -0,0 +1,281 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"tender/internal/models"
+	"time"
+)
+
+// aggregateSource is a table summarised per month, company, user, type and status.
+type aggregateSource struct {
+	table  string
+	target string
+	// columns are the measure columns of target, filled from the matching exprs of table.
+	columns []string
+	exprs   []string
+}
+
+var aggregateSources = []aggregateSource{
+	{
+		table:   "transactions",
+		target:  "transaction_monthly",
+		columns: []string{"deal_count", "amount", "total", "sell", "margin"},
+		exprs:   []string{"COUNT(*)", "SUM(amount)", "SUM(total)", "SUM(sell)", "COALESCE(SUM(margin), 0)"},
+	},
+	{
+		table:   "tenders",
+		target:  "tender_monthly",
+		columns: []string{"deal_count", "total", "commission"},
+		exprs:   []string{"COUNT(*)", "SUM(total)", "SUM(commission)"},
+	},
+}
+
+// aggregatedEntities maps audit entity names to the aggregate source their rows feed.
+var aggregatedEntities = map[string]string{
+	"transaction": "transactions",
+	"tender":      "tenders",
+}
+
+func findAggregateSource(table string) (aggregateSource, bool) {
+	for _, source := range aggregateSources {
+		if source.table == table {
+			return source, true
+		}
+	}
+	return aggregateSource{}, false
+}
+
+// queueReportRefresh marks the months a changed row was and is dated in as stale, within the
+// transaction that changes it. recordAudit calls it for every audited write.
+func queueReportRefresh(ctx context.Context, tx *sql.Tx, entity string, before, after map[string]interface{}) error {
+	table, ok := aggregatedEntities[entity]
+	if !ok {
+		return nil
+	}
+	for _, snapshot := range []map[string]interface{}{before, after} {
+		value, _ := snapshot["date"].(string)
+		date, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			continue
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO report_refresh_queue (source, year, month) VALUES (?, ?, ?)
+			ON DUPLICATE KEY UPDATE queued_at = CURRENT_TIMESTAMP(6)`,
+			table, date.Year(), int(date.Month()))
+		if err != nil {
+			return fmt.Errorf("failed to queue report refresh: %w", err)
+		}
+	}
+	return nil
+}
+
+type AggregateRepository struct {
+	Db *sql.DB
+}
+
+// RefreshQueued recomputes every month queued by a write and returns how many were refreshed.
+func (r *AggregateRepository) RefreshQueued(ctx context.Context) (int, error) {
+	type queued struct {
+		source      string
+		year, month int
+		queuedAt    time.Time
+	}
+	var months []queued
+	rows, err := r.Db.QueryContext(ctx, "SELECT source, year, month, queued_at FROM report_refresh_queue ORDER BY queued_at")
+	if err != nil {
+		return 0, err
+	}
+	for rows.Next() {
+		var q queued
+		if err := rows.Scan(&q.source, &q.year, &q.month, &q.queuedAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		months = append(months, q)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for i, q := range months {
+		source, ok := findAggregateSource(q.source)
+		if !ok {
+			continue
+		}
+		err := r.inTx(ctx, func(tx *sql.Tx) error {
+			if err := rebuildAggregates(ctx, tx, source, q.year, q.month); err != nil {
+				return err
+			}
+			// A write during the refresh moves queued_at forward and keeps the month queued.
+			_, err := tx.ExecContext(ctx,
+				"DELETE FROM report_refresh_queue WHERE source = ? AND year = ? AND month = ? AND queued_at <= ?",
+				q.source, q.year, q.month, q.queuedAt)
+			return err
+		})
+		if err != nil {
+			return i, fmt.Errorf("refreshing %s %d-%02d: %w", q.source, q.year, q.month, err)
+		}
+	}
+	return len(months), nil
+}
+
+// Rebuild recomputes every aggregate from live data.
+func (r *AggregateRepository) Rebuild(ctx context.Context) error {
+	return r.inTx(ctx, func(tx *sql.Tx) error {
+		for _, source := range aggregateSources {
+			if err := rebuildAggregates(ctx, tx, source, 0, 0); err != nil {
+				return err
+			}
+		}
+		_, err := tx.ExecContext(ctx, "DELETE FROM report_refresh_queue")
+		return err
+	})
+}
+
+// rebuildAggregates replaces the aggregates of one month, or of all months when year is 0.
+func rebuildAggregates(ctx context.Context, tx *sql.Tx, source aggregateSource, year, month int) error {
+	deleteQuery := "DELETE FROM " + source.target
+	selectWhere := " WHERE deleted_at IS NULL AND date IS NOT NULL"
+	var deleteParams, selectParams []interface{}
+	if year != 0 {
+		start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+		deleteQuery += " WHERE year = ? AND month = ?"
+		deleteParams = []interface{}{year, month}
+		selectWhere += " AND date >= ? AND date < ?"
+		selectParams = []interface{}{start, start.AddDate(0, 1, 0)}
+	}
+
+	if _, err := tx.ExecContext(ctx, deleteQuery, deleteParams...); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(`
+		INSERT INTO %s (year, month, company_id, user_id, type, status, %s)
+		SELECT YEAR(date) AS y, MONTH(date) AS m, COALESCE(company_id, 0) AS c, COALESCE(user_id, 0) AS u, type, status, %s
+		FROM %s%s
+		GROUP BY y, m, c, u, type, status`,
+		source.target, strings.Join(source.columns, ", "), strings.Join(source.exprs, ", "), source.table, selectWhere),
+		selectParams...)
+	return err
+}
+
+// Check compares every aggregate with live data.
+func (r *AggregateRepository) Check(ctx context.Context) ([]models.AggregateMismatch, error) {
+	pending := map[string]bool{}
+	rows, err := r.Db.QueryContext(ctx, "SELECT source, year, month FROM report_refresh_queue")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var source string
+		var year, month int
+		if err := rows.Scan(&source, &year, &month); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		pending[fmt.Sprintf("%s/%d/%d", source, year, month)] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	mismatches := []models.AggregateMismatch{}
+	for _, source := range aggregateSources {
+		live, err := r.groupedFigures(ctx, fmt.Sprintf(`
+			SELECT YEAR(date) AS y, MONTH(date) AS m, COALESCE(company_id, 0) AS c, COALESCE(user_id, 0) AS u, type, status, %s
+			FROM %s
+			WHERE deleted_at IS NULL AND date IS NOT NULL
+			GROUP BY y, m, c, u, type, status`, strings.Join(source.exprs, ", "), source.table), len(source.columns))
+		if err != nil {
+			return nil, err
+		}
+		stored, err := r.groupedFigures(ctx, fmt.Sprintf(
+			"SELECT year, month, company_id, user_id, type, status, %s FROM %s",
+			strings.Join(source.columns, ", "), source.target), len(source.columns))
+		if err != nil {
+			return nil, err
+		}
+
+		for key := range mergedGroups(live, stored) {
+			liveValues, storedValues := live[key], stored[key]
+			for i, column := range source.columns {
+				var a, b float64
+				if liveValues != nil {
+					a = liveValues[i]
+				}
+				if storedValues != nil {
+					b = storedValues[i]
+				}
+				if math.Abs(a-b) < 0.005 {
+					continue
+				}
+				mismatches = append(mismatches, models.AggregateMismatch{
+					Source: source.table, Year: key.year, Month: key.month, CompanyID: key.companyID,
+					UserID: key.userID, Type: key.typ, Status: key.status,
+					Field: column, Live: a, Aggregate: b,
+					Pending: pending[fmt.Sprintf("%s/%d/%d", source.table, key.year, key.month)],
+				})
+			}
+		}
+	}
+	return mismatches, nil
+}
+
+type aggregateGroup struct {
+	year, month, companyID, userID int
+	typ                            string
+	status                         int
+}
+
+// groupedFigures reads rows of six group columns followed by n measures.
+func (r *AggregateRepository) groupedFigures(ctx context.Context, query string, n int) (map[aggregateGroup][]float64, error) {
+	rows, err := r.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	groups := map[aggregateGroup][]float64{}
+	for rows.Next() {
+		var g aggregateGroup
+		values := make([]float64, n)
+		dest := []interface{}{&g.year, &g.month, &g.companyID, &g.userID, &g.typ, &g.status}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		groups[g] = values
+	}
+	return groups, rows.Err()
+}
+
+func mergedGroups(a, b map[aggregateGroup][]float64) map[aggregateGroup]struct{} {
+	keys := make(map[aggregateGroup]struct{}, len(a)+len(b))
+	for k := range a {
+		keys[k] = struct{}{}
+	}
+	for k := range b {
+		keys[k] = struct{}{}
+	}
+	return keys
+}
+
+func (r *AggregateRepository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,320 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"tender/internal/models"
+	"tender/internal/requestctx"
+	"time"
+)
+
+// auditTables maps audit entity names to the tables they are stored in.
+var auditTables = map[string]string{
+	"transaction":       "transactions",
+	"tender":            "tenders",
+	"tranche":           "tranches",
+	"change":            "changes",
+	"extra_transaction": "extra_transactions",
+	"personal_expense":  "personal_expenses",
+	"personal_debt":     "personal_debts",
+	"debt_tranche":      "debt_tranches",
+	"balance_history":   "balance_history",
+	"balance_category":  "balance_category",
+	"category":          "categories",
+	"company":           "companies",
+	"permission":        "permissions",
+	"user":              "users",
+}
+
+// auditIgnored lists columns that change on every write and carry no meaning in a diff.
+var auditIgnored = map[string]bool{"updated_at": true}
+
+// auditRedacted lists columns whose values must never be written to the audit log.
+var auditRedacted = map[string]bool{"password": true}
+
+// auditedExec runs change in a database transaction and records the row it touched in audit_log
+// within the same transaction. id is the row being changed, or 0 when change inserts a new row;
+// change returns the ID of the row it affected.
+func auditedExec(ctx context.Context, db *sql.DB, entity, action string, id int, change func(tx *sql.Tx) (int, error)) (int, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var before map[string]interface{}
+	if id != 0 {
+		before, err = snapshotRow(ctx, tx, entity, id)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	id, err = change(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	after, err := snapshotRow(ctx, tx, entity, id)
+	if err != nil {
+		return 0, err
+	}
+	if err := recordAudit(ctx, tx, entity, id, action, before, after); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+// snapshotRow reads a row as a column map for diffing, locking it for the rest of the transaction.
+// It returns nil when the row does not exist.
+func snapshotRow(ctx context.Context, tx *sql.Tx, entity string, id int) (map[string]interface{}, error) {
+	table, ok := auditTables[entity]
+	if !ok {
+		return nil, models.ErrUnknownAuditEntity
+	}
+
+	rows, err := tx.QueryContext(ctx, "SELECT * FROM "+table+" WHERE id = ? FOR UPDATE", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	snapshot, err := scanRowMap(rows)
+	if err != nil {
+		return nil, err
+	}
+	for column := range auditIgnored {
+		delete(snapshot, column)
+	}
+	return snapshot, nil
+}
+
+// scanRowMap reads the current row as a column map with JSON-friendly values.
+func scanRowMap(rows *sql.Rows) (map[string]interface{}, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if err := rows.Scan(pointers...); err != nil {
+		return nil, err
+	}
+
+	row := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		switch v := values[i].(type) {
+		case []byte:
+			row[column] = string(v)
+		case time.Time:
+			row[column] = v.Format(time.RFC3339)
+		default:
+			row[column] = v
+		}
+	}
+	return row, nil
+}
+
+// recordAudit writes the difference between two snapshots of a row to audit_log and queues
+// the reporting aggregates it affects for a refresh. Nothing is written when the row did not
+// change.
+func recordAudit(ctx context.Context, tx *sql.Tx, entity string, id int, action string, before, after map[string]interface{}) error {
+	changes := map[string]map[string]interface{}{}
+	for column := range mergedKeys(before, after) {
+		oldValue, newValue := before[column], after[column]
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		if auditRedacted[column] {
+			oldValue, newValue = "[redacted]", "[redacted]"
+		}
+		changes[column] = map[string]interface{}{"before": oldValue, "after": newValue}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	diff, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	var actorID, requestID, ip interface{}
+	if userID, ok := requestctx.CallerFrom(ctx); ok {
+		actorID = userID
+	}
+	if info, ok := requestctx.RequestInfoFrom(ctx); ok {
+		requestID, ip = info.ID, info.IP
+	}
+
+	snapshot := after
+	if snapshot == nil {
+		snapshot = before
+	}
+	userID, companyID, err := auditScope(ctx, tx, entity, id, snapshot)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO audit_log (actor_id, entity, entity_id, action, changes, request_id, ip, user_id, company_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		actorID, entity, id, action, string(diff), requestID, ip, userID, companyID)
+	if err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return queueReportRefresh(ctx, tx, entity, before, after)
+}
+
+// auditScope returns the user and the company a row belongs to, which decide who may see its
+// changes. Tranches and changes belong to the owner and company of their transaction.
+func auditScope(ctx context.Context, tx *sql.Tx, entity string, id int, snapshot map[string]interface{}) (interface{}, interface{}, error) {
+	switch entity {
+	case "user":
+		return id, nil, nil
+	case "tranche", "change":
+		transactionID := snapshot["transaction_id"]
+		if transactionID == nil {
+			return nil, nil, nil
+		}
+		var userID, companyID sql.NullInt64
+		err := tx.QueryRowContext(ctx, "SELECT user_id, company_id FROM transactions WHERE id = ?", transactionID).
+			Scan(&userID, &companyID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, nil, err
+		}
+		return nullableID(userID), nullableID(companyID), nil
+	}
+	return snapshot["user_id"], snapshot["company_id"], nil
+}
+
+func nullableID(id sql.NullInt64) interface{} {
+	if !id.Valid {
+		return nil
+	}
+	return id.Int64
+}
+
+func mergedKeys(a, b map[string]interface{}) map[string]struct{} {
+	keys := make(map[string]struct{}, len(a)+len(b))
+	for k := range a {
+		keys[k] = struct{}{}
+	}
+	for k := range b {
+		keys[k] = struct{}{}
+	}
+	return keys
+}
+
+type AuditRepository struct {
+	Db *sql.DB
+}
+
+// GetAuditLog returns matching audit entries, newest first.
+func (r *AuditRepository) GetAuditLog(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	query := `
+		SELECT id, actor_id, entity, entity_id, action, changes, request_id, ip, created_at
+		FROM audit_log
+		WHERE 1 = 1`
+	params := []interface{}{}
+
+	if filter.Entity != "" {
+		if _, ok := auditTables[filter.Entity]; !ok {
+			return nil, models.ErrUnknownAuditEntity
+		}
+		query += " AND entity = ?"
+		params = append(params, filter.Entity)
+	}
+	if filter.EntityID != 0 {
+		query += " AND entity_id = ?"
+		params = append(params, filter.EntityID)
+	}
+	if filter.ActorID != 0 {
+		query += " AND actor_id = ?"
+		params = append(params, filter.ActorID)
+	}
+	if filter.BeforeID != 0 {
+		query += " AND id < ?"
+		params = append(params, filter.BeforeID)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	params = append(params, filter.Limit)
+
+	rows, err := r.Db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var changes []byte
+		if err := rows.Scan(&entry.ID, &entry.ActorID, &entry.Entity, &entry.EntityID, &entry.Action, &changes,
+			&entry.RequestID, &entry.IP, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entry.Changes = changes
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// LatestID returns the ID of the newest audit entry, or 0 when the log is empty.
+func (r *AuditRepository) LatestID(ctx context.Context) (int64, error) {
+	var id int64
+	err := r.Db.QueryRowContext(ctx, "SELECT COALESCE(MAX(id), 0) FROM audit_log").Scan(&id)
+	return id, err
+}
+
+// ChangesAfter returns up to limit audit entries newer than afterID with their changes, oldest
+// first.
+func (r *AuditRepository) ChangesAfter(ctx context.Context, afterID int64, limit int) ([]models.AuditChange, error) {
+	rows, err := r.Db.QueryContext(ctx, `
+		SELECT id, entity, entity_id, action, actor_id, user_id, company_id, created_at, changes
+		FROM audit_log
+		WHERE id > ?
+		ORDER BY id
+		LIMIT ?`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []models.AuditChange
+	for rows.Next() {
+		var c models.AuditChange
+		var diff []byte
+		if err := rows.Scan(&c.ID, &c.Entity, &c.EntityID, &c.Action, &c.ActorID, &c.UserID, &c.CompanyID, &c.At, &diff); err != nil {
+			return nil, err
+		}
+		c.Changes = diff
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// AuditEntities returns the names of the audited entities, sorted.
+func AuditEntities() []string {
+	entities := make([]string, 0, len(auditTables))
+	for entity := range auditTables {
+		entities = append(entities, entity)
+	}
+	sort.Strings(entities)
+	return entities
+}
@@ -16,15 +16,17 @@ func (r *BalanceCategoryRepository) CreateBalanceCategory(ctx context.Context, c
 		INSERT INTO balance_category (name) 
 		VALUES (?)
 	`
-	result, err := r.Db.ExecContext(ctx, query, category.Name)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create balance category: %w", err)
-	}
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, fmt.Errorf("failed to retrieve last insert ID: %w", err)
-	}
-	return int(id), nil
+	return auditedExec(ctx, r.Db, "balance_category", models.AuditCreate, 0, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, query, category.Name)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create balance category: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return 0, fmt.Errorf("failed to retrieve last insert ID: %w", err)
+		}
+		return int(id), nil
+	})
 }
 
 func (r *BalanceCategoryRepository) GetBalanceCategoryByID(ctx context.Context, id int) (*models.BalanceCategory, error) {
@@ -51,7 +53,10 @@ func (r *BalanceCategoryRepository) UpdateBalanceCategory(ctx context.Context, c
 		SET name = ?
 		WHERE id = ?
 	`
-	_, err := r.Db.ExecContext(ctx, query, category.Name, category.ID)
+	_, err := auditedExec(ctx, r.Db, "balance_category", models.AuditUpdate, category.ID, func(tx *sql.Tx) (int, error) {
+		_, err := tx.ExecContext(ctx, query, category.Name, category.ID)
+		return category.ID, err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to update balance category: %w", err)
 	}
@@ -65,7 +70,10 @@ func (r *BalanceCategoryRepository) DeleteBalanceCategory(ctx context.Context, i
 		DELETE FROM balance_category
 		WHERE id = ?
 	`
-	_, err := r.Db.ExecContext(ctx, query, id)
+	_, err := auditedExec(ctx, r.Db, "balance_category", models.AuditDelete, id, func(tx *sql.Tx) (int, error) {
+		_, err := tx.ExecContext(ctx, query, id)
+		return id, err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to delete balance category: %w", err)
 	}
@@ -13,13 +13,16 @@ type BalanceHistoryRepository struct {
 
 // CreateBalanceHistory inserts a new balance history record into the database.
 func (r *BalanceHistoryRepository) CreateBalanceHistory(ctx context.Context, history models.BalanceHistory) (models.BalanceHistory, error) {
-	result, err := r.Db.ExecContext(ctx, "INSERT INTO balance_history (amount, description, user_id, category_id) VALUES (?, ?, ?, ?)",
-		history.Amount, history.Description, history.UserID, history.CategoryID)
-	if err != nil {
-		return models.BalanceHistory{}, err
-	}
+	id, err := auditedExec(ctx, r.Db, "balance_history", models.AuditCreate, 0, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, "INSERT INTO balance_history (amount, description, user_id, category_id) VALUES (?, ?, ?, ?)",
+			history.Amount, history.Description, history.UserID, history.CategoryID)
+		if err != nil {
+			return 0, err
+		}
 
-	id, err := result.LastInsertId()
+		id, err := result.LastInsertId()
+		return int(id), err
+	})
 	if err != nil {
 		return models.BalanceHistory{}, err
 	}
@@ -43,27 +46,32 @@ func (r *BalanceHistoryRepository) CreateBalanceHistory(ctx context.Context, his
 }
 
 func (r *BalanceHistoryRepository) DeleteBalanceHistory(ctx context.Context, id int) error {
-	result, err := r.Db.ExecContext(ctx, "DELETE FROM balance_history WHERE id = ?", id)
-	if err != nil {
-		return err
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	if rowsAffected == 0 {
-		return models.ErrBalanceHistoryNotFound
-	}
+	_, err := auditedExec(ctx, r.Db, "balance_history", models.AuditDelete, id, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx,
+			"UPDATE balance_history SET deleted_at = NOW(), deleted_by = ? WHERE id = ? AND deleted_at IS NULL", deletedBy(ctx), id)
+		if err != nil {
+			return 0, err
+		}
 
-	return nil
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if rowsAffected == 0 {
+			return 0, models.ErrBalanceHistoryNotFound
+		}
+		return id, nil
+	})
+	return err
 }
 
 // UpdateBalanceHistory updates an existing balance history record in the database.
 func (r *BalanceHistoryRepository) UpdateBalanceHistory(ctx context.Context, history models.BalanceHistory) (models.BalanceHistory, error) {
-	_, err := r.Db.ExecContext(ctx, "UPDATE balance_history SET amount = ?, description = ?, user_id = ?, category_id = ? WHERE id = ?",
-		history.Amount, history.Description, history.UserID, history.ID, history.CategoryID)
+	_, err := auditedExec(ctx, r.Db, "balance_history", models.AuditUpdate, history.ID, func(tx *sql.Tx) (int, error) {
+		_, err := tx.ExecContext(ctx, "UPDATE balance_history SET amount = ?, description = ?, user_id = ?, category_id = ? WHERE id = ? AND deleted_at IS NULL",
+			history.Amount, history.Description, history.UserID, history.CategoryID, history.ID)
+		return history.ID, err
+	})
 	if err != nil {
 		return models.BalanceHistory{}, err
 	}
@@ -73,7 +81,7 @@ func (r *BalanceHistoryRepository) UpdateBalanceHistory(ctx context.Context, his
 
 // GetAllBalanceHistories retrieves all balance history records from the database.
 func (r *BalanceHistoryRepository) GetBalanceHistoryByUserID(ctx context.Context, id int) ([]models.BalanceHistory, error) {
-	rows, err := r.Db.QueryContext(ctx, "SELECT id, amount, description, user_id, created_at, updated_at FROM balance_history WHERE user_id = ?", id)
+	rows, err := r.Db.QueryContext(ctx, "SELECT id, amount, description, user_id, created_at, updated_at FROM balance_history WHERE user_id = ? AND deleted_at IS NULL", id)
 	if err != nil {
 		return nil, err
 	}
@@ -93,7 +101,7 @@ func (r *BalanceHistoryRepository) GetBalanceHistoryByUserID(ctx context.Context
 }
 
 func (r *BalanceHistoryRepository) GetBalanceHistoryByCategoryID(ctx context.Context, id int) ([]models.BalanceHistory, error) {
-	rows, err := r.Db.QueryContext(ctx, "SELECT id, amount, description, user_id, category_id, created_at, updated_at FROM balance_history WHERE category_id = ?", id)
+	rows, err := r.Db.QueryContext(ctx, "SELECT id, amount, description, user_id, category_id, created_at, updated_at FROM balance_history WHERE category_id = ? AND deleted_at IS NULL", id)
 	if err != nil {
 		return nil, err
 	}
@@ -13,13 +13,14 @@ type CategoryRepository struct {
 // CreateCategory inserts a new category into the database.
 func (r *CategoryRepository) CreateCategory(ctx context.Context, category models.Category) (models.Category, error) {
 	// Insert the new category into the database
-	result, err := r.Db.ExecContext(ctx, "INSERT INTO categories (category_name,  parent_id) VALUES (?, ?)", category.CategoryName, category.ParentID)
-	if err != nil {
-		return models.Category{}, err
-	}
-
-	// Get the ID of the inserted record
-	id, err := result.LastInsertId()
+	id, err := auditedExec(ctx, r.Db, "category", models.AuditCreate, 0, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, "INSERT INTO categories (category_name,  parent_id) VALUES (?, ?)", category.CategoryName, category.ParentID)
+		if err != nil {
+			return 0, err
+		}
+		id, err := result.LastInsertId()
+		return int(id), err
+	})
 	if err != nil {
 		return models.Category{}, err
 	}
@@ -38,26 +39,30 @@ func (r *CategoryRepository) CreateCategory(ctx context.Context, category models
 
 // DeleteCategory removes a category from the database by ID.
 func (r *CategoryRepository) DeleteCategory(ctx context.Context, id int) error {
-	result, err := r.Db.ExecContext(ctx, "DELETE FROM categories WHERE id = ?", id)
-	if err != nil {
-		return err
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	if rowsAffected == 0 {
-		return models.ErrCategoryNotFound
-	}
+	_, err := auditedExec(ctx, r.Db, "category", models.AuditDelete, id, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, "DELETE FROM categories WHERE id = ?", id)
+		if err != nil {
+			return 0, err
+		}
 
-	return nil
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if rowsAffected == 0 {
+			return 0, models.ErrCategoryNotFound
+		}
+		return id, nil
+	})
+	return err
 }
 
 // UpdateCategory updates an existing category in the database.
 func (r *CategoryRepository) UpdateCategory(ctx context.Context, category models.Category) (models.Category, error) {
-	_, err := r.Db.ExecContext(ctx, "UPDATE categories SET category_name = ? WHERE id = ?", category.CategoryName, category.ID)
+	_, err := auditedExec(ctx, r.Db, "category", models.AuditUpdate, category.ID, func(tx *sql.Tx) (int, error) {
+		_, err := tx.ExecContext(ctx, "UPDATE categories SET category_name = ? WHERE id = ?", category.CategoryName, category.ID)
+		return category.ID, err
+	})
 	if err != nil {
 		return models.Category{}, err
 	}
@@ -16,22 +16,24 @@ func (r *ChangeRepository) CreateChange(ctx context.Context, change *models.Chan
 		INSERT INTO changes (transaction_id, amount, description) 
 		VALUES (?, ?, ?)
 	`
-	result, err := r.Db.ExecContext(ctx, query, change.TransactionID, change.Amount, change.Description)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create change: %w", err)
-	}
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, fmt.Errorf("failed to retrieve last insert ID: %w", err)
-	}
-	return int(id), nil
+	return auditedExec(ctx, r.Db, "change", models.AuditCreate, 0, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, query, change.TransactionID, change.Amount, change.Description)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create change: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return 0, fmt.Errorf("failed to retrieve last insert ID: %w", err)
+		}
+		return int(id), nil
+	})
 }
 
 func (r *ChangeRepository) GetChangeByID(ctx context.Context, id int) (*models.Change, error) {
 	query := `
 		SELECT id, transaction_id, amount, description, date
 		FROM changes
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 	var change models.Change
 	err := r.Db.QueryRowContext(ctx, query, id).Scan(
@@ -49,9 +51,12 @@ func (r *ChangeRepository) UpdateChange(ctx context.Context, change *models.Chan
 	updateQuery := `
 		UPDATE changes
 		SET transaction_id = ?, amount = ?, description = ?
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
-	_, err := r.Db.ExecContext(ctx, updateQuery, change.TransactionID, change.Amount, change.Description, change.ID)
+	_, err := auditedExec(ctx, r.Db, "change", models.AuditUpdate, change.ID, func(tx *sql.Tx) (int, error) {
+		_, err := tx.ExecContext(ctx, updateQuery, change.TransactionID, change.Amount, change.Description, change.ID)
+		return change.ID, err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to update change: %w", err)
 	}
@@ -59,7 +64,7 @@ func (r *ChangeRepository) UpdateChange(ctx context.Context, change *models.Chan
 	selectQuery := `
 		SELECT id, transaction_id, amount, description, date
 		FROM changes
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 	var updatedChange models.Change
 	err = r.Db.QueryRowContext(ctx, selectQuery, change.ID).Scan(
@@ -73,10 +78,13 @@ func (r *ChangeRepository) UpdateChange(ctx context.Context, change *models.Chan
 
 func (r *ChangeRepository) DeleteChange(ctx context.Context, id int) error {
 	query := `
-		DELETE FROM changes
-		WHERE id = ?
+		UPDATE changes SET deleted_at = NOW(), deleted_by = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
-	_, err := r.Db.ExecContext(ctx, query, id)
+	_, err := auditedExec(ctx, r.Db, "change", models.AuditDelete, id, func(tx *sql.Tx) (int, error) {
+		_, err := tx.ExecContext(ctx, query, deletedBy(ctx), id)
+		return id, err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to delete change: %w", err)
 	}
@@ -87,7 +95,7 @@ func (r *ChangeRepository) GetAllChangesByTransactionID(ctx context.Context, tra
 	query := `
 		SELECT id, transaction_id, amount, description, date
 		FROM changes
-		WHERE transaction_id = ?
+		WHERE transaction_id = ? AND deleted_at IS NULL
 		ORDER BY date DESC
 	`
 
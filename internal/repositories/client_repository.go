@@ -19,7 +19,7 @@ func (r *ClientRepository) GetClientData(ctx context.Context, userID int) (model
 	transactionsQuery := `
     SELECT transaction_number, sell
     FROM transactions
-    WHERE user_id = ? AND status = 2
+    WHERE user_id = ? AND status = 2 AND deleted_at IS NULL
     `
 	transRows, err := r.Db.QueryContext(ctx, transactionsQuery, userID)
 	if err != nil {
@@ -48,7 +48,7 @@ func (r *ClientRepository) GetClientData(ctx context.Context, userID int) (model
 	tendersGOIKQuery := `
     SELECT tender_number, total
     FROM tenders
-    WHERE user_id = ? AND status = 2 AND type = 'ГОИК'
+    WHERE user_id = ? AND status = 2 AND type = 'ГОИК' AND deleted_at IS NULL
     `
 	goikRows, err := r.Db.QueryContext(ctx, tendersGOIKQuery, userID)
 	if err != nil {
@@ -72,7 +72,7 @@ func (r *ClientRepository) GetClientData(ctx context.Context, userID int) (model
 	tendersGOPPQuery := `
     SELECT tender_number, total
     FROM tenders
-    WHERE user_id = ? AND status = 2 AND type = 'ГОПП'
+    WHERE user_id = ? AND status = 2 AND type = 'ГОПП' AND deleted_at IS NULL
     `
 	goppRows, err := r.Db.QueryContext(ctx, tendersGOPPQuery, userID)
 	if err != nil {
@@ -97,7 +97,7 @@ func (r *ClientRepository) GetClientData(ctx context.Context, userID int) (model
     SELECT ae.date, ae.amount
     FROM additional_expenses ae
     JOIN transactions t ON ae.transaction_id = t.id
-    WHERE t.user_id = ? AND t.status = 2
+    WHERE t.user_id = ? AND t.status = 2 AND t.deleted_at IS NULL
     `
 	expenseRows, err := r.Db.QueryContext(ctx, expensesQuery, userID)
 	if err != nil {
@@ -12,12 +12,14 @@ type CompanyRepository struct {
 
 // CreateCompany inserts a new company into the database.
 func (r *CompanyRepository) CreateCompany(ctx context.Context, company models.Company) (int, error) {
-	result, err := r.Db.ExecContext(ctx, "INSERT INTO companies (name, description) VALUES (?, ?)", company.Name, company.Description)
-	if err != nil {
-		return 0, err
-	}
-
-	id, err := result.LastInsertId()
+	id, err := auditedExec(ctx, r.Db, "company", models.AuditCreate, 0, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, "INSERT INTO companies (name, description) VALUES (?, ?)", company.Name, company.Description)
+		if err != nil {
+			return 0, err
+		}
+		id, err := result.LastInsertId()
+		return int(id), err
+	})
 	if err != nil {
 		return 0, err
 	}
@@ -27,21 +29,22 @@ func (r *CompanyRepository) CreateCompany(ctx context.Context, company models.Co
 
 // DeleteCompany removes a company from the database by ID.
 func (r *CompanyRepository) DeleteCompany(ctx context.Context, id int) error {
-	result, err := r.Db.ExecContext(ctx, "DELETE FROM companies WHERE id = ?", id)
-	if err != nil {
-		return err
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	if rowsAffected == 0 {
-		return models.ErrCompanyNotFound
-	}
+	_, err := auditedExec(ctx, r.Db, "company", models.AuditDelete, id, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, "DELETE FROM companies WHERE id = ?", id)
+		if err != nil {
+			return 0, err
+		}
 
-	return nil
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if rowsAffected == 0 {
+			return 0, models.ErrCompanyNotFound
+		}
+		return id, nil
+	})
+	return err
 }
 
 // UpdateCompany updates an existing company in the database.
@@ -63,7 +66,10 @@ func (r *CompanyRepository) UpdateCompany(ctx context.Context, company models.Co
 	query += " WHERE id = ?"
 	params = append(params, company.ID)
 
-	_, err := r.Db.ExecContext(ctx, query, params...)
+	_, err := auditedExec(ctx, r.Db, "company", models.AuditUpdate, company.ID, func(tx *sql.Tx) (int, error) {
+		_, err := tx.ExecContext(ctx, query, params...)
+		return company.ID, err
+	})
 	if err != nil {
 		return models.Company{}, err
 	}
@@ -17,15 +17,17 @@ func (r *DebtTrancheRepository) CreateDebtTranche(ctx context.Context, tranche *
 		INSERT INTO debt_tranches (debt_id, amount, description, date) 
 		VALUES (?, ?, ?, ?)
 	`
-	result, err := r.Db.ExecContext(ctx, query, tranche.DebtID, tranche.Amount, tranche.Description, tranche.Date)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create tranche: %w", err)
-	}
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, fmt.Errorf("failed to retrieve last insert ID: %w", err)
-	}
-	return int(id), nil
+	return auditedExec(ctx, r.Db, "debt_tranche", models.AuditCreate, 0, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, query, tranche.DebtID, tranche.Amount, tranche.Description, tranche.Date)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create tranche: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return 0, fmt.Errorf("failed to retrieve last insert ID: %w", err)
+		}
+		return int(id), nil
+	})
 }
 
 // Get a tranche by ID
@@ -33,7 +35,7 @@ func (r *DebtTrancheRepository) GetDebtTrancheByID(ctx context.Context, id int)
 	query := `
 		SELECT id, debt_id, amount, description, date
 		FROM debt_tranches
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 	var tranche models.DebtTranche
 	err := r.Db.QueryRowContext(ctx, query, id).Scan(
@@ -53,9 +55,12 @@ func (r *DebtTrancheRepository) UpdateDebtTranche(ctx context.Context, tranche *
 	updateQuery := `
 		UPDATE debt_tranches
 		SET debt_id = ?, amount = ?, description = ?, date = ?
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
-	_, err := r.Db.ExecContext(ctx, updateQuery, tranche.DebtID, tranche.Amount, tranche.Description, tranche.Date, tranche.ID)
+	_, err := auditedExec(ctx, r.Db, "debt_tranche", models.AuditUpdate, tranche.ID, func(tx *sql.Tx) (int, error) {
+		_, err := tx.ExecContext(ctx, updateQuery, tranche.DebtID, tranche.Amount, tranche.Description, tranche.Date, tranche.ID)
+		return tranche.ID, err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to update tranche: %w", err)
 	}
@@ -64,7 +69,7 @@ func (r *DebtTrancheRepository) UpdateDebtTranche(ctx context.Context, tranche *
 	selectQuery := `
 		SELECT id, debt_id, amount, description, date
 		FROM debt_tranches
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 
 	var updatedTranche models.DebtTranche
@@ -77,13 +82,16 @@ func (r *DebtTrancheRepository) UpdateDebtTranche(ctx context.Context, tranche *
 	return &updatedTranche, nil
 }
 
-// Delete a tranche by ID
+// Move a tranche to the trash by ID
 func (r *DebtTrancheRepository) DeleteDebtTranche(ctx context.Context, id int) error {
 	query := `
-		DELETE FROM debt_tranches
-		WHERE id = ?
+		UPDATE debt_tranches SET deleted_at = NOW(), deleted_by = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
-	_, err := r.Db.ExecContext(ctx, query, id)
+	_, err := auditedExec(ctx, r.Db, "debt_tranche", models.AuditDelete, id, func(tx *sql.Tx) (int, error) {
+		_, err := tx.ExecContext(ctx, query, deletedBy(ctx), id)
+		return id, err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to delete tranche: %w", err)
 	}
@@ -94,7 +102,7 @@ func (r *DebtTrancheRepository) GetAllDebtTranchesByDebtID(ctx context.Context,
 	query := `
 		SELECT id, debt_id, amount, description, date
 		FROM debt_tranches
-		WHERE debt_id = ?
+		WHERE debt_id = ? AND deleted_at IS NULL
 		ORDER BY date DESC
 	`
 
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"tender/internal/models"
+	"time"
+)
+
+type DocumentRepository struct {
+	Db *sql.DB
+}
+
+// GetReconciliation collects a company's completed sales and the tranches paid for its
+// transactions between start and end, both inclusive, with the balance before start.
+func (r *DocumentRepository) GetReconciliation(ctx context.Context, companyID int, start, end time.Time) (models.ReconciliationAct, error) {
+	act := models.ReconciliationAct{CompanyID: companyID, Entries: []models.ReconciliationEntry{}}
+	err := r.Db.QueryRowContext(ctx, "SELECT name FROM companies WHERE id = ?", companyID).Scan(&act.CompanyName)
+	if err == sql.ErrNoRows {
+		return act, models.ErrCompanyNotFound
+	}
+	if err != nil {
+		return act, err
+	}
+
+	err = r.Db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COALESCE(SUM(sell), 0) FROM transactions
+			 WHERE company_id = ? AND status = 2 AND deleted_at IS NULL AND COALESCE(completed_date, date) < ?)
+			-
+			(SELECT COALESCE(SUM(tr.amount), 0) FROM tranches tr
+			 JOIN transactions t ON t.id = tr.transaction_id
+			 WHERE t.company_id = ? AND tr.deleted_at IS NULL AND t.deleted_at IS NULL AND tr.date < ?)`,
+		companyID, start, companyID, start).Scan(&act.OpeningBalance)
+	if err != nil {
+		return act, err
+	}
+
+	// Sales sort before payments made on the same day.
+	after := end.AddDate(0, 0, 1)
+	rows, err := r.Db.QueryContext(ctx, `
+		SELECT COALESCE(t.completed_date, t.date) AS entry_date, 'sale' AS kind, t.id, t.tender_number, t.product_name, t.sell
+		FROM transactions t
+		WHERE t.company_id = ? AND t.status = 2 AND t.deleted_at IS NULL
+			AND COALESCE(t.completed_date, t.date) >= ? AND COALESCE(t.completed_date, t.date) < ?
+		UNION ALL
+		SELECT tr.date, 'payment', t.id, t.tender_number, tr.description, tr.amount
+		FROM tranches tr
+		JOIN transactions t ON t.id = tr.transaction_id
+		WHERE t.company_id = ? AND tr.deleted_at IS NULL AND t.deleted_at IS NULL
+			AND tr.date >= ? AND tr.date < ?
+		ORDER BY entry_date, kind DESC, id`,
+		companyID, start, after, companyID, start, after)
+	if err != nil {
+		return act, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.ReconciliationEntry
+		var date time.Time
+		var amount float64
+		err := rows.Scan(&date, &entry.Kind, &entry.TransactionID, &entry.TenderNumber, &entry.Description, &amount)
+		if err != nil {
+			return act, err
+		}
+		entry.Date = date.Format("2006-01-02")
+		if entry.Kind == "sale" {
+			entry.Debit = amount
+		} else {
+			entry.Credit = amount
+		}
+		act.Entries = append(act.Entries, entry)
+	}
+	return act, rows.Err()
+}
@@ -0,0 +1,181 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"tender/internal/export"
+	"tender/internal/models"
+)
+
+// exportDataset is a list that can be downloaded as a file. query selects the columns in order
+// and ends in a WHERE clause; date, user and company are the expressions ExportFilter applies
+// to, empty when the list has no such column.
+type exportDataset struct {
+	query   string
+	columns []export.Column
+	date    string
+	user    string
+	company string
+	order   string
+}
+
+var exportDatasets = map[string]exportDataset{
+	"transactions": {
+		query: `SELECT t.id, t.transaction_number, t.type, t.tender_number, c.name, CONCAT_WS(' ', u.name, u.last_name),
+				t.organization, t.product_name, t.amount, t.total, t.sell, t.margin, t.status, t.date, t.completed_date
+			FROM transactions t
+			LEFT JOIN companies c ON c.id = t.company_id
+			LEFT JOIN users u ON u.id = t.user_id
+			WHERE t.deleted_at IS NULL`,
+		columns: []export.Column{
+			{Key: "id", Kind: export.Integer}, {Key: "transaction_number"}, {Key: "type"}, {Key: "tender_number"},
+			{Key: "company"}, {Key: "user"}, {Key: "organization"}, {Key: "product_name"},
+			{Key: "amount", Kind: export.Number, Total: true}, {Key: "total", Kind: export.Number, Total: true},
+			{Key: "sell", Kind: export.Number, Total: true}, {Key: "margin", Kind: export.Number, Total: true},
+			{Key: "status", Kind: export.Integer}, {Key: "date", Kind: export.Date}, {Key: "completed_date", Kind: export.Date},
+		},
+		date: "t.date", user: "t.user_id", company: "t.company_id", order: "t.date DESC, t.id DESC",
+	},
+	"tenders": {
+		query: `SELECT t.id, t.type, t.tender_number, c.name, CONCAT_WS(' ', u.name, u.last_name), t.organization,
+				t.total, t.commission, t.status, t.date, t.completed_date
+			FROM tenders t
+			LEFT JOIN companies c ON c.id = t.company_id
+			LEFT JOIN users u ON u.id = t.user_id
+			WHERE t.deleted_at IS NULL`,
+		columns: []export.Column{
+			{Key: "id", Kind: export.Integer}, {Key: "type"}, {Key: "tender_number"}, {Key: "company"}, {Key: "user"},
+			{Key: "organization"}, {Key: "total", Kind: export.Number, Total: true},
+			{Key: "commission", Kind: export.Number, Total: true}, {Key: "status", Kind: export.Integer},
+			{Key: "date", Kind: export.Date}, {Key: "completed_date", Kind: export.Date},
+		},
+		date: "t.date", user: "t.user_id", company: "t.company_id", order: "t.date DESC, t.id DESC",
+	},
+	"extra_transactions": {
+		query: `SELECT e.id, CONCAT_WS(' ', u.name, u.last_name), e.description, e.total, e.status, e.date
+			FROM extra_transactions e
+			LEFT JOIN users u ON u.id = e.user_id
+			WHERE e.deleted_at IS NULL`,
+		columns: []export.Column{
+			{Key: "id", Kind: export.Integer}, {Key: "user"}, {Key: "description"},
+			{Key: "total", Kind: export.Number, Total: true}, {Key: "status", Kind: export.Integer},
+			{Key: "date", Kind: export.Date},
+		},
+		date: "e.date", user: "e.user_id", order: "e.date DESC, e.id DESC",
+	},
+	"expenses": {
+		query: `SELECT p.id, c.category_name, p.reason, p.description, p.amount, p.date
+			FROM personal_expenses p
+			LEFT JOIN categories c ON c.id = p.category_id
+			WHERE p.deleted_at IS NULL`,
+		columns: []export.Column{
+			{Key: "id", Kind: export.Integer}, {Key: "category"}, {Key: "reason"}, {Key: "description"},
+			{Key: "amount", Kind: export.Number, Total: true}, {Key: "date", Kind: export.Date},
+		},
+		date: "p.date", order: "p.date DESC, p.id DESC",
+	},
+	"personal_debts": {
+		query: `SELECT id, name, type, amount, get_date, return_date, status, created_at
+			FROM personal_debts
+			WHERE deleted_at IS NULL`,
+		columns: []export.Column{
+			{Key: "id", Kind: export.Integer}, {Key: "name"}, {Key: "type"},
+			{Key: "amount", Kind: export.Number, Total: true}, {Key: "get_date", Kind: export.Date},
+			{Key: "return_date", Kind: export.Date}, {Key: "status", Kind: export.Integer},
+			{Key: "created_at", Kind: export.Timestamp},
+		},
+		date: "get_date", order: "created_at DESC, id DESC",
+	},
+}
+
+type ExportRepository struct {
+	Db *sql.DB
+}
+
+// ExportColumns returns the columns of a list export.
+func (r *ExportRepository) ExportColumns(name string) ([]export.Column, error) {
+	dataset, ok := exportDatasets[name]
+	if !ok {
+		return nil, models.ErrUnknownEntity
+	}
+	return dataset.columns, nil
+}
+
+// StreamExport calls fn with every row of a list export in turn.
+func (r *ExportRepository) StreamExport(ctx context.Context, name string, filter models.ExportFilter, fn func(values []interface{}) error) error {
+	dataset, ok := exportDatasets[name]
+	if !ok {
+		return models.ErrUnknownEntity
+	}
+
+	query := dataset.query
+	var params []interface{}
+	if dataset.date != "" && filter.StartDate != "" {
+		query += " AND " + dataset.date + " >= ?"
+		params = append(params, filter.StartDate)
+	}
+	if dataset.date != "" && filter.EndDate != "" {
+		// The end date is inclusive and the columns hold times of day.
+		query += " AND " + dataset.date + " < DATE_ADD(?, INTERVAL 1 DAY)"
+		params = append(params, filter.EndDate)
+	}
+	if dataset.user != "" && filter.UserID != nil {
+		query += " AND " + dataset.user + " = ?"
+		params = append(params, *filter.UserID)
+	}
+	if dataset.company != "" && filter.CompanyID != nil {
+		query += " AND " + dataset.company + " = ?"
+		params = append(params, *filter.CompanyID)
+	}
+	query += " ORDER BY " + dataset.order
+
+	rows, err := r.Db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	values := make([]interface{}, len(dataset.columns))
+	dest := make([]interface{}, len(dataset.columns))
+	for rows.Next() {
+		for i, column := range dataset.columns {
+			dest[i] = scanTarget(column.Kind)
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return err
+		}
+		for i := range dest {
+			values[i] = scannedValue(dest[i])
+		}
+		if err := fn(values); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func scanTarget(kind export.Kind) interface{} {
+	switch kind {
+	case export.Integer:
+		return new(sql.NullInt64)
+	case export.Number:
+		return new(sql.NullFloat64)
+	case export.Date, export.Timestamp:
+		return new(sql.NullTime)
+	}
+	return new(sql.NullString)
+}
+
+func scannedValue(dest interface{}) interface{} {
+	switch v := dest.(type) {
+	case *sql.NullInt64:
+		return *v
+	case *sql.NullFloat64:
+		return *v
+	case *sql.NullTime:
+		return *v
+	case *sql.NullString:
+		return *v
+	}
+	return nil
+}
@@ -13,15 +13,17 @@ type ExtraTransactionRepository struct {
 }
 
 func (r *ExtraTransactionRepository) CreateExtraTransaction(ctx context.Context, extraTransaction models.ExtraTransaction) (models.ExtraTransaction, error) {
-	result, err := r.Db.ExecContext(ctx, `
-		INSERT INTO extra_transactions (user_id, description, total, status)
-		VALUES (?, ?, ?, ?)`,
-		extraTransaction.UserID, extraTransaction.Description, extraTransaction.Total, extraTransaction.Status)
-	if err != nil {
-		return models.ExtraTransaction{}, err
-	}
-
-	id, err := result.LastInsertId()
+	id, err := auditedExec(ctx, r.Db, "extra_transaction", models.AuditCreate, 0, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO extra_transactions (user_id, description, total, status)
+			VALUES (?, ?, ?, ?)`,
+			extraTransaction.UserID, extraTransaction.Description, extraTransaction.Total, extraTransaction.Status)
+		if err != nil {
+			return 0, err
+		}
+		id, err := result.LastInsertId()
+		return int(id), err
+	})
 	if err != nil {
 		return models.ExtraTransaction{}, err
 	}
@@ -36,7 +38,7 @@ func (r *ExtraTransactionRepository) GetExtraTransactionByID(ctx context.Context
 		SELECT et.id, user_id, description, total, date, et.status,CONCAT(u.name, ' ', u.last_name) as username
 		FROM extra_transactions et
 		JOIN tender.users u on u.id = et.user_id
-		WHERE et.id = ?`, id).
+		WHERE et.id = ? AND et.deleted_at IS NULL`, id).
 		Scan(&extraTransaction.ID, &extraTransaction.UserID, &extraTransaction.Description, &extraTransaction.Total, &extraTransaction.Date, &extraTransaction.Status, &extraTransaction.UserName)
 	if err != nil {
 		return models.ExtraTransaction{}, err
@@ -50,6 +52,7 @@ func (r *ExtraTransactionRepository) GetAllExtraTransactions(ctx context.Context
 		SELECT et.id, user_id, description, total, date, et.status, u.name 
 		FROM extra_transactions et
 		JOIN tender.users u on u.id = et.user_id
+		WHERE et.deleted_at IS NULL
 		ORDER BY date DESC`)
 	if err != nil {
 		log.Printf("Error querying extra transactions: %v", err)
@@ -81,7 +84,7 @@ func (r *ExtraTransactionRepository) GetExtraTransactionsByUser(ctx context.Cont
 		SELECT extra_transactions.id, user_id, description, total, date, extra_transactions.status , CONCAT(u.name, ' ', u.last_name) as username
 		FROM extra_transactions
 		JOIN tender.users u on extra_transactions.user_id = u.id
-		WHERE user_id = ? ORDER BY date DESC`, userID)
+		WHERE user_id = ? AND extra_transactions.deleted_at IS NULL ORDER BY date DESC`, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -122,10 +125,13 @@ func (r *ExtraTransactionRepository) UpdateExtraTransaction(ctx context.Context,
 	}
 
 	// Remove the trailing comma and add the WHERE clause
-	query = query[:len(query)-1] + " WHERE id = ?"
+	query = query[:len(query)-1] + " WHERE id = ? AND deleted_at IS NULL"
 	params = append(params, extraTransaction.ID)
 
-	_, err := r.Db.ExecContext(ctx, query, params...)
+	_, err := auditedExec(ctx, r.Db, "extra_transaction", models.AuditUpdate, extraTransaction.ID, func(tx *sql.Tx) (int, error) {
+		_, err := tx.ExecContext(ctx, query, params...)
+		return extraTransaction.ID, err
+	})
 	if err != nil {
 		return models.ExtraTransaction{}, err
 	}
@@ -135,7 +141,7 @@ func (r *ExtraTransactionRepository) UpdateExtraTransaction(ctx context.Context,
 	err = r.Db.QueryRowContext(ctx, `
 		SELECT id, user_id, description, total, status, date
 		FROM extra_transactions
-		WHERE id = ?`, extraTransaction.ID).
+		WHERE id = ? AND deleted_at IS NULL`, extraTransaction.ID).
 		Scan(&updatedTransaction.ID, &updatedTransaction.UserID, &updatedTransaction.Description,
 			&updatedTransaction.Total, &updatedTransaction.Status, &updatedTransaction.Date)
 
@@ -147,7 +153,11 @@ func (r *ExtraTransactionRepository) UpdateExtraTransaction(ctx context.Context,
 }
 
 func (r *ExtraTransactionRepository) DeleteExtraTransaction(ctx context.Context, id int) error {
-	_, err := r.Db.ExecContext(ctx, `DELETE FROM extra_transactions WHERE id = ?`, id)
+	_, err := auditedExec(ctx, r.Db, "extra_transaction", models.AuditDelete, id, func(tx *sql.Tx) (int, error) {
+		_, err := tx.ExecContext(ctx,
+			`UPDATE extra_transactions SET deleted_at = NOW(), deleted_by = ? WHERE id = ? AND deleted_at IS NULL`, deletedBy(ctx), id)
+		return id, err
+	})
 	return err
 }
 
@@ -156,14 +166,14 @@ func (r *ExtraTransactionRepository) GetExtraTransactionCountsByUserID(ctx conte
 	queryTotal := `
         SELECT COUNT(*) 
         FROM extra_transactions 
-        WHERE user_id = ?;
+        WHERE user_id = ? AND deleted_at IS NULL;
     `
 
 	// Query to count extra transactions by user ID and status
 	queryStatus := `
         SELECT COUNT(*) 
         FROM extra_transactions 
-        WHERE user_id = ? AND status = ?;
+        WHERE user_id = ? AND status = ? AND deleted_at IS NULL;
     `
 
 	counts := &models.ExtraTransactionCount{}
@@ -196,7 +206,7 @@ func (r *ExtraTransactionRepository) GetAllExtraTransactionsByDateRange(ctx cont
 			SELECT e.id, e.user_id, e.description, e.total, e.date, e.status, u.name as username
 			FROM extra_transactions e 
 			JOIN users u ON u.id = e.user_id
-			WHERE e.date BETWEEN ? AND ?
+			WHERE e.date BETWEEN ? AND ? AND e.deleted_at IS NULL
 			ORDER BY e.date DESC
 		`
 		rows, err = r.Db.QueryContext(ctx, query, startDate, endDate)
@@ -205,7 +215,7 @@ func (r *ExtraTransactionRepository) GetAllExtraTransactionsByDateRange(ctx cont
 			SELECT e.id, e.user_id, e.description, e.total, e.date, e.status, u.name as username
 			FROM extra_transactions e 
 			JOIN users u ON u.id = e.user_id
-			WHERE e.user_id = ? AND e.date BETWEEN ? AND ?
+			WHERE e.user_id = ? AND e.date BETWEEN ? AND ? AND e.deleted_at IS NULL
 			ORDER BY e.date DESC
 		`
 		rows, err = r.Db.QueryContext(ctx, query, userId, startDate, endDate)
@@ -242,7 +252,7 @@ func (r *ExtraTransactionRepository) GetAllExtraTransactionsByDateRangeCompany(c
 			SELECT e.id, e.user_id, e.description, e.total, e.date, e.status, u.name as username
 			FROM extra_transactions e 
 			JOIN users u ON u.id = e.user_id
-			WHERE e.user_id = ? AND e.date BETWEEN ? AND ?
+			WHERE e.user_id = ? AND e.date BETWEEN ? AND ? AND e.deleted_at IS NULL
 			ORDER BY e.date DESC
 		`
 		rows, err = r.Db.QueryContext(ctx, query, startDate, endDate, companyId)
@@ -251,7 +261,7 @@ func (r *ExtraTransactionRepository) GetAllExtraTransactionsByDateRangeCompany(c
 			SELECT e.id, e.user_id, e.description, e.total, e.date, e.status, u.name as username
 			FROM extra_transactions e 
 			JOIN users u ON u.id = e.user_id
-			WHERE e.user_id = ? AND e.user_id = ? AND e.date BETWEEN ? AND ?
+			WHERE e.user_id = ? AND e.user_id = ? AND e.date BETWEEN ? AND ? AND e.deleted_at IS NULL
 			ORDER BY e.date DESC
 		`
 		rows, err = r.Db.QueryContext(ctx, query, userId, companyId, startDate, endDate)
@@ -3,72 +3,329 @@ package repositories
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"tender/internal/models"
+	"time"
 )
 
+// historySource is one table feeding the activity history. Every query selects the same columns:
+// id, source, user_id, company_id, amount, total, description, status, date.
+type historySource struct {
+	name  string
+	table string
+	query string
+}
+
+// historySources lists the feed's sources in their default order.
+var historySources = []historySource{
+	{name: "Transaction", table: "transactions", query: `
+		SELECT id, 'Transaction' AS source, user_id, company_id, amount, total,
+			CONCAT_WS(' ', type, tender_number, product_name) AS description, status, date
+		FROM transactions
+		WHERE deleted_at IS NULL`},
+	{name: "Tender", table: "tenders", query: `
+		SELECT id, 'Tender' AS source, user_id, company_id, total AS amount, NULL AS total,
+			CONCAT_WS(' ', type, tender_number, organization) AS description, status, date
+		FROM tenders
+		WHERE deleted_at IS NULL`},
+	{name: "Tranche", table: "tranches", query: `
+		SELECT tr.id, 'Tranche' AS source, t.user_id, t.company_id, tr.amount, NULL AS total,
+			tr.description, NULL AS status, tr.date
+		FROM tranches tr
+		LEFT JOIN transactions t ON t.id = tr.transaction_id
+		WHERE tr.deleted_at IS NULL AND t.deleted_at IS NULL`},
+	{name: "Change", table: "changes", query: `
+		SELECT c.id, 'Change' AS source, t.user_id, t.company_id, c.amount, NULL AS total,
+			c.description, NULL AS status, c.date
+		FROM changes c
+		LEFT JOIN transactions t ON t.id = c.transaction_id
+		WHERE c.deleted_at IS NULL AND t.deleted_at IS NULL`},
+	{name: "PersonalExpense", table: "personal_expenses", query: `
+		SELECT id, 'PersonalExpense' AS source, NULL AS user_id, NULL AS company_id, amount, NULL AS total,
+			reason AS description, NULL AS status, date
+		FROM personal_expenses
+		WHERE deleted_at IS NULL`},
+	{name: "PersonalDebt", table: "personal_debts", query: `
+		SELECT id, 'PersonalDebt' AS source, NULL AS user_id, NULL AS company_id, amount, NULL AS total,
+			name AS description, status, COALESCE(get_date, created_at) AS date
+		FROM personal_debts
+		WHERE deleted_at IS NULL`},
+	{name: "DebtTranche", table: "debt_tranches", query: `
+		SELECT dt.id, 'DebtTranche' AS source, NULL AS user_id, NULL AS company_id, dt.amount, NULL AS total,
+			dt.description, NULL AS status, dt.date
+		FROM debt_tranches dt
+		LEFT JOIN personal_debts d ON d.id = dt.debt_id
+		WHERE dt.deleted_at IS NULL AND d.deleted_at IS NULL`},
+	{name: "ExtraTransaction", table: "extra_transactions", query: `
+		SELECT id, 'ExtraTransaction' AS source, user_id, NULL AS company_id, total AS amount, NULL AS total,
+			description, status, date
+		FROM extra_transactions
+		WHERE deleted_at IS NULL`},
+	{name: "BalanceHistory", table: "balance_history", query: `
+		SELECT id, 'BalanceHistory' AS source, user_id, NULL AS company_id, amount, NULL AS total,
+			description, NULL AS status, created_at AS date
+		FROM balance_history
+		WHERE deleted_at IS NULL`},
+}
+
+func findHistorySource(name string) (historySource, bool) {
+	for _, source := range historySources {
+		if source.name == name {
+			return source, true
+		}
+	}
+	return historySource{}, false
+}
+
+// historyCursor is the position of the last item of a page. Items are ordered by
+// (date, source, id) descending, so the position stays valid when new rows are inserted.
+type historyCursor struct {
+	Date   time.Time `json:"d"`
+	Source string    `json:"s"`
+	ID     int       `json:"i"`
+}
+
+func (c historyCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeHistoryCursor(s string) (historyCursor, error) {
+	var c historyCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, models.ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.Source == "" {
+		return c, models.ErrInvalidCursor
+	}
+	return c, nil
+}
+
 type HistoryRepository struct {
 	Db *sql.DB
 }
 
-// GetAllHistory retrieves combined history data from all tables.
-func (r *HistoryRepository) GetAllHistory(ctx context.Context, source *string, startDate, endDate string, limit, offset int) ([]models.CombinedAction, error) {
-	query := `
-		WITH combined_data AS (
-			SELECT id, 'Transaction' AS source, amount, total, date
-			FROM transactions
-			UNION ALL
-			SELECT id, 'Tender' AS source, total AS amount, NULL AS total, date
-			FROM tenders
-			UNION ALL
-			SELECT id, 'PersonalExpense' AS source, amount, NULL AS total, date
-			FROM personal_expenses
-			UNION ALL
-			SELECT id, 'PersonalDebt' AS source, amount, NULL AS total, get_date AS date
-			FROM personal_debts
-			UNION ALL
-			SELECT id, 'ExtraTransaction' AS source, total AS amount, NULL AS total, date
-			FROM extra_transactions
-			UNION ALL
-			SELECT id, 'BalanceHistory' AS source, amount, NULL AS total, created_at AS date
-			FROM balance_history
-		)
-		SELECT *
-		FROM combined_data
-		WHERE
-			date >= ?
-			AND date <= ?
-			AND (? IS NULL OR source = ?)
-		ORDER BY date DESC
-		LIMIT ? OFFSET ?;
-	`
-
-	rows, err := r.Db.QueryContext(ctx, query, startDate, endDate, source, source, limit, offset)
+// historyQuery selects the combined history matching req's sources and filters, without ordering
+// or paging. An empty Sources list includes every source.
+func historyQuery(req models.HistoryRequest) (string, []interface{}, error) {
+	sources := historySources
+	if len(req.Sources) > 0 {
+		sources = make([]historySource, 0, len(req.Sources))
+		seen := map[string]bool{}
+		for _, name := range req.Sources {
+			source, ok := findHistorySource(name)
+			if !ok {
+				return "", nil, models.ErrUnknownHistorySource
+			}
+			if !seen[name] {
+				seen[name] = true
+				sources = append(sources, source)
+			}
+		}
+	}
+
+	selects := make([]string, 0, len(sources))
+	for _, source := range sources {
+		selects = append(selects, source.query)
+	}
+	query := "SELECT * FROM (" + strings.Join(selects, "\n\t\tUNION ALL") + "\n\t) AS combined_data\n\tWHERE date IS NOT NULL"
+	params := []interface{}{}
+
+	if req.StartDate != "" {
+		query += " AND date >= ?"
+		params = append(params, req.StartDate)
+	}
+	if req.EndDate != "" {
+		query += " AND date <= ?"
+		params = append(params, req.EndDate)
+	}
+	if req.UserID != nil {
+		query += " AND user_id = ?"
+		params = append(params, *req.UserID)
+	}
+	if req.CompanyID != nil {
+		query += " AND company_id = ?"
+		params = append(params, *req.CompanyID)
+	}
+	return query, params, nil
+}
+
+// scanHistoryAction reads a row selected by historyQuery.
+func scanHistoryAction(rows *sql.Rows) (models.CombinedAction, time.Time, error) {
+	var action models.CombinedAction
+	var total sql.NullFloat64 // Handle NULL for total
+	var date time.Time
+	err := rows.Scan(&action.ID, &action.Source, &action.UserID, &action.CompanyID, &action.Amount, &total,
+		&action.Description, &action.Status, &date)
+	if err != nil {
+		return action, date, err
+	}
+	if total.Valid {
+		action.Total = &total.Float64
+	}
+	action.Date = date.Format(time.RFC3339)
+	return action, date, nil
+}
+
+// GetAllHistory retrieves req.Limit items of the combined history of all sources, newest first,
+// skipping the first offset.
+func (r *HistoryRepository) GetAllHistory(ctx context.Context, req models.HistoryRequest, offset int) ([]models.CombinedAction, error) {
+	query, params, err := historyQuery(req)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.Db.QueryContext(ctx, query+" ORDER BY date DESC, source DESC, id DESC LIMIT ? OFFSET ?",
+		append(params, req.Limit, offset)...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var history []models.CombinedAction
+	history := []models.CombinedAction{}
 	for rows.Next() {
-		var action models.CombinedAction
-		var total sql.NullFloat64 // Handle NULL for total
-		err := rows.Scan(&action.ID, &action.Source, &action.Amount, &total, &action.Date)
+		action, _, err := scanHistoryAction(rows)
 		if err != nil {
 			return nil, err
 		}
+		history = append(history, action)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		if total.Valid {
-			action.Total = &total.Float64
-		} else {
-			action.Total = nil
+	if req.Expand {
+		if err := r.expand(ctx, history); err != nil {
+			return nil, err
 		}
+	}
+	return history, nil
+}
 
-		history = append(history, action)
+// GetHistoryPage retrieves the page of the combined history of all sources, newest first, that
+// follows req.Cursor.
+func (r *HistoryRepository) GetHistoryPage(ctx context.Context, req models.HistoryRequest) (models.HistoryPage, error) {
+	query, params, err := historyQuery(req)
+	if err != nil {
+		return models.HistoryPage{}, err
+	}
+	if req.Cursor != "" {
+		cursor, err := decodeHistoryCursor(req.Cursor)
+		if err != nil {
+			return models.HistoryPage{}, err
+		}
+		query += " AND (date, source, id) < (?, ?, ?)"
+		params = append(params, cursor.Date, cursor.Source, cursor.ID)
 	}
+	// One extra row tells whether another page follows.
+	query += " ORDER BY date DESC, source DESC, id DESC LIMIT ?"
+	params = append(params, req.Limit+1)
 
+	rows, err := r.Db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return models.HistoryPage{}, err
+	}
+	defer rows.Close()
+
+	page := models.HistoryPage{Items: []models.CombinedAction{}}
+	var last historyCursor
+	for rows.Next() {
+		if len(page.Items) == req.Limit {
+			page.NextCursor = last.encode()
+			break
+		}
+
+		action, date, err := scanHistoryAction(rows)
+		if err != nil {
+			return models.HistoryPage{}, err
+		}
+		last = historyCursor{Date: date, Source: action.Source, ID: action.ID}
+
+		page.Items = append(page.Items, action)
+	}
 	if err := rows.Err(); err != nil {
+		return models.HistoryPage{}, err
+	}
+
+	if req.Expand {
+		if err := r.expand(ctx, page.Items); err != nil {
+			return models.HistoryPage{}, err
+		}
+	}
+
+	return page, nil
+}
+
+// StreamHistory calls fn with every history item matching req, newest first, ignoring paging.
+func (r *HistoryRepository) StreamHistory(ctx context.Context, req models.HistoryRequest, fn func(models.CombinedAction) error) error {
+	query, params, err := historyQuery(req)
+	if err != nil {
+		return err
+	}
+	rows, err := r.Db.QueryContext(ctx, query+" ORDER BY date DESC, source DESC, id DESC", params...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		action, _, err := scanHistoryAction(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(action); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// expand attaches the underlying row to every item, loading each source with a single query.
+func (r *HistoryRepository) expand(ctx context.Context, items []models.CombinedAction) error {
+	bySource := map[string][]int{}
+	for i, item := range items {
+		bySource[item.Source] = append(bySource[item.Source], i)
+	}
+
+	for name, indexes := range bySource {
+		source, _ := findHistorySource(name)
+		ids := make([]interface{}, len(indexes))
+		for i, index := range indexes {
+			ids[i] = items[index].ID
+		}
+
+		records, err := r.fetchRecords(ctx, source.table, ids)
+		if err != nil {
+			return err
+		}
+		for _, index := range indexes {
+			items[index].Record = records[items[index].ID]
+		}
+	}
+	return nil
+}
+
+func (r *HistoryRepository) fetchRecords(ctx context.Context, table string, ids []interface{}) (map[int]map[string]interface{}, error) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	rows, err := r.Db.QueryContext(ctx, "SELECT * FROM "+table+" WHERE id IN ("+placeholders+")", ids...)
+	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
-	return history, nil
+	records := make(map[int]map[string]interface{}, len(ids))
+	for rows.Next() {
+		record, err := scanRowMap(rows)
+		if err != nil {
+			return nil, err
+		}
+		if id, err := strconv.Atoi(fmt.Sprint(record["id"])); err == nil {
+			records[id] = record
+		}
+	}
+	return records, rows.Err()
 }
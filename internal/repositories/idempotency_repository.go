@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"tender/internal/models"
+	"time"
+)
+
+type IdempotencyRepository struct {
+	Db *sql.DB
+}
+
+// Reserve inserts a pending record for the caller's key. It reports false when the key is already taken.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, record models.IdempotencyRecord) (bool, error) {
+	_, err := r.Db.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (idem_key, user_id, method, path, request_hash, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		record.Key, record.UserID, record.Method, record.Path, record.RequestHash, record.ExpiresAt)
+	if err != nil {
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1062 {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// GetByKey retrieves the record stored for the caller's key.
+func (r *IdempotencyRepository) GetByKey(ctx context.Context, userID int, key string) (models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	err := r.Db.QueryRowContext(ctx, `
+		SELECT id, idem_key, user_id, method, path, request_hash, status_code, content_type, response_body, created_at, expires_at
+		FROM idempotency_keys
+		WHERE user_id = ? AND idem_key = ?`, userID, key).Scan(
+		&record.ID, &record.Key, &record.UserID, &record.Method, &record.Path, &record.RequestHash,
+		&record.StatusCode, &record.ContentType, &record.ResponseBody, &record.CreatedAt, &record.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return record, models.ErrNoRecord
+		}
+		return record, err
+	}
+	return record, nil
+}
+
+// Complete stores the response produced for a reserved key.
+func (r *IdempotencyRepository) Complete(ctx context.Context, userID int, key string, statusCode int, contentType string, body []byte) error {
+	_, err := r.Db.ExecContext(ctx, `
+		UPDATE idempotency_keys SET status_code = ?, content_type = ?, response_body = ?
+		WHERE user_id = ? AND idem_key = ?`, statusCode, contentType, body, userID, key)
+	return err
+}
+
+// Delete removes the record for the caller's key so the request can be retried.
+func (r *IdempotencyRepository) Delete(ctx context.Context, userID int, key string) error {
+	_, err := r.Db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE user_id = ? AND idem_key = ?", userID, key)
+	return err
+}
+
+// DeleteExpired removes every record that expired before the given time.
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.Db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,648 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"tender/internal/models"
+	"time"
+)
+
+// NotificationRepository stores device tokens, the history of notifications and the outbox
+// they are delivered from.
+type NotificationRepository struct {
+	Db *sql.DB
+}
+
+// GetCompanyUserIDs returns the active users holding a permission on a company.
+func (r *NotificationRepository) GetCompanyUserIDs(ctx context.Context, companyID int) ([]int, error) {
+	rows, err := r.Db.QueryContext(ctx, `
+		SELECT DISTINCT p.user_id
+		FROM permissions p
+		JOIN users u ON u.id = p.user_id
+		WHERE p.company_id = ? AND p.status = 1 AND u.deleted_at IS NULL`, companyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, id)
+	}
+	return userIDs, rows.Err()
+}
+
+const tokenColumns = "id, user_id, token, platform, app_version, locale, created_at, last_seen_at"
+
+func scanToken(row interface{ Scan(...interface{}) error }) (models.Token, error) {
+	var t models.Token
+	err := row.Scan(&t.ID, &t.UserId, &t.Token, &t.Platform, &t.AppVersion, &t.Locale, &t.CreatedAt, &t.LastSeenAt)
+	return t, err
+}
+
+// UpsertToken registers a device token for t.UserId, or refreshes it when it is known. A token
+// registered by another user moves to t.UserId, and the pushes still queued for its previous
+// owner are failed so they never reach the new one.
+func (r *NotificationRepository) UpsertToken(ctx context.Context, t models.Token) (models.Token, error) {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.Token{}, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE notification_outbox SET status = ?, last_error = ? WHERE channel = ? AND token = ? AND user_id <> ? AND status = ?",
+		models.OutboxFailed, "device token moved to another user", models.ChannelPush, t.Token, t.UserId, models.OutboxPending)
+	if err != nil {
+		return models.Token{}, err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO notify_tokens (user_id, token, platform, app_version, locale) VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE
+			user_id = VALUES(user_id),
+			platform = COALESCE(VALUES(platform), platform),
+			app_version = COALESCE(VALUES(app_version), app_version),
+			locale = COALESCE(VALUES(locale), locale),
+			last_seen_at = CURRENT_TIMESTAMP`,
+		t.UserId, t.Token, t.Platform, t.AppVersion, t.Locale)
+	if err != nil {
+		return models.Token{}, err
+	}
+	saved, err := scanToken(tx.QueryRowContext(ctx, "SELECT "+tokenColumns+" FROM notify_tokens WHERE token = ?", t.Token))
+	if err != nil {
+		return models.Token{}, err
+	}
+	return saved, tx.Commit()
+}
+
+// ListTokens returns the device tokens of a user, most recently seen first.
+func (r *NotificationRepository) ListTokens(ctx context.Context, userID int) ([]models.Token, error) {
+	rows, err := r.Db.QueryContext(ctx,
+		"SELECT "+tokenColumns+" FROM notify_tokens WHERE user_id = ? ORDER BY last_seen_at DESC, id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []models.Token{}
+	for rows.Next() {
+		t, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+	return tokens, rows.Err()
+}
+
+// DeleteToken removes the device token id of a user. It returns models.ErrDeviceTokenNotFound
+// when the user has no such token.
+func (r *NotificationRepository) DeleteToken(ctx context.Context, id, userID int) error {
+	result, err := r.Db.ExecContext(ctx, "DELETE FROM notify_tokens WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err == nil && affected == 0 {
+		return models.ErrDeviceTokenNotFound
+	}
+	return err
+}
+
+// DeleteTokenValue removes a device token of a user by its value.
+func (r *NotificationRepository) DeleteTokenValue(ctx context.Context, userID int, token string) (int, error) {
+	result, err := r.Db.ExecContext(ctx, "DELETE FROM notify_tokens WHERE user_id = ? AND token = ?", userID, token)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// DeleteTokensByUserID removes every device token of a user.
+func (r *NotificationRepository) DeleteTokensByUserID(ctx context.Context, userID int) (int, error) {
+	result, err := r.Db.ExecContext(ctx, "DELETE FROM notify_tokens WHERE user_id = ?", userID)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// ExpireTokens removes the device tokens not seen since before.
+func (r *NotificationRepository) ExpireTokens(ctx context.Context, before time.Time) (int, error) {
+	result, err := r.Db.ExecContext(ctx, "DELETE FROM notify_tokens WHERE last_seen_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+// QueuedNotification is a notification for one user with the channels it is delivered on.
+type QueuedNotification struct {
+	Notify   models.Notify
+	Delivery models.NotificationDelivery
+}
+
+// Enqueue stores notifications for the channels of their deliveries in one database transaction:
+// in the user's inbox, in the outbox for instant delivery, or in the digest queue. Either all of
+// them are stored or none is. It returns how many outbox deliveries were queued.
+func (r *NotificationRepository) Enqueue(ctx context.Context, notifications ...QueuedNotification) (int, error) {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	queued := 0
+	for _, q := range notifications {
+		count, err := enqueueNotification(ctx, tx, q.Notify, q.Delivery)
+		if err != nil {
+			return 0, err
+		}
+		queued += count
+	}
+	return queued, tx.Commit()
+}
+
+// enqueueNotification stores n for the channels of d and returns how many outbox deliveries were
+// queued.
+func enqueueNotification(ctx context.Context, tx *sql.Tx, n models.Notify, d models.NotificationDelivery) (int, error) {
+	var historyID interface{}
+	if d.InApp {
+		data, err := json.Marshal(n.Data)
+		if err != nil {
+			return 0, err
+		}
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO notify_history (user_id, title, body, sender, receiver, category, data)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			n.UserID, n.Title, n.Body, n.Sender, n.Receiver, n.Category, data)
+		if err != nil {
+			return 0, err
+		}
+		if historyID, err = result.LastInsertId(); err != nil {
+			return 0, err
+		}
+	}
+
+	queued := 0
+	for _, channel := range []struct {
+		name, mode string
+		after      time.Time
+	}{
+		{models.ChannelPush, d.Push, d.PushAfter},
+		{models.ChannelEmail, d.Email, time.Time{}},
+	} {
+		switch channel.mode {
+		case models.ModeInstant:
+			count, err := enqueueOutbox(ctx, tx, channel.name, historyID, n, channel.after)
+			if err != nil {
+				return 0, err
+			}
+			queued += count
+		case models.ModeDigest:
+			_, err := tx.ExecContext(ctx,
+				"INSERT INTO notification_digest_queue (user_id, channel, title, body) VALUES (?, ?, ?, ?)",
+				n.UserID, channel.name, n.Title, n.Body)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+	return queued, nil
+}
+
+// enqueueOutbox queues n on a channel: for push, once for every device of the user; for email,
+// once to the user's address. Delivery starts at after, or at once when after is zero.
+func enqueueOutbox(ctx context.Context, tx *sql.Tx, channel string, historyID interface{}, n models.Notify, after time.Time) (int, error) {
+	data, err := json.Marshal(n.Data)
+	if err != nil {
+		return 0, err
+	}
+	if after.IsZero() {
+		after = time.Now()
+	}
+
+	var recipients string
+	switch channel {
+	case models.ChannelPush:
+		recipients = "SELECT DISTINCT user_id, token FROM notify_tokens WHERE user_id = ? AND token IS NOT NULL"
+	case models.ChannelEmail:
+		recipients = "SELECT id AS user_id, email AS token FROM users WHERE id = ? AND email <> '' AND deleted_at IS NULL"
+	}
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO notification_outbox (history_id, user_id, channel, token, title, body, data, next_attempt_at)
+		SELECT ?, recipient.user_id, ?, recipient.token, ?, ?, ?, ?
+		FROM (`+recipients+`) AS recipient`,
+		historyID, channel, n.Title, n.Body, data, after, n.UserID)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	return int(count), err
+}
+
+const outboxColumns = `id, history_id, user_id, channel, token, title, body, data, status, attempts, next_attempt_at,
+	last_error, message_id, created_at, sent_at`
+
+func (r *NotificationRepository) queryOutbox(ctx context.Context, query string, params ...interface{}) ([]models.OutboxMessage, error) {
+	rows, err := r.Db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []models.OutboxMessage{}
+	for rows.Next() {
+		var m models.OutboxMessage
+		var data []byte
+		if err := rows.Scan(&m.ID, &m.HistoryID, &m.UserID, &m.Channel, &m.Token, &m.Title, &m.Body, &data, &m.Status,
+			&m.Attempts, &m.NextAttemptAt, &m.LastError, &m.MessageID, &m.CreatedAt, &m.SentAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &m.Data); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	return messages, rows.Err()
+}
+
+// DueOutbox returns up to limit pending messages whose next attempt is due, oldest first.
+func (r *NotificationRepository) DueOutbox(ctx context.Context, now time.Time, limit int) ([]models.OutboxMessage, error) {
+	return r.queryOutbox(ctx, "SELECT "+outboxColumns+`
+		FROM notification_outbox
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY id
+		LIMIT ?`, models.OutboxPending, now, limit)
+}
+
+// ClaimOutbox counts an attempt at delivering a message and holds it until lease, so that no
+// other worker picks it up meanwhile. It reports false when another worker got there first.
+func (r *NotificationRepository) ClaimOutbox(ctx context.Context, id int64, attempts int, lease time.Time) (bool, error) {
+	result, err := r.Db.ExecContext(ctx, `
+		UPDATE notification_outbox SET attempts = attempts + 1, next_attempt_at = ?
+		WHERE id = ? AND status = ? AND attempts = ?`,
+		lease, id, models.OutboxPending, attempts)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (r *NotificationRepository) MarkSent(ctx context.Context, id int64, messageID string, now time.Time) error {
+	_, err := r.Db.ExecContext(ctx,
+		"UPDATE notification_outbox SET status = ?, message_id = ?, sent_at = ?, last_error = NULL WHERE id = ?",
+		models.OutboxSent, messageID, now, id)
+	return err
+}
+
+// MarkRetry schedules another attempt at next.
+func (r *NotificationRepository) MarkRetry(ctx context.Context, id int64, next time.Time, reason string) error {
+	_, err := r.Db.ExecContext(ctx,
+		"UPDATE notification_outbox SET next_attempt_at = ?, last_error = ? WHERE id = ?", next, reason, id)
+	return err
+}
+
+func (r *NotificationRepository) MarkFailed(ctx context.Context, id int64, reason string) error {
+	_, err := r.Db.ExecContext(ctx,
+		"UPDATE notification_outbox SET status = ?, last_error = ? WHERE id = ?", models.OutboxFailed, reason, id)
+	return err
+}
+
+// ForgetToken deletes a device token the provider has rejected and fails every message still
+// pending for it.
+func (r *NotificationRepository) ForgetToken(ctx context.Context, token, reason string) error {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM notify_tokens WHERE token = ?", token); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx,
+		"UPDATE notification_outbox SET status = ?, last_error = ? WHERE channel = ? AND token = ? AND status = ?",
+		models.OutboxFailed, reason, models.ChannelPush, token, models.OutboxPending)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListOutbox returns a page of the outbox, newest first.
+func (r *NotificationRepository) ListOutbox(ctx context.Context, f models.OutboxFilter) (models.OutboxPage, error) {
+	query := "SELECT " + outboxColumns + " FROM notification_outbox WHERE 1 = 1"
+	params := []interface{}{}
+	if f.Status != "" {
+		query += " AND status = ?"
+		params = append(params, f.Status)
+	}
+	if f.UserID != 0 {
+		query += " AND user_id = ?"
+		params = append(params, f.UserID)
+	}
+	if f.BeforeID != 0 {
+		query += " AND id < ?"
+		params = append(params, f.BeforeID)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	params = append(params, f.Limit)
+
+	items, err := r.queryOutbox(ctx, query, params...)
+	if err != nil {
+		return models.OutboxPage{}, err
+	}
+
+	counts := map[string]int{models.OutboxPending: 0, models.OutboxSent: 0, models.OutboxFailed: 0}
+	rows, err := r.Db.QueryContext(ctx, "SELECT status, COUNT(*) FROM notification_outbox GROUP BY status")
+	if err != nil {
+		return models.OutboxPage{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return models.OutboxPage{}, err
+		}
+		counts[status] = count
+	}
+	if err := rows.Err(); err != nil {
+		return models.OutboxPage{}, err
+	}
+
+	return models.OutboxPage{Items: items, Counts: counts}, nil
+}
+
+const notifyColumns = "id, user_id, title, body, sender, receiver, category, data, created_at, read_at"
+
+func (r *NotificationRepository) queryNotifications(ctx context.Context, query string, params ...interface{}) ([]models.Notify, error) {
+	rows, err := r.Db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []models.Notify{}
+	for rows.Next() {
+		var n models.Notify
+		var data []byte
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Title, &n.Body, &n.Sender, &n.Receiver, &n.Category, &data,
+			&n.CreatedAt, &n.ReadAt); err != nil {
+			return nil, err
+		}
+		n.Data = map[string]string{}
+		if data != nil {
+			if err := json.Unmarshal(data, &n.Data); err != nil {
+				return nil, err
+			}
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+func (r *NotificationRepository) GetHistoryByUserID(ctx context.Context, userID int) ([]models.Notify, error) {
+	return r.queryNotifications(ctx, "SELECT "+notifyColumns+" FROM notify_history WHERE user_id = ? ORDER BY id", userID)
+}
+
+// Inbox returns one page of a user's notifications, newest first. The cursor is the ID of the
+// last notification of the previous page.
+func (r *NotificationRepository) Inbox(ctx context.Context, req models.InboxRequest) (models.InboxPage, error) {
+	query := "SELECT " + notifyColumns + " FROM notify_history WHERE user_id = ?"
+	params := []interface{}{req.UserID}
+	if req.UnreadOnly {
+		query += " AND read_at IS NULL"
+	}
+	if req.Cursor != "" {
+		beforeID, err := decodeInboxCursor(req.Cursor)
+		if err != nil {
+			return models.InboxPage{}, err
+		}
+		query += " AND id < ?"
+		params = append(params, beforeID)
+	}
+	// One extra row tells whether another page follows.
+	query += " ORDER BY id DESC LIMIT ?"
+	params = append(params, req.Limit+1)
+
+	items, err := r.queryNotifications(ctx, query, params...)
+	if err != nil {
+		return models.InboxPage{}, err
+	}
+	page := models.InboxPage{Items: items}
+	if len(items) > req.Limit {
+		page.Items = items[:req.Limit]
+		page.NextCursor = encodeInboxCursor(page.Items[req.Limit-1].ID)
+	}
+
+	page.UnreadCount, err = r.UnreadCount(ctx, req.UserID)
+	return page, err
+}
+
+func encodeInboxCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(id)))
+}
+
+func decodeInboxCursor(s string) (int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, models.ErrInvalidCursor
+	}
+	id, err := strconv.Atoi(string(data))
+	if err != nil {
+		return 0, models.ErrInvalidCursor
+	}
+	return id, nil
+}
+
+func (r *NotificationRepository) UnreadCount(ctx context.Context, userID int) (int, error) {
+	var count int
+	err := r.Db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM notify_history WHERE user_id = ? AND read_at IS NULL", userID).Scan(&count)
+	return count, err
+}
+
+// MarkRead marks one of a user's notifications as read. Marking a read notification again
+// keeps its original read time.
+func (r *NotificationRepository) MarkRead(ctx context.Context, id, userID int) error {
+	result, err := r.Db.ExecContext(ctx,
+		"UPDATE notify_history SET read_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ? AND read_at IS NULL", id, userID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil || affected > 0 {
+		return err
+	}
+
+	var exists bool
+	err = r.Db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM notify_history WHERE id = ? AND user_id = ?)", id, userID).Scan(&exists)
+	if err == nil && !exists {
+		return models.ErrNotificationNotFound
+	}
+	return err
+}
+
+// MarkAllRead marks every unread notification of a user as read and returns how many there were.
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID int) (int, error) {
+	result, err := r.Db.ExecContext(ctx,
+		"UPDATE notify_history SET read_at = CURRENT_TIMESTAMP WHERE user_id = ? AND read_at IS NULL", userID)
+	if err != nil {
+		return 0, err
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
+
+func (r *NotificationRepository) DeleteHistory(ctx context.Context, id int) error {
+	_, err := r.Db.ExecContext(ctx, "DELETE FROM notify_history WHERE id = ?", id)
+	return err
+}
+
+// defaultDigestHour is the local hour of the daily digest of users who have not chosen one.
+const defaultDigestHour = 9
+
+// GetSettings returns a user's notification settings, with defaults when none were saved.
+func (r *NotificationRepository) GetSettings(ctx context.Context, userID int) (models.NotificationSettings, error) {
+	settings := models.NotificationSettings{UserID: userID, DigestHour: defaultDigestHour}
+	var timezone, quietStart, quietEnd sql.NullString
+	err := r.Db.QueryRowContext(ctx,
+		"SELECT timezone, quiet_start, quiet_end, digest_hour FROM notification_settings WHERE user_id = ?", userID).
+		Scan(&timezone, &quietStart, &quietEnd, &settings.DigestHour)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return settings, err
+	}
+	settings.Timezone, settings.QuietStart, settings.QuietEnd = timezone.String, quietStart.String, quietEnd.String
+
+	rows, err := r.Db.QueryContext(ctx,
+		"SELECT event, channel, mode FROM notification_preferences WHERE user_id = ? ORDER BY event, channel", userID)
+	if err != nil {
+		return settings, err
+	}
+	defer rows.Close()
+
+	settings.Preferences = []models.NotificationPreference{}
+	for rows.Next() {
+		var p models.NotificationPreference
+		if err := rows.Scan(&p.Event, &p.Channel, &p.Mode); err != nil {
+			return settings, err
+		}
+		settings.Preferences = append(settings.Preferences, p)
+	}
+	return settings, rows.Err()
+}
+
+// SaveSettings replaces a user's notification settings and preferences.
+func (r *NotificationRepository) SaveSettings(ctx context.Context, settings models.NotificationSettings) error {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO notification_settings (user_id, timezone, quiet_start, quiet_end, digest_hour)
+		VALUES (?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), ?)
+		ON DUPLICATE KEY UPDATE timezone = VALUES(timezone), quiet_start = VALUES(quiet_start),
+			quiet_end = VALUES(quiet_end), digest_hour = VALUES(digest_hour)`,
+		settings.UserID, settings.Timezone, settings.QuietStart, settings.QuietEnd, settings.DigestHour)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM notification_preferences WHERE user_id = ?", settings.UserID); err != nil {
+		return err
+	}
+	for _, p := range settings.Preferences {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO notification_preferences (user_id, event, channel, mode) VALUES (?, ?, ?, ?)",
+			settings.UserID, p.Event, p.Channel, p.Mode)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// DigestRecipients returns the users with notifications waiting for their daily digest.
+func (r *NotificationRepository) DigestRecipients(ctx context.Context) ([]models.DigestRecipient, error) {
+	rows, err := r.Db.QueryContext(ctx, `
+		SELECT q.user_id, COALESCE(s.timezone, ''), COALESCE(s.digest_hour, ?), s.last_digest_at
+		FROM (SELECT DISTINCT user_id FROM notification_digest_queue) AS q
+		LEFT JOIN notification_settings s ON s.user_id = q.user_id`, defaultDigestHour)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []models.DigestRecipient
+	for rows.Next() {
+		var d models.DigestRecipient
+		if err := rows.Scan(&d.UserID, &d.Timezone, &d.DigestHour, &d.LastDigestAt); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, d)
+	}
+	return recipients, rows.Err()
+}
+
+// DigestItems returns the notifications waiting for a user's digest, oldest first.
+func (r *NotificationRepository) DigestItems(ctx context.Context, userID int) ([]models.DigestItem, error) {
+	rows, err := r.Db.QueryContext(ctx,
+		"SELECT id, channel, title, body, created_at FROM notification_digest_queue WHERE user_id = ? ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.DigestItem
+	for rows.Next() {
+		var item models.DigestItem
+		if err := rows.Scan(&item.ID, &item.Channel, &item.Title, &item.Body, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// FlushDigest queues a user's digest for delivery, one message per channel, and removes the
+// items up to lastID that it summarises.
+func (r *NotificationRepository) FlushDigest(ctx context.Context, userID int, lastID int64, digests map[string]models.Notify, now time.Time) error {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for channel, n := range digests {
+		if _, err := enqueueOutbox(ctx, tx, channel, nil, n, now); err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM notification_digest_queue WHERE user_id = ? AND id <= ?", userID, lastID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO notification_settings (user_id, last_digest_at) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE last_digest_at = VALUES(last_digest_at)`, userID, now)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -13,12 +13,14 @@ type PermissionRepository struct {
 
 // AddPermission inserts a new permission into the database.
 func (r *PermissionRepository) AddPermission(ctx context.Context, permission models.Permission) (models.Permission, error) {
-	result, err := r.Db.ExecContext(ctx, "INSERT INTO permissions (user_id, company_id, status) VALUES (?, ?, 1)", permission.UserID, permission.CompanyID)
-	if err != nil {
-		return models.Permission{}, err
-	}
-
-	id, err := result.LastInsertId()
+	id, err := auditedExec(ctx, r.Db, "permission", models.AuditCreate, 0, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, "INSERT INTO permissions (user_id, company_id, status) VALUES (?, ?, 1)", permission.UserID, permission.CompanyID)
+		if err != nil {
+			return 0, err
+		}
+		id, err := result.LastInsertId()
+		return int(id), err
+	})
 	if err != nil {
 		return models.Permission{}, err
 	}
@@ -44,21 +46,22 @@ func (r *PermissionRepository) AddPermission(ctx context.Context, permission mod
 
 // DeletePermission removes a permission from the database by ID.
 func (r *PermissionRepository) DeletePermission(ctx context.Context, id int) error {
-	result, err := r.Db.ExecContext(ctx, "DELETE FROM permissions WHERE id = ?", id)
-	if err != nil {
-		return err
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	if rowsAffected == 0 {
-		return models.ErrPermissionNotFound
-	}
+	_, err := auditedExec(ctx, r.Db, "permission", models.AuditDelete, id, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, "DELETE FROM permissions WHERE id = ?", id)
+		if err != nil {
+			return 0, err
+		}
 
-	return nil
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if rowsAffected == 0 {
+			return 0, models.ErrPermissionNotFound
+		}
+		return id, nil
+	})
+	return err
 }
 
 // UpdatePermission updates an existing permission in the database.
@@ -89,7 +92,10 @@ func (r *PermissionRepository) UpdatePermission(ctx context.Context, permission
 	query += " WHERE id = ?"
 	params = append(params, permission.ID)
 
-	_, err := r.Db.ExecContext(ctx, query, params...)
+	_, err := auditedExec(ctx, r.Db, "permission", models.AuditUpdate, permission.ID, func(tx *sql.Tx) (int, error) {
+		_, err := tx.ExecContext(ctx, query, params...)
+		return permission.ID, err
+	})
 	if err != nil {
 		return models.Permission{}, err
 	}
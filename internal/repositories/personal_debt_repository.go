@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"tender/internal/models"
+	"time"
 )
 
 type PersonalDebtRepository struct {
@@ -16,26 +17,28 @@ func (r *PersonalDebtRepository) CreatePersonalDebt(ctx context.Context, debt *m
 		INSERT INTO personal_debts (name, amount, type, get_date, return_date, status) 
 		VALUES (?, ?, ?, ?, ?, ?)
 	`
-	result, err := r.Db.ExecContext(ctx, query, debt.Name, debt.Amount, debt.Type, debt.GetDate, debt.ReturnDate, debt.Status)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create personal debt: %w", err)
-	}
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, fmt.Errorf("failed to retrieve last insert ID: %w", err)
-	}
-	return int(id), nil
+	return auditedExec(ctx, r.Db, "personal_debt", models.AuditCreate, 0, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, query, debt.Name, debt.Amount, debt.Type, debt.GetDate, debt.ReturnDate, debt.Status)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create personal debt: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return 0, fmt.Errorf("failed to retrieve last insert ID: %w", err)
+		}
+		return int(id), nil
+	})
 }
 
 func (r *PersonalDebtRepository) GetPersonalDebtByID(ctx context.Context, id int) (*models.PersonalDebt, error) {
 	query := `
-		SELECT id, name, amount, type, get_date, return_date, status, created_at, updated_at
+		SELECT id, name, amount, type, get_date, return_date, status, created_at, updated_at, version
 		FROM personal_debts
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 	var debt models.PersonalDebt
 	err := r.Db.QueryRowContext(ctx, query, id).Scan(
-		&debt.ID, &debt.Name, &debt.Amount, &debt.Type, &debt.GetDate, &debt.ReturnDate, &debt.Status, &debt.CreatedAt, &debt.UpdatedAt)
+		&debt.ID, &debt.Name, &debt.Amount, &debt.Type, &debt.GetDate, &debt.ReturnDate, &debt.Status, &debt.CreatedAt, &debt.UpdatedAt, &debt.Version)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -45,27 +48,49 @@ func (r *PersonalDebtRepository) GetPersonalDebtByID(ctx context.Context, id int
 	return &debt, nil
 }
 
+// UpdatePersonalDebt overwrites a personal debt. A non-zero debt.Version must match the stored version.
 func (r *PersonalDebtRepository) UpdatePersonalDebt(ctx context.Context, debt *models.PersonalDebt) (*models.PersonalDebt, error) {
 	query := `
 		UPDATE personal_debts
-		SET name = ?, amount = ?, type = ?, get_date = ?, return_date = ?, status = ?
-		WHERE id = ?
+		SET name = ?, amount = ?, type = ?, get_date = ?, return_date = ?, status = ?, version = version + 1
+		WHERE id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)
 	`
-	_, err := r.Db.ExecContext(ctx, query, debt.Name, debt.Amount, debt.Type, debt.GetDate, debt.ReturnDate, debt.Status, debt.ID)
+	var rowsAffected int64
+	_, err := auditedExec(ctx, r.Db, "personal_debt", models.AuditUpdate, debt.ID, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, query, debt.Name, debt.Amount, debt.Type, debt.GetDate, debt.ReturnDate, debt.Status,
+			debt.ID, debt.Version, debt.Version)
+		if err != nil {
+			return 0, err
+		}
+		rowsAffected, err = result.RowsAffected()
+		return debt.ID, err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to update personal debt: %w", err)
 	}
 
 	// Retrieve the updated personal debt
-	return r.GetPersonalDebtByID(ctx, debt.ID)
+	updatedDebt, err := r.GetPersonalDebtByID(ctx, debt.ID)
+	if err != nil {
+		return nil, err
+	}
+	if updatedDebt != nil && rowsAffected == 0 {
+		return nil, models.ErrVersionConflict
+	}
+
+	return updatedDebt, nil
 }
 
+// DeletePersonalDebt moves a personal debt to the trash.
 func (r *PersonalDebtRepository) DeletePersonalDebt(ctx context.Context, id int) error {
 	query := `
-		DELETE FROM personal_debts
-		WHERE id = ?
+		UPDATE personal_debts SET deleted_at = NOW(), deleted_by = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
-	_, err := r.Db.ExecContext(ctx, query, id)
+	_, err := auditedExec(ctx, r.Db, "personal_debt", models.AuditDelete, id, func(tx *sql.Tx) (int, error) {
+		_, err := tx.ExecContext(ctx, query, deletedBy(ctx), id)
+		return id, err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to delete personal debt: %w", err)
 	}
@@ -76,6 +101,7 @@ func (r *PersonalDebtRepository) GetAllPersonalDebts(ctx context.Context) ([]mod
 	query := `
 		SELECT id, name, amount, type, get_date, return_date, status, created_at, updated_at
 		FROM personal_debts
+		WHERE deleted_at IS NULL
 		ORDER BY created_at DESC
 	`
 
@@ -104,7 +130,7 @@ func (r *PersonalDebtRepository) GetAllPersonalDebts(ctx context.Context) ([]mod
 func (r *PersonalDebtRepository) GetAllPersonalDebtsByStatus(ctx context.Context, status int) ([]models.PersonalDebt, error) {
 	query := `
 		SELECT id, name, amount, type, get_date, return_date, status, created_at, updated_at
-		FROM personal_debts WHERE status = ?
+		FROM personal_debts WHERE status = ? AND deleted_at IS NULL
 		ORDER BY created_at DESC
 	`
 
@@ -133,7 +159,7 @@ func (r *PersonalDebtRepository) GetAllPersonalDebtsByStatus(ctx context.Context
 func (r *PersonalDebtRepository) GetAllPersonalDebtsByType(ctx context.Context, status int) ([]models.PersonalDebt, error) {
 	query := `
 		SELECT id, name, amount, type, get_date, return_date, status, created_at, updated_at
-		FROM personal_debts WHERE type = ?
+		FROM personal_debts WHERE type = ? AND deleted_at IS NULL
 		ORDER BY created_at DESC
 	`
 
@@ -158,3 +184,87 @@ func (r *PersonalDebtRepository) GetAllPersonalDebtsByType(ctx context.Context,
 
 	return debts, nil
 }
+
+// unpaidDebtsQuery selects the debts with a return date that are not fully paid back, with the
+// amount paid so far.
+const unpaidDebtsQuery = `
+	SELECT d.id, d.name, d.amount, d.type, d.get_date, d.return_date, d.status, d.created_at, d.updated_at, d.version,
+		d.overdue_at, COALESCE(SUM(dt.amount), 0) AS paid
+	FROM personal_debts d
+	LEFT JOIN debt_tranches dt ON dt.debt_id = d.id AND dt.deleted_at IS NULL
+	WHERE d.deleted_at IS NULL AND d.return_date IS NOT NULL AND d.return_date <= ?
+	GROUP BY d.id
+	HAVING d.amount - paid > 0
+	ORDER BY d.return_date, d.id`
+
+// GetUnpaidDebts returns the unpaid debts due on or before until, earliest first. today is the
+// day DaysOverdue is counted to.
+func (r *PersonalDebtRepository) GetUnpaidDebts(ctx context.Context, until, today time.Time) ([]models.UnpaidDebt, error) {
+	rows, err := r.Db.QueryContext(ctx, unpaidDebtsQuery, until.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unpaid personal debts: %w", err)
+	}
+	defer rows.Close()
+
+	debts := []models.UnpaidDebt{}
+	for rows.Next() {
+		var debt models.UnpaidDebt
+		var returnDate time.Time
+		if err := rows.Scan(&debt.ID, &debt.Name, &debt.Amount, &debt.Type, &debt.GetDate, &returnDate, &debt.Status,
+			&debt.CreatedAt, &debt.UpdatedAt, &debt.Version, &debt.OverdueAt, &debt.Paid); err != nil {
+			return nil, fmt.Errorf("failed to scan unpaid personal debt: %w", err)
+		}
+		due := time.Date(returnDate.Year(), returnDate.Month(), returnDate.Day(), 0, 0, 0, 0, today.Location())
+		formatted := due.Format("2006-01-02")
+		debt.ReturnDate = &formatted
+		debt.Due = due
+		debt.Remaining = debt.Amount - debt.Paid
+		if days := int(today.Sub(due).Hours() / 24); days > 0 {
+			debt.DaysOverdue = days
+		}
+		debts = append(debts, debt)
+	}
+	return debts, rows.Err()
+}
+
+// HasReminder reports whether a reminder of kind was sent for a debt due on due. Moving the
+// return date makes the debt eligible again.
+func (r *PersonalDebtRepository) HasReminder(ctx context.Context, debtID int, kind string, due time.Time) (bool, error) {
+	var exists bool
+	err := r.Db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM personal_debt_reminders WHERE debt_id = ? AND kind = ? AND return_date = ?)",
+		debtID, kind, due.Format("2006-01-02")).Scan(&exists)
+	return exists, err
+}
+
+// RecordReminder notes that a reminder of kind was sent for a debt due on due.
+func (r *PersonalDebtRepository) RecordReminder(ctx context.Context, debtID int, kind string, due time.Time) error {
+	_, err := r.Db.ExecContext(ctx,
+		"INSERT IGNORE INTO personal_debt_reminders (debt_id, kind, return_date) VALUES (?, ?, ?)",
+		debtID, kind, due.Format("2006-01-02"))
+	return err
+}
+
+// SyncOverdue marks the unpaid debts whose return date is before today as overdue, and clears
+// the mark of debts paid back or given a later return date. It returns how many debts were newly
+// marked. The mark is not a user edit, so neither updated_at nor version changes.
+func (r *PersonalDebtRepository) SyncOverdue(ctx context.Context, today time.Time) (int, error) {
+	const paid = "(SELECT COALESCE(SUM(dt.amount), 0) FROM debt_tranches dt WHERE dt.debt_id = d.id AND dt.deleted_at IS NULL)"
+	day := today.Format("2006-01-02")
+
+	_, err := r.Db.ExecContext(ctx, `
+		UPDATE personal_debts d SET overdue_at = NULL, updated_at = updated_at
+		WHERE overdue_at IS NOT NULL
+			AND (deleted_at IS NOT NULL OR return_date IS NULL OR return_date >= ? OR amount <= `+paid+`)`, day)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear overdue personal debts: %w", err)
+	}
+	result, err := r.Db.ExecContext(ctx, `
+		UPDATE personal_debts d SET overdue_at = CURRENT_TIMESTAMP, updated_at = updated_at
+		WHERE overdue_at IS NULL AND deleted_at IS NULL AND return_date < ? AND amount > `+paid, day)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark overdue personal debts: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	return int(affected), err
+}
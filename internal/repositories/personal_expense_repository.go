@@ -15,12 +15,14 @@ type PersonalExpenseRepository struct {
 // TODO: add date
 // CreatePersonalExpense inserts a new expense into the database.
 func (r *PersonalExpenseRepository) CreatePersonalExpense(ctx context.Context, expense models.PersonalExpense) (int, error) {
-	result, err := r.Db.ExecContext(ctx, "INSERT INTO personal_expenses (amount, reason, description, category_id, date) VALUES (?, ?, ?, ?, ?)", expense.Amount, expense.Reason, expense.Description, expense.CategoryID, expense.Date)
-	if err != nil {
-		return 0, err
-	}
-
-	id, err := result.LastInsertId()
+	id, err := auditedExec(ctx, r.Db, "personal_expense", models.AuditCreate, 0, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, "INSERT INTO personal_expenses (amount, reason, description, category_id, date) VALUES (?, ?, ?, ?, ?)", expense.Amount, expense.Reason, expense.Description, expense.CategoryID, expense.Date)
+		if err != nil {
+			return 0, err
+		}
+		id, err := result.LastInsertId()
+		return int(id), err
+	})
 	if err != nil {
 		return 0, err
 	}
@@ -31,7 +33,7 @@ func (r *PersonalExpenseRepository) CreatePersonalExpense(ctx context.Context, e
 // GetPersonalExpenseByID retrieves an expense by ID from the database.
 func (r *PersonalExpenseRepository) GetPersonalExpenseByID(ctx context.Context, id int) (models.PersonalExpense, error) {
 	var expense models.PersonalExpense
-	err := r.Db.QueryRowContext(ctx, "SELECT id, amount, reason, description, category_id ,date FROM personal_expenses WHERE id = ?", id).
+	err := r.Db.QueryRowContext(ctx, "SELECT id, amount, reason, description, category_id ,date FROM personal_expenses WHERE id = ? AND deleted_at IS NULL", id).
 		Scan(&expense.ID, &expense.Amount, &expense.Reason, &expense.Description, &expense.CategoryID, &expense.Date)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -45,7 +47,7 @@ func (r *PersonalExpenseRepository) GetPersonalExpenseByID(ctx context.Context,
 
 // GetAllPersonalExpenses retrieves all expenses from the database.
 func (r *PersonalExpenseRepository) GetAllPersonalExpenses(ctx context.Context) ([]models.PersonalExpense, error) {
-	rows, err := r.Db.QueryContext(ctx, "SELECT id, amount, reason, description, category_id, date FROM personal_expenses")
+	rows, err := r.Db.QueryContext(ctx, "SELECT id, amount, reason, description, category_id, date FROM personal_expenses WHERE deleted_at IS NULL")
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +72,7 @@ func (r *PersonalExpenseRepository) GetAllPersonalExpenses(ctx context.Context)
 
 func (r *PersonalExpenseRepository) GetAllPersonalExpensesSummary(ctx context.Context) (*models.PersonalExpenseSummary, error) {
 	// Query to get all expenses
-	rows, err := r.Db.QueryContext(ctx, "SELECT id, amount, reason, description, category_id, date FROM personal_expenses")
+	rows, err := r.Db.QueryContext(ctx, "SELECT id, amount, reason, description, category_id, date FROM personal_expenses WHERE deleted_at IS NULL")
 	if err != nil {
 		return nil, err
 	}
@@ -125,7 +127,7 @@ func (r *PersonalExpenseRepository) GetAllPersonalExpensesSummary(ctx context.Co
 
 func (r *PersonalExpenseRepository) GetPersonalExpensesSummaryBySubCategory(ctx context.Context, category_id int) (*models.PersonalExpenseSummary, error) {
 	// Query to get all expenses with the specified category_id
-	rows, err := r.Db.QueryContext(ctx, "SELECT id, amount, reason, description, category_id, date FROM personal_expenses WHERE category_id = ?", category_id)
+	rows, err := r.Db.QueryContext(ctx, "SELECT id, amount, reason, description, category_id, date FROM personal_expenses WHERE category_id = ? AND deleted_at IS NULL", category_id)
 	if err != nil {
 		return nil, err
 	}
@@ -180,7 +182,7 @@ func (r *PersonalExpenseRepository) GetPersonalExpensesSummaryBySubCategory(ctx
 
 func (r *PersonalExpenseRepository) GetPersonalExpensesSummaryByCategory(ctx context.Context, category_id int) (*models.PersonalExpenseSummary, error) {
 	// Query to get all expenses with the specified category_id
-	rows, err := r.Db.QueryContext(ctx, "SELECT personal_expenses.id, amount, reason, description, category_id, date FROM personal_expenses JOIN tender.categories ON personal_expenses.category_id = categories.id WHERE parent_id = ? OR category_id = ?", category_id, category_id)
+	rows, err := r.Db.QueryContext(ctx, "SELECT personal_expenses.id, amount, reason, description, category_id, date FROM personal_expenses JOIN tender.categories ON personal_expenses.category_id = categories.id WHERE (parent_id = ? OR category_id = ?) AND personal_expenses.deleted_at IS NULL", category_id, category_id)
 	if err != nil {
 		return nil, err
 	}
@@ -235,7 +237,7 @@ func (r *PersonalExpenseRepository) GetPersonalExpensesSummaryByCategory(ctx con
 
 // GetAllPersonalExpenses retrieves all expenses from the database.
 func (r *PersonalExpenseRepository) GetPersonalExpensesByCategoryId(ctx context.Context, category_id int) ([]models.PersonalExpense, error) {
-	rows, err := r.Db.QueryContext(ctx, "SELECT id, amount, reason, description, category_id, date FROM personal_expenses WHERE category_id = ?", category_id)
+	rows, err := r.Db.QueryContext(ctx, "SELECT id, amount, reason, description, category_id, date FROM personal_expenses WHERE category_id = ? AND deleted_at IS NULL", category_id)
 	if err != nil {
 		return nil, err
 	}
@@ -282,16 +284,19 @@ func (r *PersonalExpenseRepository) UpdatePersonalExpense(ctx context.Context, e
 
 	// Trim the last comma from the query
 	query = query[:len(query)-1]
-	query += " WHERE id = ?"
+	query += " WHERE id = ? AND deleted_at IS NULL"
 	params = append(params, expense.ID)
 
-	_, err := r.Db.ExecContext(ctx, query, params...)
+	_, err := auditedExec(ctx, r.Db, "personal_expense", models.AuditUpdate, expense.ID, func(tx *sql.Tx) (int, error) {
+		_, err := tx.ExecContext(ctx, query, params...)
+		return expense.ID, err
+	})
 	if err != nil {
 		return models.PersonalExpense{}, err
 	}
 
 	// Retrieve the updated expense data
-	row := r.Db.QueryRowContext(ctx, "SELECT id, amount, reason, description, category_id FROM personal_expenses WHERE id = ?", expense.ID)
+	row := r.Db.QueryRowContext(ctx, "SELECT id, amount, reason, description, category_id FROM personal_expenses WHERE id = ? AND deleted_at IS NULL", expense.ID)
 	var updatedExpense models.PersonalExpense
 	err = row.Scan(&updatedExpense.ID, &updatedExpense.Amount, &updatedExpense.Reason, &updatedExpense.Description, &updatedExpense.CategoryID)
 	if err != nil {
@@ -304,21 +309,23 @@ func (r *PersonalExpenseRepository) UpdatePersonalExpense(ctx context.Context, e
 	return updatedExpense, nil
 }
 
-// DeletePersonalExpense removes an expense from the database by ID.
+// DeletePersonalExpense moves an expense to the trash by ID.
 func (r *PersonalExpenseRepository) DeletePersonalExpense(ctx context.Context, id int) error {
-	result, err := r.Db.ExecContext(ctx, "DELETE FROM personal_expenses WHERE id = ?", id)
-	if err != nil {
-		return err
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	if rowsAffected == 0 {
-		return models.ErrExpenseNotFound
-	}
+	_, err := auditedExec(ctx, r.Db, "personal_expense", models.AuditDelete, id, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx,
+			"UPDATE personal_expenses SET deleted_at = NOW(), deleted_by = ? WHERE id = ? AND deleted_at IS NULL", deletedBy(ctx), id)
+		if err != nil {
+			return 0, err
+		}
 
-	return nil
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if rowsAffected == 0 {
+			return 0, models.ErrExpenseNotFound
+		}
+		return id, nil
+	})
+	return err
 }
@@ -0,0 +1,109 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"tender/internal/models"
+	"time"
+)
+
+// ReceivableRepository stores the payment terms of companies and the overdue alerts sent.
+type ReceivableRepository struct {
+	Db *sql.DB
+}
+
+// ListPaymentTerms returns the companies' own payment terms by company ID.
+func (r *ReceivableRepository) ListPaymentTerms(ctx context.Context) (map[int]models.PaymentTerms, error) {
+	rows, err := r.Db.QueryContext(ctx, "SELECT company_id, due_days, escalate_days FROM company_payment_terms")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	terms := map[int]models.PaymentTerms{}
+	for rows.Next() {
+		var t models.PaymentTerms
+		if err := rows.Scan(&t.CompanyID, &t.DueDays, &t.EscalateDays); err != nil {
+			return nil, err
+		}
+		terms[t.CompanyID] = t
+	}
+	return terms, rows.Err()
+}
+
+// GetPaymentTerms returns the payment terms of a company, or nil when it has none of its own.
+func (r *ReceivableRepository) GetPaymentTerms(ctx context.Context, companyID int) (*models.PaymentTerms, error) {
+	t := models.PaymentTerms{CompanyID: companyID}
+	err := r.Db.QueryRowContext(ctx,
+		"SELECT due_days, escalate_days FROM company_payment_terms WHERE company_id = ?", companyID).
+		Scan(&t.DueDays, &t.EscalateDays)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *ReceivableRepository) SavePaymentTerms(ctx context.Context, t models.PaymentTerms) error {
+	_, err := r.Db.ExecContext(ctx, `
+		INSERT INTO company_payment_terms (company_id, due_days, escalate_days) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE due_days = VALUES(due_days), escalate_days = VALUES(escalate_days)`,
+		t.CompanyID, t.DueDays, t.EscalateDays)
+	return err
+}
+
+// DeletePaymentTerms returns a company to the default payment terms.
+func (r *ReceivableRepository) DeletePaymentTerms(ctx context.Context, companyID int) error {
+	_, err := r.Db.ExecContext(ctx, "DELETE FROM company_payment_terms WHERE company_id = ?", companyID)
+	return err
+}
+
+// HasAlert reports whether an alert of level was sent for the transaction.
+func (r *ReceivableRepository) HasAlert(ctx context.Context, transactionID int, level string) (bool, error) {
+	var exists bool
+	err := r.Db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM receivable_alerts WHERE transaction_id = ? AND level = ?)",
+		transactionID, level).Scan(&exists)
+	return exists, err
+}
+
+// RecordAlert notes an alert that was sent.
+func (r *ReceivableRepository) RecordAlert(ctx context.Context, a models.ReceivableAlert, due time.Time) error {
+	_, err := r.Db.ExecContext(ctx, `
+		INSERT IGNORE INTO receivable_alerts (transaction_id, level, company_id, user_id, due_date, outstanding)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		a.TransactionID, a.Level, a.CompanyID, a.UserID, due.Format("2006-01-02"), a.Outstanding)
+	return err
+}
+
+// ListAlerts returns the most recent alerts sent, of one company when companyID is set.
+func (r *ReceivableRepository) ListAlerts(ctx context.Context, companyID *int, limit int) ([]models.ReceivableAlert, error) {
+	query := "SELECT transaction_id, level, company_id, user_id, due_date, outstanding, sent_at FROM receivable_alerts"
+	params := []interface{}{}
+	if companyID != nil {
+		query += " WHERE company_id = ?"
+		params = append(params, *companyID)
+	}
+	query += " ORDER BY sent_at DESC, transaction_id DESC LIMIT ?"
+	params = append(params, limit)
+
+	rows, err := r.Db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := []models.ReceivableAlert{}
+	for rows.Next() {
+		var a models.ReceivableAlert
+		var due time.Time
+		if err := rows.Scan(&a.TransactionID, &a.Level, &a.CompanyID, &a.UserID, &due, &a.Outstanding, &a.SentAt); err != nil {
+			return nil, err
+		}
+		a.DueDate = due.Format("2006-01-02")
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
@@ -0,0 +1,748 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"tender/internal/models"
+	"time"
+)
+
+// reportColumn is a column of the report output.
+type reportColumn struct {
+	name string
+	expr string
+	text bool // scanned as a string rather than a number
+}
+
+// reportDimension groups transactions by key; label, when set, is a readable name for the key.
+type reportDimension struct {
+	key   reportColumn
+	label *reportColumn
+}
+
+// reportSchema is a table the report builder can query. Its dimensions, measures and filters
+// are the only SQL the builder puts into a query; everything supplied by the client is either
+// looked up here or passed as a parameter.
+type reportSchema struct {
+	from       string
+	dimensions map[string]reportDimension
+	measures   map[string]string
+	// filters maps ReportFilters fields to conditions taking one parameter.
+	filters map[string]string
+}
+
+// liveReport reads transactions directly. Like the aggregates, it leaves out undated ones.
+var liveReport = reportSchema{
+	from: `transactions t
+		LEFT JOIN companies c ON c.id = t.company_id
+		LEFT JOIN users u ON u.id = t.user_id
+		WHERE t.deleted_at IS NULL AND t.date IS NOT NULL`,
+	dimensions: map[string]reportDimension{
+		"year":    {key: reportColumn{name: "year", expr: "YEAR(t.date)"}},
+		"month":   {key: reportColumn{name: "month", expr: "MONTH(t.date)"}},
+		"quarter": {key: reportColumn{name: "quarter", expr: "QUARTER(t.date)"}},
+		"company": {
+			key:   reportColumn{name: "company_id", expr: "t.company_id"},
+			label: &reportColumn{name: "company_name", expr: "MAX(c.name)", text: true},
+		},
+		"user": {
+			key:   reportColumn{name: "user_id", expr: "t.user_id"},
+			label: &reportColumn{name: "user_name", expr: "MAX(CONCAT_WS(' ', u.name, u.last_name))", text: true},
+		},
+		"type":   {key: reportColumn{name: "type", expr: "t.type", text: true}},
+		"status": {key: reportColumn{name: "status", expr: "t.status"}},
+	},
+	measures: map[string]string{
+		"amount": "COALESCE(SUM(t.amount), 0)",
+		"total":  "COALESCE(SUM(t.total), 0)",
+		"sell":   "COALESCE(SUM(t.sell), 0)",
+		"margin": "COALESCE(SUM(t.margin), 0)",
+		"count":  "COUNT(*)",
+	},
+	filters: map[string]string{
+		"year":       "YEAR(t.date) = ?",
+		"month":      "MONTH(t.date) = ?",
+		"quarter":    "QUARTER(t.date) = ?",
+		"company_id": "t.company_id = ?",
+		"user_id":    "t.user_id = ?",
+		"type":       "t.type = ?",
+		"status":     "t.status = ?",
+		"start_date": "t.date >= ?",
+		"end_date":   "t.date <= ?",
+	},
+}
+
+// monthlyReport reads the transaction_monthly aggregates, which cannot filter by exact dates.
+var monthlyReport = reportSchema{
+	from: `transaction_monthly m
+		LEFT JOIN companies c ON c.id = m.company_id
+		LEFT JOIN users u ON u.id = m.user_id
+		WHERE 1 = 1`,
+	dimensions: map[string]reportDimension{
+		"year":    {key: reportColumn{name: "year", expr: "m.year"}},
+		"month":   {key: reportColumn{name: "month", expr: "m.month"}},
+		"quarter": {key: reportColumn{name: "quarter", expr: "(m.month + 2) DIV 3"}},
+		"company": {
+			key:   reportColumn{name: "company_id", expr: "NULLIF(m.company_id, 0)"},
+			label: &reportColumn{name: "company_name", expr: "MAX(c.name)", text: true},
+		},
+		"user": {
+			key:   reportColumn{name: "user_id", expr: "NULLIF(m.user_id, 0)"},
+			label: &reportColumn{name: "user_name", expr: "MAX(CONCAT_WS(' ', u.name, u.last_name))", text: true},
+		},
+		"type":   {key: reportColumn{name: "type", expr: "m.type", text: true}},
+		"status": {key: reportColumn{name: "status", expr: "m.status"}},
+	},
+	measures: map[string]string{
+		"amount": "COALESCE(SUM(m.amount), 0)",
+		"total":  "COALESCE(SUM(m.total), 0)",
+		"sell":   "COALESCE(SUM(m.sell), 0)",
+		"margin": "COALESCE(SUM(m.margin), 0)",
+		"count":  "COALESCE(SUM(m.deal_count), 0)",
+	},
+	filters: map[string]string{
+		"year":       "m.year = ?",
+		"month":      "m.month = ?",
+		"quarter":    "(m.month + 2) DIV 3 = ?",
+		"company_id": "m.company_id = ?",
+		"user_id":    "m.user_id = ?",
+		"type":       "m.type = ?",
+		"status":     "m.status = ?",
+	},
+}
+
+type ReportRepository struct {
+	Db *sql.DB
+}
+
+// Query runs a pivot report over transactions. It reads the monthly aggregates unless the
+// query filters by exact dates.
+func (r *ReportRepository) Query(ctx context.Context, q models.ReportQuery) (models.ReportResult, error) {
+	schema := monthlyReport
+	if q.Filters.StartDate != "" || q.Filters.EndDate != "" {
+		schema = liveReport
+	}
+	if len(q.Measures) == 0 {
+		return models.ReportResult{}, fmt.Errorf("%w: at least one measure is required", models.ErrInvalidReport)
+	}
+
+	var columns []reportColumn
+	var selects, groupBy []string
+	sortable := map[string]string{}
+	for _, name := range q.Dimensions {
+		dimension, ok := schema.dimensions[name]
+		if !ok {
+			return models.ReportResult{}, fmt.Errorf("%w: %s", models.ErrUnknownDimension, name)
+		}
+		if _, dup := sortable[name]; dup {
+			continue
+		}
+		columns = append(columns, dimension.key)
+		selects = append(selects, dimension.key.expr)
+		groupBy = append(groupBy, dimension.key.expr)
+		if dimension.label != nil {
+			columns = append(columns, *dimension.label)
+			selects = append(selects, dimension.label.expr)
+		}
+		sortable[name] = dimension.key.expr
+	}
+	for _, name := range q.Measures {
+		expr, ok := schema.measures[name]
+		if !ok {
+			return models.ReportResult{}, fmt.Errorf("%w: %s", models.ErrUnknownMeasure, name)
+		}
+		if _, dup := sortable[name]; dup {
+			continue
+		}
+		columns = append(columns, reportColumn{name: name, expr: expr})
+		selects = append(selects, expr)
+		sortable[name] = expr
+	}
+
+	query := "SELECT " + strings.Join(selects, ", ") + " FROM " + schema.from
+	where, params := schema.where(q.Filters)
+	query += where
+
+	if len(groupBy) > 0 {
+		query += " GROUP BY " + strings.Join(groupBy, ", ")
+	}
+
+	orderBy := make([]string, 0, len(q.OrderBy))
+	for _, field := range q.OrderBy {
+		name, direction := field, "ASC"
+		if strings.HasPrefix(field, "-") {
+			name, direction = field[1:], "DESC"
+		}
+		expr, ok := sortable[name]
+		if !ok {
+			return models.ReportResult{}, fmt.Errorf("%w: cannot order by %q", models.ErrInvalidReport, field)
+		}
+		orderBy = append(orderBy, expr+" "+direction)
+	}
+	if len(orderBy) == 0 {
+		orderBy = groupBy
+	}
+	if len(orderBy) > 0 {
+		query += " ORDER BY " + strings.Join(orderBy, ", ")
+	}
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		params = append(params, q.Limit)
+	}
+
+	rows, err := r.Db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return models.ReportResult{}, err
+	}
+	defer rows.Close()
+
+	result := models.ReportResult{Dimensions: q.Dimensions, Measures: q.Measures, Rows: []models.ReportRow{}}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		for i, column := range columns {
+			if column.text {
+				values[i] = new(sql.NullString)
+			} else {
+				values[i] = new(sql.NullFloat64)
+			}
+		}
+		if err := rows.Scan(values...); err != nil {
+			return models.ReportResult{}, err
+		}
+
+		row := make(models.ReportRow, len(columns))
+		for i, column := range columns {
+			switch v := values[i].(type) {
+			case *sql.NullString:
+				if v.Valid {
+					row[column.name] = v.String
+				} else {
+					row[column.name] = nil
+				}
+			case *sql.NullFloat64:
+				if v.Valid {
+					row[column.name] = v.Float64
+				} else {
+					row[column.name] = nil
+				}
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return models.ReportResult{}, err
+	}
+
+	return result, nil
+}
+
+// where builds the AND conditions for the query's filters.
+func (schema reportSchema) where(f models.ReportFilters) (string, []interface{}) {
+	var where strings.Builder
+	var params []interface{}
+	add := func(filter string, value interface{}) {
+		where.WriteString(" AND " + schema.filters[filter])
+		params = append(params, value)
+	}
+
+	if f.Year != nil {
+		add("year", *f.Year)
+	}
+	if f.Month != nil {
+		add("month", *f.Month)
+	}
+	if f.Quarter != nil {
+		add("quarter", *f.Quarter)
+	}
+	if f.CompanyID != nil {
+		add("company_id", *f.CompanyID)
+	}
+	if f.UserID != nil {
+		add("user_id", *f.UserID)
+	}
+	if f.Type != nil {
+		add("type", *f.Type)
+	}
+	if f.Status != nil {
+		add("status", *f.Status)
+	}
+	if f.StartDate != "" {
+		add("start_date", f.StartDate)
+	}
+	if f.EndDate != "" {
+		add("end_date", f.EndDate)
+	}
+	return where.String(), params
+}
+
+// ProfitAndLoss returns the raw profit and loss lines per month (YYYY-MM) of the period.
+// Revenue, cost and tender commissions of whole months are read from the monthly aggregates,
+// and those of a partly covered first or last month from live data. Margins are left for the
+// caller to compute. The period runs from the start day through the end day, as validated by the
+// caller; f gives only the company and user scope.
+func (r *ReportRepository) ProfitAndLoss(ctx context.Context, f models.PnLFilter, start, end time.Time) (map[string]*models.PnLFigures, error) {
+	end = end.AddDate(0, 0, 1)
+
+	months := map[string]*models.PnLFigures{}
+	month := func(key string) *models.PnLFigures {
+		if months[key] == nil {
+			months[key] = &models.PnLFigures{TenderCommissions: map[string]float64{}, PersonalExpenses: map[string]float64{}}
+		}
+		return months[key]
+	}
+
+	// Whole months run from wholeFrom up to wholeTo; the rest of the period is read live.
+	wholeFrom := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if wholeFrom.Before(start) {
+		wholeFrom = wholeFrom.AddDate(0, 1, 0)
+	}
+	wholeTo := time.Date(end.Year(), end.Month(), 1, 0, 0, 0, 0, time.UTC)
+	var liveRanges [][2]time.Time
+	if !wholeFrom.Before(wholeTo) {
+		liveRanges = append(liveRanges, [2]time.Time{start, end})
+	} else {
+		if start.Before(wholeFrom) {
+			liveRanges = append(liveRanges, [2]time.Time{start, wholeFrom})
+		}
+		if wholeTo.Before(end) {
+			liveRanges = append(liveRanges, [2]time.Time{wholeTo, end})
+		}
+		if err := r.aggregatedPnL(ctx, f, wholeFrom, wholeTo, month); err != nil {
+			return nil, err
+		}
+	}
+
+	var scope string
+	var scopeParams []interface{}
+	if f.CompanyID != nil {
+		scope += " AND t.company_id = ?"
+		scopeParams = append(scopeParams, *f.CompanyID)
+	}
+	if f.UserID != nil {
+		scope += " AND t.user_id = ?"
+		scopeParams = append(scopeParams, *f.UserID)
+	}
+	period := " AND t.date >= ? AND t.date < ?"
+
+	for _, live := range liveRanges {
+		params := append([]interface{}{live[0], live[1]}, scopeParams...)
+		err := r.eachRow(ctx, `
+			SELECT DATE_FORMAT(t.date, '%Y-%m') AS month, COALESCE(SUM(t.sell), 0), COALESCE(SUM(t.total), 0)
+			FROM transactions t
+			WHERE t.deleted_at IS NULL`+period+scope+`
+			GROUP BY month`, params, func(rows *sql.Rows) error {
+			var key string
+			var revenue, cost float64
+			if err := rows.Scan(&key, &revenue, &cost); err != nil {
+				return err
+			}
+			month(key).Revenue += revenue
+			month(key).Cost += cost
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		err = r.eachRow(ctx, `
+			SELECT DATE_FORMAT(t.date, '%Y-%m') AS month, t.type, COALESCE(SUM(t.commission), 0)
+			FROM tenders t
+			WHERE t.deleted_at IS NULL`+period+scope+`
+			GROUP BY month, t.type`, params, func(rows *sql.Rows) error {
+			var key, tenderType string
+			var commission float64
+			if err := rows.Scan(&key, &tenderType, &commission); err != nil {
+				return err
+			}
+			month(key).TenderCommissions[tenderType] += commission
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	params := append([]interface{}{start, end}, scopeParams...)
+	err := r.eachRow(ctx, `
+		SELECT DATE_FORMAT(t.date, '%Y-%m') AS month, COALESCE(SUM(ae.amount), 0)
+		FROM additional_expenses ae
+		JOIN transactions t ON t.id = ae.transaction_id
+		WHERE t.deleted_at IS NULL`+period+scope+`
+		GROUP BY month`, params, func(rows *sql.Rows) error {
+		var key string
+		var amount float64
+		if err := rows.Scan(&key, &amount); err != nil {
+			return err
+		}
+		month(key).AdditionalExpenses = amount
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Extra transactions have a user but no company.
+	if f.CompanyID == nil {
+		extraScope, extraParams := period, []interface{}{start, end}
+		if f.UserID != nil {
+			extraScope += " AND t.user_id = ?"
+			extraParams = append(extraParams, *f.UserID)
+		}
+		err = r.eachRow(ctx, `
+			SELECT DATE_FORMAT(t.date, '%Y-%m') AS month, COALESCE(SUM(t.total), 0)
+			FROM extra_transactions t
+			WHERE t.deleted_at IS NULL`+extraScope+`
+			GROUP BY month`, extraParams, func(rows *sql.Rows) error {
+			var key string
+			var total float64
+			if err := rows.Scan(&key, &total); err != nil {
+				return err
+			}
+			month(key).ExtraTransactions = total
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if f.CompanyID == nil && f.UserID == nil {
+		err = r.eachRow(ctx, `
+			SELECT DATE_FORMAT(t.date, '%Y-%m') AS month, COALESCE(c.category_name, ''), COALESCE(SUM(t.amount), 0)
+			FROM personal_expenses t
+			LEFT JOIN categories c ON c.id = t.category_id
+			WHERE t.deleted_at IS NULL`+period+`
+			GROUP BY month, c.category_name`, []interface{}{start, end}, func(rows *sql.Rows) error {
+			var key, category string
+			var amount float64
+			if err := rows.Scan(&key, &category, &amount); err != nil {
+				return err
+			}
+			month(key).PersonalExpenses[category] += amount
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return months, nil
+}
+
+// aggregatedPnL adds the revenue, cost and tender commissions of the whole months from up to
+// to, read from transaction_monthly and tender_monthly.
+func (r *ReportRepository) aggregatedPnL(ctx context.Context, f models.PnLFilter, from, to time.Time, month func(key string) *models.PnLFigures) error {
+	scope := " AND m.year * 12 + m.month >= ? AND m.year * 12 + m.month < ?"
+	params := []interface{}{from.Year()*12 + int(from.Month()), to.Year()*12 + int(to.Month())}
+	if f.CompanyID != nil {
+		scope += " AND m.company_id = ?"
+		params = append(params, *f.CompanyID)
+	}
+	if f.UserID != nil {
+		scope += " AND m.user_id = ?"
+		params = append(params, *f.UserID)
+	}
+
+	err := r.eachRow(ctx, `
+		SELECT m.year, m.month, COALESCE(SUM(m.sell), 0), COALESCE(SUM(m.total), 0)
+		FROM transaction_monthly m
+		WHERE 1 = 1`+scope+`
+		GROUP BY m.year, m.month`, params, func(rows *sql.Rows) error {
+		var year, monthNumber int
+		var revenue, cost float64
+		if err := rows.Scan(&year, &monthNumber, &revenue, &cost); err != nil {
+			return err
+		}
+		figures := month(fmt.Sprintf("%04d-%02d", year, monthNumber))
+		figures.Revenue += revenue
+		figures.Cost += cost
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.eachRow(ctx, `
+		SELECT m.year, m.month, m.type, COALESCE(SUM(m.commission), 0)
+		FROM tender_monthly m
+		WHERE 1 = 1`+scope+`
+		GROUP BY m.year, m.month, m.type`, params, func(rows *sql.Rows) error {
+		var year, monthNumber int
+		var tenderType string
+		var commission float64
+		if err := rows.Scan(&year, &monthNumber, &tenderType, &commission); err != nil {
+			return err
+		}
+		month(fmt.Sprintf("%04d-%02d", year, monthNumber)).TenderCommissions[tenderType] += commission
+		return nil
+	})
+}
+
+// eachRow runs query and calls scan for every row.
+func (r *ReportRepository) eachRow(ctx context.Context, query string, params []interface{}, scan func(rows *sql.Rows) error) error {
+	rows, err := r.Db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Receivable is a completed transaction that has not been fully paid with tranches.
+type Receivable struct {
+	CompanyID     *int
+	CompanyName   *string
+	UserID        *int
+	Item          models.AgingItem
+	CompletedAt   time.Time
+	LastPaymentAt *time.Time
+}
+
+// GetReceivables lists completed transactions with an outstanding balance, oldest first.
+// A nil userID lists every user's transactions.
+func (r *ReportRepository) GetReceivables(ctx context.Context, userID *int) ([]Receivable, error) {
+	query := `
+		SELECT t.company_id, c.name, t.user_id, t.id, t.tender_number, t.product_name, t.sell,
+			COALESCE(tr.paid, 0), COALESCE(t.completed_date, t.date), tr.last_payment
+		FROM transactions t
+		LEFT JOIN companies c ON c.id = t.company_id
+		LEFT JOIN (
+			SELECT transaction_id, SUM(amount) AS paid, MAX(date) AS last_payment
+			FROM tranches
+			WHERE deleted_at IS NULL
+			GROUP BY transaction_id
+		) tr ON tr.transaction_id = t.id
+		WHERE t.status = 2 AND t.deleted_at IS NULL AND t.sell - COALESCE(tr.paid, 0) > 0.005`
+	params := []interface{}{}
+	if userID != nil {
+		query += " AND t.user_id = ?"
+		params = append(params, *userID)
+	}
+	query += " ORDER BY COALESCE(t.completed_date, t.date), t.id"
+
+	rows, err := r.Db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var receivables []Receivable
+	for rows.Next() {
+		var rec Receivable
+		var completed, lastPayment sql.NullTime
+		err := rows.Scan(&rec.CompanyID, &rec.CompanyName, &rec.UserID, &rec.Item.TransactionID, &rec.Item.TenderNumber,
+			&rec.Item.ProductName, &rec.Item.Sell, &rec.Item.Paid, &completed, &lastPayment)
+		if err != nil {
+			return nil, err
+		}
+		rec.CompletedAt = completed.Time
+		if lastPayment.Valid {
+			rec.LastPaymentAt = &lastPayment.Time
+		}
+		receivables = append(receivables, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return receivables, nil
+}
+
+// Cash flow event kinds.
+const (
+	CashFlowReceivable = "receivable"
+	CashFlowTranche    = "tranche"
+	CashFlowDebtGiven  = "debt_given"
+	CashFlowDebtTaken  = "debt_taken"
+	// CashFlowDebtUnknown is a debt whose type is not configured as given or taken.
+	CashFlowDebtUnknown = "debt_unknown"
+)
+
+// CashFlowEvent is an expected movement of money on a date.
+type CashFlowEvent struct {
+	Kind   string
+	Date   time.Time
+	Amount float64
+}
+
+// GetTreasuryBalance returns the admin's balance, which holds the company's money.
+func (r *ReportRepository) GetTreasuryBalance(ctx context.Context) (float64, error) {
+	var balance float64
+	err := r.Db.QueryRowContext(ctx, "SELECT COALESCE(balance, 0) FROM users WHERE id = ?", models.AdminUserID).Scan(&balance)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return balance, err
+}
+
+// GetCashFlowEvents lists the money expected to come in or go out after now: tranches and debt
+// tranches dated in the future, and the remainder of receivables and personal debts that no
+// planned tranche covers. Receivables are due now; debts are due on their return date, or now
+// when it has passed. Debts without a return date are left out.
+func (r *ReportRepository) GetCashFlowEvents(ctx context.Context, now time.Time, debtTypes models.DebtTypes) ([]CashFlowEvent, error) {
+	var events []CashFlowEvent
+
+	err := r.eachRow(ctx, `
+		SELECT tr.date, tr.amount
+		FROM tranches tr
+		JOIN transactions t ON t.id = tr.transaction_id
+		WHERE tr.deleted_at IS NULL AND t.deleted_at IS NULL AND tr.date > ?`, []interface{}{now}, func(rows *sql.Rows) error {
+		event := CashFlowEvent{Kind: CashFlowTranche}
+		if err := rows.Scan(&event.Date, &event.Amount); err != nil {
+			return err
+		}
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.eachRow(ctx, `
+		SELECT t.sell - COALESCE(SUM(tr.amount), 0)
+		FROM transactions t
+		LEFT JOIN tranches tr ON tr.transaction_id = t.id AND tr.deleted_at IS NULL
+		WHERE t.status = 2 AND t.deleted_at IS NULL
+		GROUP BY t.id, t.sell
+		HAVING t.sell - COALESCE(SUM(tr.amount), 0) > 0`, nil, func(rows *sql.Rows) error {
+		event := CashFlowEvent{Kind: CashFlowReceivable, Date: now}
+		if err := rows.Scan(&event.Amount); err != nil {
+			return err
+		}
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	debtKind := func(debtType int) string {
+		switch debtTypes.Direction(debtType) {
+		case models.DebtGiven:
+			return CashFlowDebtGiven
+		case models.DebtTaken:
+			return CashFlowDebtTaken
+		}
+		return CashFlowDebtUnknown
+	}
+
+	err = r.eachRow(ctx, `
+		SELECT d.type, dt.date, dt.amount
+		FROM debt_tranches dt
+		JOIN personal_debts d ON d.id = dt.debt_id
+		WHERE dt.deleted_at IS NULL AND d.deleted_at IS NULL AND dt.date > ?`, []interface{}{now}, func(rows *sql.Rows) error {
+		var debtType int
+		var event CashFlowEvent
+		if err := rows.Scan(&debtType, &event.Date, &event.Amount); err != nil {
+			return err
+		}
+		event.Kind = debtKind(debtType)
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.eachRow(ctx, `
+		SELECT d.type, d.return_date, d.amount - COALESCE(SUM(dt.amount), 0)
+		FROM personal_debts d
+		LEFT JOIN debt_tranches dt ON dt.debt_id = d.id AND dt.deleted_at IS NULL
+		WHERE d.deleted_at IS NULL AND d.return_date IS NOT NULL
+		GROUP BY d.id, d.type, d.return_date, d.amount
+		HAVING d.amount - COALESCE(SUM(dt.amount), 0) > 0`, nil, func(rows *sql.Rows) error {
+		var debtType int
+		var event CashFlowEvent
+		if err := rows.Scan(&debtType, &event.Date, &event.Amount); err != nil {
+			return err
+		}
+		event.Kind = debtKind(debtType)
+		if event.Date.Before(now) {
+			event.Date = now
+		}
+		events = append(events, event)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// GetAverageMonthlyExpenses returns the average monthly personal expenses over the given
+// number of whole months before the month of now.
+func (r *ReportRepository) GetAverageMonthlyExpenses(ctx context.Context, now time.Time, months int) (float64, error) {
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	var total float64
+	err := r.Db.QueryRowContext(ctx, `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM personal_expenses
+		WHERE deleted_at IS NULL AND date >= ? AND date < ?`,
+		thisMonth.AddDate(0, -months, 0), thisMonth).Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+	return total / float64(months), nil
+}
+
+// UserPerformance is a user's closed deal metrics for a period.
+type UserPerformance struct {
+	UserID   int
+	UserName string
+	Paid     float64 // tranches received for the deals
+	models.LeaderboardMetrics
+}
+
+// GetUserPerformance returns the metrics of every user who completed a deal between start and
+// end (inclusive YYYY-MM-DD dates), optionally limited to one company. Tender commissions are
+// those of tenders completed in the same period.
+func (r *ReportRepository) GetUserPerformance(ctx context.Context, start, end string, companyID *int) ([]UserPerformance, error) {
+	// The same period and company conditions apply to the tender subquery and the transactions.
+	scope := " AND %[1]scompleted_date >= ? AND %[1]scompleted_date < DATE_ADD(?, INTERVAL 1 DAY)"
+	scopeParams := []interface{}{start, end}
+	if companyID != nil {
+		scope += " AND %[1]scompany_id = ?"
+		scopeParams = append(scopeParams, *companyID)
+	}
+	query := `
+		SELECT t.user_id, MAX(CONCAT_WS(' ', u.name, u.last_name)), COUNT(*),
+			COALESCE(SUM(t.sell), 0), COALESCE(SUM(t.margin), 0),
+			AVG(DATEDIFF(t.completed_date, t.date)), COALESCE(SUM(tr.paid), 0),
+			(SELECT COALESCE(SUM(tn.commission), 0) FROM tenders tn
+			 WHERE tn.user_id = t.user_id AND tn.status = 2 AND tn.deleted_at IS NULL` + fmt.Sprintf(scope, "tn.") + `)
+		FROM transactions t
+		JOIN users u ON u.id = t.user_id
+		LEFT JOIN (
+			SELECT transaction_id, SUM(amount) AS paid
+			FROM tranches
+			WHERE deleted_at IS NULL
+			GROUP BY transaction_id
+		) tr ON tr.transaction_id = t.id
+		WHERE t.deleted_at IS NULL AND t.status = 2` + fmt.Sprintf(scope, "t.")
+	params := append(append([]interface{}{}, scopeParams...), scopeParams...)
+	query += " GROUP BY t.user_id"
+
+	var stats []UserPerformance
+	err := r.eachRow(ctx, query, params, func(rows *sql.Rows) error {
+		var p UserPerformance
+		var cycle sql.NullFloat64
+		if err := rows.Scan(&p.UserID, &p.UserName, &p.ClosedDeals, &p.Revenue, &p.Margin, &cycle, &p.Paid, &p.TenderCommission); err != nil {
+			return err
+		}
+		if cycle.Valid {
+			p.AvgCycleDays = &cycle.Float64
+		}
+		stats = append(stats, p)
+		return nil
+	})
+	return stats, err
+}
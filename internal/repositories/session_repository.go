@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"tender/internal/models"
+	"time"
+)
+
+// SessionRepository stores the sessions opened at login. Only a hash of each token is kept.
+type SessionRepository struct {
+	Db *sql.DB
+}
+
+// Create stores a session of userID and drops the user's expired ones.
+func (r *SessionRepository) Create(ctx context.Context, userID int, tokenHash string, expiresAt time.Time) error {
+	if _, err := r.Db.ExecContext(ctx, "DELETE FROM user_sessions WHERE user_id = ? AND expires_at < ?", userID, time.Now()); err != nil {
+		return err
+	}
+	_, err := r.Db.ExecContext(ctx,
+		"INSERT INTO user_sessions (user_id, token_hash, expires_at) VALUES (?, ?, ?)",
+		userID, tokenHash, expiresAt)
+	return err
+}
+
+// UserID returns the user of an unexpired session.
+func (r *SessionRepository) UserID(ctx context.Context, tokenHash string, now time.Time) (int, error) {
+	var userID int
+	err := r.Db.QueryRowContext(ctx, `
+		SELECT s.user_id
+		FROM user_sessions s
+		JOIN users u ON u.id = s.user_id AND u.deleted_at IS NULL
+		WHERE s.token_hash = ? AND s.expires_at > ?`, tokenHash, now).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, models.ErrSessionNotFound
+	}
+	return userID, err
+}
+
+func (r *SessionRepository) Delete(ctx context.Context, tokenHash string) error {
+	_, err := r.Db.ExecContext(ctx, "DELETE FROM user_sessions WHERE token_hash = ?", tokenHash)
+	return err
+}
@@ -0,0 +1,144 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"tender/internal/models"
+	"time"
+)
+
+type SubscriptionRepository struct {
+	Db *sql.DB
+}
+
+const subscriptionColumns = "id, user_id, report, filters, schedule, format, email, active, last_run_at, next_run_at, created_at"
+
+func scanSubscription(scan func(dest ...interface{}) error) (models.ReportSubscription, error) {
+	var sub models.ReportSubscription
+	var filters []byte
+	err := scan(&sub.ID, &sub.UserID, &sub.Report, &filters, &sub.Schedule, &sub.Format, &sub.Email, &sub.Active,
+		&sub.LastRunAt, &sub.NextRunAt, &sub.CreatedAt)
+	if err != nil {
+		return sub, err
+	}
+	if err := json.Unmarshal(filters, &sub.Filters); err != nil {
+		return sub, err
+	}
+	return sub, nil
+}
+
+func (r *SubscriptionRepository) querySubscriptions(ctx context.Context, query string, params ...interface{}) ([]models.ReportSubscription, error) {
+	rows, err := r.Db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []models.ReportSubscription{}
+	for rows.Next() {
+		sub, err := scanSubscription(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, rows.Err()
+}
+
+func (r *SubscriptionRepository) Create(ctx context.Context, sub models.ReportSubscription, nextRun time.Time) (int, error) {
+	filters, err := json.Marshal(sub.Filters)
+	if err != nil {
+		return 0, err
+	}
+	result, err := r.Db.ExecContext(ctx, `
+		INSERT INTO report_subscriptions (user_id, report, filters, schedule, format, email, active, next_run_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		sub.UserID, sub.Report, filters, sub.Schedule, sub.Format, sub.Email, sub.Active, nextRun)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+func (r *SubscriptionRepository) GetByID(ctx context.Context, id int) (models.ReportSubscription, error) {
+	row := r.Db.QueryRowContext(ctx, "SELECT "+subscriptionColumns+" FROM report_subscriptions WHERE id = ?", id)
+	sub, err := scanSubscription(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return sub, models.ErrSubscriptionNotFound
+	}
+	return sub, err
+}
+
+func (r *SubscriptionRepository) ListByUser(ctx context.Context, userID int) ([]models.ReportSubscription, error) {
+	return r.querySubscriptions(ctx, "SELECT "+subscriptionColumns+" FROM report_subscriptions WHERE user_id = ? ORDER BY id", userID)
+}
+
+// Due returns the active subscriptions whose next run is at or before now.
+func (r *SubscriptionRepository) Due(ctx context.Context, now time.Time) ([]models.ReportSubscription, error) {
+	return r.querySubscriptions(ctx,
+		"SELECT "+subscriptionColumns+" FROM report_subscriptions WHERE active AND next_run_at <= ? ORDER BY next_run_at", now)
+}
+
+// Claim moves a due subscription's next run from due to next. It reports false when another
+// process has claimed the run already.
+func (r *SubscriptionRepository) Claim(ctx context.Context, id int, due, next, now time.Time) (bool, error) {
+	result, err := r.Db.ExecContext(ctx,
+		"UPDATE report_subscriptions SET next_run_at = ?, last_run_at = ? WHERE id = ? AND next_run_at = ?",
+		next, now, id, due)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+func (r *SubscriptionRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.Db.ExecContext(ctx, "DELETE FROM report_subscriptions WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return models.ErrSubscriptionNotFound
+	}
+	return nil
+}
+
+func (r *SubscriptionRepository) RecordRun(ctx context.Context, run models.SubscriptionRun, startedAt, finishedAt time.Time) error {
+	_, err := r.Db.ExecContext(ctx, `
+		INSERT INTO report_subscription_runs (subscription_id, started_at, finished_at, status, recipient, error)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		run.SubscriptionID, startedAt, finishedAt, run.Status, run.Recipient, run.Error)
+	return err
+}
+
+// ListRuns returns the latest runs of a subscription, newest first.
+func (r *SubscriptionRepository) ListRuns(ctx context.Context, subscriptionID, limit int) ([]models.SubscriptionRun, error) {
+	rows, err := r.Db.QueryContext(ctx, `
+		SELECT id, subscription_id, started_at, finished_at, status, recipient, error
+		FROM report_subscription_runs
+		WHERE subscription_id = ?
+		ORDER BY id DESC
+		LIMIT ?`, subscriptionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []models.SubscriptionRun{}
+	for rows.Next() {
+		var run models.SubscriptionRun
+		if err := rows.Scan(&run.ID, &run.SubscriptionID, &run.StartedAt, &run.FinishedAt, &run.Status,
+			&run.Recipient, &run.Error); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
@@ -14,14 +14,14 @@ type SumRepository struct {
 func (r *SumRepository) GetSumsByUserID(ctx context.Context, userID int) (models.Sums, error) {
 	query := `
     SELECT
-        (SELECT COALESCE(SUM(total), 0) FROM transactions WHERE user_id = ? AND status = 2) AS transactions_sum,
+        (SELECT COALESCE(SUM(total), 0) FROM transactions WHERE user_id = ? AND status = 2 AND deleted_at IS NULL) AS transactions_sum,
         (SELECT COALESCE(SUM(ae.amount), 0)
          FROM additional_expenses ae
          JOIN transactions t ON ae.transaction_id = t.id
-         WHERE t.user_id = ? AND t.status = 2) AS additional_expenses_sum,
-        (SELECT COALESCE(SUM(total), 0) FROM tenders WHERE user_id = ? AND status = 2 AND type = 'ГОИК') AS tenders_goik_sum,
-        (SELECT COALESCE(SUM(total), 0) FROM tenders WHERE user_id = ? AND status = 2 AND type = 'ГОПП') AS tenders_gopp_sum,
-         (SELECT COALESCE(SUM(total), 0) FROM extra_transactions WHERE user_id = ? AND status = 2) AS extra_transactions_sum
+         WHERE t.user_id = ? AND t.status = 2 AND t.deleted_at IS NULL) AS additional_expenses_sum,
+        (SELECT COALESCE(SUM(total), 0) FROM tenders WHERE user_id = ? AND status = 2 AND type = 'ГОИК' AND deleted_at IS NULL) AS tenders_goik_sum,
+        (SELECT COALESCE(SUM(total), 0) FROM tenders WHERE user_id = ? AND status = 2 AND type = 'ГОПП' AND deleted_at IS NULL) AS tenders_gopp_sum,
+         (SELECT COALESCE(SUM(total), 0) FROM extra_transactions WHERE user_id = ? AND status = 2 AND deleted_at IS NULL) AS extra_transactions_sum
     `
 	row := r.Db.QueryRowContext(ctx, query, userID, userID, userID, userID, userID)
 	var sums models.Sums
@@ -35,13 +35,13 @@ func (r *SumRepository) GetSumsByUserID(ctx context.Context, userID int) (models
 func (r *SumRepository) GetDebtsByAccount(ctx context.Context) ([]models.AccountDebts, error) {
 	query := `
     WITH tender_numbers AS (
-        SELECT tender_number FROM transactions WHERE status = 2
+        SELECT tender_number FROM transactions WHERE status = 2 AND deleted_at IS NULL
         UNION
         SELECT t.tender_number FROM additional_expenses ae
         JOIN transactions t ON ae.transaction_id = t.id
-        WHERE t.status = 2
+        WHERE t.status = 2 AND t.deleted_at IS NULL
         UNION
-        SELECT tender_number FROM tenders WHERE status = 2
+        SELECT tender_number FROM tenders WHERE status = 2 AND deleted_at IS NULL
     )
     SELECT
         tn.tender_number AS account_number,
@@ -53,26 +53,26 @@ func (r *SumRepository) GetDebtsByAccount(ctx context.Context) ([]models.Account
     LEFT JOIN (
         SELECT tender_number, SUM(total) AS transactions_sum
         FROM transactions
-        WHERE status = 2
+        WHERE status = 2 AND deleted_at IS NULL
         GROUP BY tender_number
     ) ts ON tn.tender_number = ts.tender_number
     LEFT JOIN (
         SELECT t.tender_number, SUM(ae.amount) AS additional_expenses_sum
         FROM additional_expenses ae
         JOIN transactions t ON ae.transaction_id = t.id
-        WHERE t.status = 2
+        WHERE t.status = 2 AND t.deleted_at IS NULL
         GROUP BY t.tender_number
     ) aes ON tn.tender_number = aes.tender_number
     LEFT JOIN (
         SELECT tender_number, SUM(total) AS tenders_goik_sum
         FROM tenders
-        WHERE status = 2 AND type = 'ГОИК'
+        WHERE status = 2 AND type = 'ГОИК' AND deleted_at IS NULL
         GROUP BY tender_number
     ) goik ON tn.tender_number = goik.tender_number
     LEFT JOIN (
         SELECT tender_number, SUM(total) AS tenders_gopp_sum
         FROM tenders
-        WHERE status = 2 AND type = 'ГОПП'
+        WHERE status = 2 AND type = 'ГОПП' AND deleted_at IS NULL
         GROUP BY tender_number
     ) gopp ON tn.tender_number = gopp.tender_number
     ORDER BY tn.tender_number
@@ -17,43 +17,43 @@ func (r *TenderRepository) CreateTender(ctx context.Context, tender models.Tende
 	now := time.Now()
 	formattedTime := now.Format("2006-01-02 15:04:05")
 
-	result, err := r.Db.ExecContext(ctx, `
-        INSERT INTO tenders (
-            type, tender_number, user_id, company_id, organization,
-            total, commission, completed_date, date, status
-        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		tender.Type, tender.TenderNumber, tender.UserID, tender.CompanyID, tender.Organization,
-		tender.Total, tender.Commission, tender.CompletedDate, formattedTime, tender.Status,
-	)
-	if err != nil {
-		return 0, err
-	}
-
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, err
-	}
+	return auditedExec(ctx, r.Db, "tender", models.AuditCreate, 0, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, `
+            INSERT INTO tenders (
+                type, tender_number, user_id, company_id, organization,
+                total, commission, completed_date, date, status
+            ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			tender.Type, tender.TenderNumber, tender.UserID, tender.CompanyID, tender.Organization,
+			tender.Total, tender.Commission, tender.CompletedDate, formattedTime, tender.Status,
+		)
+		if err != nil {
+			return 0, err
+		}
 
-	return int(id), nil
+		id, err := result.LastInsertId()
+		return int(id), err
+	})
 }
 
-// DeleteTender removes a tender from the database by ID.
+// DeleteTender moves a tender to the trash by ID.
 func (r *TenderRepository) DeleteTender(ctx context.Context, id int) error {
-	result, err := r.Db.ExecContext(ctx, "DELETE FROM tenders WHERE id = ?", id)
-	if err != nil {
-		return err
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	if rowsAffected == 0 {
-		return models.ErrTenderNotFound
-	}
+	_, err := auditedExec(ctx, r.Db, "tender", models.AuditDelete, id, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx,
+			"UPDATE tenders SET deleted_at = NOW(), deleted_by = ? WHERE id = ? AND deleted_at IS NULL", deletedBy(ctx), id)
+		if err != nil {
+			return 0, err
+		}
 
-	return nil
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if rowsAffected == 0 {
+			return 0, models.ErrTenderNotFound
+		}
+		return id, nil
+	})
+	return err
 }
 
 // UpdateTender updates an existing tender in the database.
@@ -107,18 +107,52 @@ func (r *TenderRepository) UpdateTender(ctx context.Context, tender models.Tende
 		return r.GetTenderByID(ctx, tender.ID)
 	}
 
-	// Trim the last comma from the query
-	query = query[:len(query)-1]
-	query += " WHERE id = ?"
-	params = append(params, tender.ID)
+	query += " version = version + 1 WHERE id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)"
+	params = append(params, tender.ID, tender.Version, tender.Version)
+
+	return r.execTenderUpdate(ctx, tender.ID, query, params...)
+}
+
+// ReplaceTender overwrites every column of an existing tender, storing zero values as given.
+func (r *TenderRepository) ReplaceTender(ctx context.Context, tender models.Tender) (models.Tender, error) {
+	query := `
+        UPDATE tenders SET
+            type = ?, tender_number = ?, user_id = ?, company_id = ?, organization = ?,
+            total = ?, commission = ?, completed_date = ?, date = ?, status = ?, version = version + 1
+        WHERE id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)`
 
-	_, err := r.Db.ExecContext(ctx, query, params...)
+	return r.execTenderUpdate(ctx, tender.ID, query,
+		tender.Type, tender.TenderNumber, tender.UserID, tender.CompanyID, tender.Organization,
+		tender.Total, tender.Commission, tender.CompletedDate, tender.Date, tender.Status,
+		tender.ID, tender.Version, tender.Version,
+	)
+}
+
+// execTenderUpdate runs a versioned update and tells a missing tender apart from a stale version.
+func (r *TenderRepository) execTenderUpdate(ctx context.Context, id int, query string, params ...interface{}) (models.Tender, error) {
+	var rowsAffected int64
+	_, err := auditedExec(ctx, r.Db, "tender", models.AuditUpdate, id, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, query, params...)
+		if err != nil {
+			return 0, err
+		}
+		rowsAffected, err = result.RowsAffected()
+		return id, err
+	})
 	if err != nil {
 		return models.Tender{}, err
 	}
 
 	// Retrieve the updated tender data
-	return r.GetTenderByID(ctx, tender.ID)
+	updated, err := r.GetTenderByID(ctx, id)
+	if err != nil {
+		return models.Tender{}, err
+	}
+	if rowsAffected == 0 {
+		return models.Tender{}, models.ErrVersionConflict
+	}
+
+	return updated, nil
 }
 
 // GetTenderByID retrieves a tender by ID from the database.
@@ -126,14 +160,16 @@ func (r *TenderRepository) GetTenderByID(ctx context.Context, id int) (models.Te
 	var tender models.Tender
 	err := r.Db.QueryRowContext(ctx, `
         SELECT tenders.id, type, tender_number, user_id, company_id, organization,
-               total, commission, completed_date, date, tenders.status, u.name, c.name
+               total, commission, completed_date, date, tenders.status, u.name, c.name,
+               tenders.version, tenders.updated_at
         FROM tenders
         JOIN tender.users u ON u.id = tenders.user_id
 		JOIN tender.companies c ON c.id = tenders.company_id
-		 WHERE tenders.id = ?
+		 WHERE tenders.id = ? AND tenders.deleted_at IS NULL
 		ORDER BY tenders.date DESC`, id).Scan(
 		&tender.ID, &tender.Type, &tender.TenderNumber, &tender.UserID, &tender.CompanyID, &tender.Organization,
 		&tender.Total, &tender.Commission, &tender.CompletedDate, &tender.Date, &tender.Status, &tender.UserName, &tender.CompanyName,
+		&tender.Version, &tender.UpdatedAt,
 	)
 
 	if err != nil {
@@ -154,6 +190,7 @@ func (r *TenderRepository) GetAllTenders(ctx context.Context) ([]models.Tender,
         FROM tenders
         JOIN tender.users u ON u.id = tenders.user_id
 		JOIN tender.companies c ON c.id = tenders.company_id
+		WHERE tenders.deleted_at IS NULL
 		ORDER BY tenders.date DESC`)
 	if err != nil {
 		return nil, err
@@ -188,6 +225,7 @@ func (r *TenderRepository) GetTotalNetByCompany(ctx context.Context) ([]struct {
 	rows, err := r.Db.QueryContext(ctx, `
 		SELECT company_id, SUM(total - commission) AS total_net
 		FROM tenders
+		WHERE deleted_at IS NULL
 		GROUP BY company_id
 	`)
 	if err != nil {
@@ -225,7 +263,7 @@ func (r *TenderRepository) GetTendersByUserID(ctx context.Context, userID int) (
         FROM tenders
         JOIN tender.users u ON u.id = tenders.user_id
         JOIN tender.companies c ON c.id = tenders.company_id
-        WHERE tenders.user_id = ?
+        WHERE tenders.user_id = ? AND tenders.deleted_at IS NULL
         ORDER BY tenders.date DESC`, userID)
 	if err != nil {
 		return nil, err
@@ -259,7 +297,7 @@ func (r *TenderRepository) GetTendersByCompanyID(ctx context.Context, companyID
         FROM tenders
         JOIN tender.users u ON u.id = tenders.user_id
         JOIN tender.companies c ON c.id = tenders.company_id
-        WHERE tenders.company_id = ?
+        WHERE tenders.company_id = ? AND tenders.deleted_at IS NULL
         ORDER BY tenders.date DESC`, companyID)
 	if err != nil {
 		return nil, err
@@ -290,7 +328,7 @@ func (r *TenderRepository) GetAllTendersSum(ctx context.Context) (*models.Tender
 	queryGOIK := `
         SELECT COALESCE(SUM(total-commission), 0) AS total_sum
         FROM tenders
-        WHERE status = 3 AND status = 2
+        WHERE status = 3 AND status = 2 AND deleted_at IS NULL
         AND type = 'ГОИК';
     `
 
@@ -304,7 +342,7 @@ func (r *TenderRepository) GetAllTendersSum(ctx context.Context) (*models.Tender
 	queryGOPP := `
         SELECT COALESCE(SUM(total-commission), 0) AS total_sum
         FROM tenders
-        WHERE status = 3 AND status = 2
+        WHERE status = 3 AND status = 2 AND deleted_at IS NULL
         AND type = 'ГОПП';
     `
 
@@ -326,14 +364,14 @@ func (r *TenderRepository) GetTenderCountsByUserID(ctx context.Context, userID i
 	queryTotal := `
         SELECT COUNT(*) 
         FROM tenders 
-        WHERE user_id = ?;
+        WHERE user_id = ? AND deleted_at IS NULL;
     `
 
 	// Query to count tenders by user ID and status
 	queryStatus := `
         SELECT COUNT(*) 
         FROM tenders 
-        WHERE user_id = ? AND status = ?;
+        WHERE user_id = ? AND status = ? AND deleted_at IS NULL;
     `
 
 	counts := &models.TenderCount{}
@@ -369,7 +407,7 @@ func (r *TenderRepository) GetAllTendersByDateRange(ctx context.Context, startDa
 			FROM tenders t 
 			JOIN users u ON u.id = t.user_id
 			JOIN companies c ON c.id = t.company_id
-			WHERE t.completed_date BETWEEN ? AND ?
+			WHERE t.completed_date BETWEEN ? AND ? AND t.deleted_at IS NULL
 			ORDER BY t.completed_date DESC
 		`
 		rows, err = r.Db.QueryContext(ctx, query, startDate, endDate)
@@ -381,7 +419,7 @@ func (r *TenderRepository) GetAllTendersByDateRange(ctx context.Context, startDa
 			FROM tenders t 
 			JOIN users u ON u.id = t.user_id
 			JOIN companies c ON c.id = t.company_id
-			WHERE t.user_id = ? AND t.completed_date BETWEEN ? AND ?
+			WHERE t.user_id = ? AND t.completed_date BETWEEN ? AND ? AND t.deleted_at IS NULL
 			ORDER BY t.completed_date DESC
 		`
 		rows, err = r.Db.QueryContext(ctx, query, userId, startDate, endDate)
@@ -422,7 +460,7 @@ func (r *TenderRepository) GetAllTendersByDateRangeCompany(ctx context.Context,
 			FROM tenders t 
 			JOIN users u ON u.id = t.user_id
 			JOIN companies c ON c.id = t.company_id
-			WHERE t.company_id = ? AND t.completed_date BETWEEN ? AND ?
+			WHERE t.company_id = ? AND t.completed_date BETWEEN ? AND ? AND t.deleted_at IS NULL
 			ORDER BY t.completed_date DESC
 		`
 		rows, err = r.Db.QueryContext(ctx, query, companyId, startDate, endDate)
@@ -434,7 +472,7 @@ func (r *TenderRepository) GetAllTendersByDateRangeCompany(ctx context.Context,
 			FROM tenders t 
 			JOIN users u ON u.id = t.user_id
 			JOIN companies c ON c.id = t.company_id
-			WHERE t.user_id = ? AND t.company_id = ? AND t.completed_date BETWEEN ? AND ?
+			WHERE t.user_id = ? AND t.company_id = ? AND t.completed_date BETWEEN ? AND ? AND t.deleted_at IS NULL
 			ORDER BY t.completed_date DESC
 		`
 		rows, err = r.Db.QueryContext(ctx, query, userId, companyId, startDate, endDate)
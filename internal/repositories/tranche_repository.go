@@ -17,27 +17,29 @@ func (r *TrancheRepository) CreateTranche(ctx context.Context, tranche *models.T
 		INSERT INTO tranches (transaction_id, amount, description) 
 		VALUES (?, ?, ?)
 	`
-	result, err := r.Db.ExecContext(ctx, query, tranche.TransactionID, tranche.Amount, tranche.Description)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create tranche: %w", err)
-	}
-	id, err := result.LastInsertId()
-	if err != nil {
-		return 0, fmt.Errorf("failed to retrieve last insert ID: %w", err)
-	}
-	return int(id), nil
+	return auditedExec(ctx, r.Db, "tranche", models.AuditCreate, 0, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, query, tranche.TransactionID, tranche.Amount, tranche.Description)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create tranche: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return 0, fmt.Errorf("failed to retrieve last insert ID: %w", err)
+		}
+		return int(id), nil
+	})
 }
 
 // Get a tranche by ID
 func (r *TrancheRepository) GetTrancheByID(ctx context.Context, id int) (*models.Tranche, error) {
 	query := `
-		SELECT id, transaction_id, amount, description, date
+		SELECT id, transaction_id, amount, description, date, version, updated_at
 		FROM tranches
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 	var tranche models.Tranche
 	err := r.Db.QueryRowContext(ctx, query, id).Scan(
-		&tranche.ID, &tranche.TransactionID, &tranche.Amount, &tranche.Description, &tranche.Date)
+		&tranche.ID, &tranche.TransactionID, &tranche.Amount, &tranche.Description, &tranche.Date, &tranche.Version, &tranche.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // No record found
@@ -47,42 +49,50 @@ func (r *TrancheRepository) GetTrancheByID(ctx context.Context, id int) (*models
 	return &tranche, nil
 }
 
-// Update an existing tranche
+// Update an existing tranche. A non-zero tranche.Version must match the stored version.
 func (r *TrancheRepository) UpdateTranche(ctx context.Context, tranche *models.Tranche) (*models.Tranche, error) {
 	// Update the tranche
 	updateQuery := `
 		UPDATE tranches
-		SET transaction_id = ?, amount = ?, description = ?
-		WHERE id = ?
+		SET transaction_id = ?, amount = ?, description = ?, version = version + 1
+		WHERE id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)
 	`
-	_, err := r.Db.ExecContext(ctx, updateQuery, tranche.TransactionID, tranche.Amount, tranche.Description, tranche.ID)
+	var rowsAffected int64
+	_, err := auditedExec(ctx, r.Db, "tranche", models.AuditUpdate, tranche.ID, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, updateQuery, tranche.TransactionID, tranche.Amount, tranche.Description,
+			tranche.ID, tranche.Version, tranche.Version)
+		if err != nil {
+			return 0, err
+		}
+		rowsAffected, err = result.RowsAffected()
+		return tranche.ID, err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to update tranche: %w", err)
 	}
 
 	// Retrieve the updated tranche
-	selectQuery := `
-		SELECT id, transaction_id, amount, description, date
-		FROM tranches
-		WHERE id = ?
-	`
-	var updatedTranche models.Tranche
-	err = r.Db.QueryRowContext(ctx, selectQuery, tranche.ID).Scan(
-		&updatedTranche.ID, &updatedTranche.TransactionID, &updatedTranche.Amount, &updatedTranche.Description, &updatedTranche.Date)
+	updatedTranche, err := r.GetTrancheByID(ctx, tranche.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve updated tranche: %w", err)
 	}
+	if updatedTranche != nil && rowsAffected == 0 {
+		return nil, models.ErrVersionConflict
+	}
 
-	return &updatedTranche, nil
+	return updatedTranche, nil
 }
 
-// Delete a tranche by ID
+// Move a tranche to the trash by ID
 func (r *TrancheRepository) DeleteTranche(ctx context.Context, id int) error {
 	query := `
-		DELETE FROM tranches
-		WHERE id = ?
+		UPDATE tranches SET deleted_at = NOW(), deleted_by = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
-	_, err := r.Db.ExecContext(ctx, query, id)
+	_, err := auditedExec(ctx, r.Db, "tranche", models.AuditDelete, id, func(tx *sql.Tx) (int, error) {
+		_, err := tx.ExecContext(ctx, query, deletedBy(ctx), id)
+		return id, err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to delete tranche: %w", err)
 	}
@@ -93,7 +103,7 @@ func (r *TrancheRepository) GetAllTranchesByTransactionID(ctx context.Context, t
 	query := `
 		SELECT id, transaction_id, amount, description, date
 		FROM tranches
-		WHERE transaction_id = ?
+		WHERE transaction_id = ? AND deleted_at IS NULL
 		ORDER BY date DESC
 	`
 
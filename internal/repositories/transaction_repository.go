@@ -94,6 +94,15 @@ func (r *TransactionRepository) CreateTransaction(ctx context.Context, transacti
 		return models.Transaction{}, err
 	}
 
+	after, err := snapshotTransaction(ctx, tx, transaction.ID)
+	if err == nil {
+		err = recordAudit(ctx, tx, "transaction", transaction.ID, models.AuditCreate, nil, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return models.Transaction{}, err
+	}
+
 	// Commit the transaction
 	if err := tx.Commit(); err != nil {
 		return models.Transaction{}, err
@@ -108,14 +117,15 @@ func (r *TransactionRepository) GetTransactionByID(ctx context.Context, id int)
 
 	// Retrieve the transaction
 	err := r.Db.QueryRowContext(ctx, `
-		SELECT t.id, transaction_number, type, tender_number, user_id, company_id, organization, amount, total, sell, product_name, completed_date, date, t.status, CONCAT(u.name, ' ', u.last_name) as username, c.name
+		SELECT t.id, transaction_number, type, tender_number, user_id, company_id, organization, amount, total, sell, product_name, completed_date, date, t.status, t.margin, t.version, t.updated_at, CONCAT(u.name, ' ', u.last_name) as username, c.name
 		FROM transactions t
 		JOIN users u ON t.user_id = u.id
 		JOIN companies c ON t.company_id = c.id
-		WHERE t.id = ?`, id).Scan(&transaction.ID, &transaction.TransactionNumber, &transaction.Type, &transaction.TenderNumber,
+		WHERE t.id = ? AND t.deleted_at IS NULL`, id).Scan(&transaction.ID, &transaction.TransactionNumber, &transaction.Type, &transaction.TenderNumber,
 		&transaction.UserID, &transaction.CompanyID, &transaction.Organization, &transaction.Amount,
 		&transaction.Total, &transaction.Sell, &transaction.ProductName, &transaction.CompletedDate,
-		&transaction.Date, &transaction.Status, &transaction.UserName, &transaction.CompanyName)
+		&transaction.Date, &transaction.Status, &transaction.Margin, &transaction.Version, &transaction.UpdatedAt,
+		&transaction.UserName, &transaction.CompanyName)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return transaction, models.ErrTransactionNotFound
@@ -151,7 +161,8 @@ func (r *TransactionRepository) GetTransactionByID(ctx context.Context, id int)
 func (r *TransactionRepository) GetAllTransactions(ctx context.Context) ([]models.Transaction, error) {
 	rows, err := r.Db.QueryContext(ctx, `
 		SELECT t.id,t.transaction_number, t.type, t.tender_number, t.user_id, t.company_id, t.organization, t.amount, t.total, t.sell, t.product_name, t.completed_date, t.date, t.status, c.name, u.name
-		FROM transactions t JOIN tender.companies c on c.id = t.company_id JOIN tender.users u on u.id = t.user_id ORDER BY t.date DESC`)
+		FROM transactions t JOIN tender.companies c on c.id = t.company_id JOIN tender.users u on u.id = t.user_id
+		WHERE t.deleted_at IS NULL ORDER BY t.date DESC`)
 	if err != nil {
 		return nil, err
 	}
@@ -201,11 +212,11 @@ func (r *TransactionRepository) GetAllTransactions(ctx context.Context) ([]model
 
 func (r *TransactionRepository) GetTransactionsByUser(ctx context.Context, userID int) ([]models.Transaction, error) {
 	query := `
-		SELECT transactions.*, u.name, c.name
+		SELECT transactions.id, transactions.transaction_number, transactions.type, transactions.tender_number, transactions.user_id, transactions.company_id, transactions.organization, transactions.amount, transactions.total, transactions.sell, transactions.product_name, transactions.completed_date, transactions.date, transactions.status, transactions.margin, u.name, c.name
 		FROM transactions
 		JOIN tender.users u ON u.id = transactions.user_id
 		JOIN tender.companies c ON c.id = transactions.company_id
-		WHERE u.id = ?
+		WHERE u.id = ? AND transactions.deleted_at IS NULL
 		ORDER BY transactions.date DESC
 	`
 
@@ -269,11 +280,11 @@ func (r *TransactionRepository) GetTransactionsByUser(ctx context.Context, userI
 
 func (r *TransactionRepository) GetTransactionsByCompany(ctx context.Context, companyID int) ([]models.Transaction, error) {
 	query := `
-		SELECT transactions.*, u.name AS user_name, u.last_name AS user_last_name, c.name AS company_name
+		SELECT transactions.id, transactions.transaction_number, transactions.type, transactions.tender_number, transactions.user_id, transactions.company_id, transactions.organization, transactions.amount, transactions.total, transactions.sell, transactions.product_name, transactions.completed_date, transactions.date, transactions.status, transactions.margin, u.name AS user_name, u.last_name AS user_last_name, c.name AS company_name
 		FROM transactions
 		JOIN tender.users u ON u.id = transactions.user_id
 		JOIN tender.companies c ON c.id = transactions.company_id
-		WHERE c.id = ?
+		WHERE c.id = ? AND transactions.deleted_at IS NULL
 		ORDER BY transactions.date DESC
 	`
 
@@ -340,7 +351,7 @@ func (r *TransactionRepository) GetTransactionsByCompany(ctx context.Context, co
 		// Calculate the debt (sell - sum of tranches)
 		var totalTranches float64
 		err = r.Db.QueryRowContext(ctx, `
-			SELECT COALESCE(SUM(amount), 0) FROM tranches WHERE transaction_id = ? AND transaction_id IN 
+			SELECT COALESCE(SUM(amount), 0) FROM tranches WHERE transaction_id = ? AND deleted_at IS NULL AND transaction_id IN 
 				(SELECT id FROM transactions WHERE company_id = ? AND status = 2)`, transaction.ID, companyID).Scan(&totalTranches)
 		if err != nil {
 			return nil, err
@@ -355,11 +366,11 @@ func (r *TransactionRepository) GetTransactionsByCompany(ctx context.Context, co
 
 func (r *TransactionRepository) GetTransactionsForUserByCompany(ctx context.Context, userID, companyID int) ([]models.Transaction, error) {
 	query := `
-		SELECT transactions.*, u.name, c.name
+		SELECT transactions.id, transactions.transaction_number, transactions.type, transactions.tender_number, transactions.user_id, transactions.company_id, transactions.organization, transactions.amount, transactions.total, transactions.sell, transactions.product_name, transactions.completed_date, transactions.date, transactions.status, transactions.margin, u.name, c.name
 		FROM transactions
 		JOIN tender.users u ON u.id = transactions.user_id
 		JOIN tender.companies c ON c.id = transactions.company_id
-		WHERE c.id = ? AND u.id = ?
+		WHERE c.id = ? AND u.id = ? AND transactions.deleted_at IS NULL
 		ORDER BY transactions.date DESC
 	`
 
@@ -426,6 +437,7 @@ func (r *TransactionRepository) GetAllTransactionsSum(ctx context.Context) (*mod
         SELECT COALESCE(SUM(sell), 0) AS total_sum
         FROM tender.transactions
         WHERE status = 3
+        AND deleted_at IS NULL
         AND type = 'Закуп';
     `
 
@@ -447,13 +459,13 @@ func (r *TransactionRepository) GetTransactionCountsByUserID(ctx context.Context
 	queryTotal := `
         SELECT COUNT(*) 
         FROM tender.transactions 
-        WHERE user_id = ?;
+        WHERE user_id = ? AND deleted_at IS NULL;
     `
 
 	queryStatus := `
         SELECT COUNT(*) 
         FROM tender.transactions 
-        WHERE user_id = ? AND status = ?;
+        WHERE user_id = ? AND status = ? AND deleted_at IS NULL;
     `
 
 	counts := &models.TransactionCount{}
@@ -480,6 +492,7 @@ func (r *TransactionRepository) GetTransactionsDebtZakup(ctx context.Context, us
         SELECT COALESCE(SUM(total), 0) AS total_sum
         FROM tender.transactions
         WHERE status = 2
+        AND deleted_at IS NULL
         AND user_id = ?
         AND type = 'Закуп';
     `
@@ -502,6 +515,7 @@ func (r *TransactionRepository) GetTransactionsDebt(ctx context.Context, transac
         SELECT total AS total_sum
         FROM tender.transactions
         WHERE status = 2
+        AND deleted_at IS NULL
         AND id = ?
         AND type = 'Закуп';
     `
@@ -520,7 +534,19 @@ func (r *TransactionRepository) GetTransactionsDebt(ctx context.Context, transac
 }
 
 // UpdateTransaction updates an existing transaction and its expenses in the database.
+// Zero-valued fields keep their stored values.
 func (r *TransactionRepository) UpdateTransaction(ctx context.Context, transaction models.Transaction) (models.Transaction, error) {
+	return r.updateTransaction(ctx, transaction, true)
+}
+
+// ReplaceTransaction overwrites every column of an existing transaction, storing zero values as given.
+func (r *TransactionRepository) ReplaceTransaction(ctx context.Context, transaction models.Transaction) (models.Transaction, error) {
+	return r.updateTransaction(ctx, transaction, false)
+}
+
+// updateTransaction writes the transaction and its expenses. When transaction.Version is set,
+// the update only succeeds if the stored version still matches it.
+func (r *TransactionRepository) updateTransaction(ctx context.Context, transaction models.Transaction, preserve bool) (models.Transaction, error) {
 	// Begin a new database transaction
 	tx, err := r.Db.BeginTx(ctx, nil)
 	if err != nil {
@@ -530,12 +556,12 @@ func (r *TransactionRepository) UpdateTransaction(ctx context.Context, transacti
 	// Retrieve existing transaction data to preserve non-updated fields
 	var existingTransaction models.Transaction
 	row := tx.QueryRowContext(ctx, `
-		SELECT transaction_number,type, tender_number, user_id, company_id, organization, amount, total, sell, product_name, completed_date, status,margin
-		FROM transactions WHERE id = ?`, transaction.ID)
+		SELECT transaction_number,type, tender_number, user_id, company_id, organization, amount, total, sell, product_name, completed_date, status,margin, version
+		FROM transactions WHERE id = ? AND deleted_at IS NULL FOR UPDATE`, transaction.ID)
 	err = row.Scan(&existingTransaction.TransactionNumber, &existingTransaction.Type, &existingTransaction.TenderNumber, &existingTransaction.UserID,
 		&existingTransaction.CompanyID, &existingTransaction.Organization, &existingTransaction.Amount,
 		&existingTransaction.Total, &existingTransaction.Sell, &existingTransaction.ProductName,
-		&existingTransaction.CompletedDate, &existingTransaction.Status, &existingTransaction.Margin)
+		&existingTransaction.CompletedDate, &existingTransaction.Status, &existingTransaction.Margin, &existingTransaction.Version)
 	if err == sql.ErrNoRows {
 		fmt.Println("1")
 		tx.Rollback()
@@ -546,48 +572,61 @@ func (r *TransactionRepository) UpdateTransaction(ctx context.Context, transacti
 		return models.Transaction{}, err
 	}
 
-	// Set the values to be updated, preserving existing ones if not provided
-	if transaction.TransactionNumber == nil {
-		transaction.TransactionNumber = existingTransaction.TransactionNumber
-	}
-	if transaction.Type == "" {
-		transaction.Type = existingTransaction.Type
-	}
-	if transaction.TenderNumber == nil {
-		transaction.TenderNumber = existingTransaction.TenderNumber
-	}
-	if transaction.UserID == nil {
-		transaction.UserID = existingTransaction.UserID
-	}
-	if transaction.CompanyID == nil {
-		transaction.CompanyID = existingTransaction.CompanyID
-	}
-	if transaction.Organization == nil {
-		transaction.Organization = existingTransaction.Organization
-	}
-	if transaction.Amount == 0 {
-		transaction.Amount = existingTransaction.Amount
-	}
-	if transaction.Total == 0 {
-		transaction.Total = existingTransaction.Total
-	}
-	if transaction.Sell == 0 {
-		transaction.Sell = existingTransaction.Sell
-	}
-	if transaction.ProductName == "" {
-		transaction.ProductName = existingTransaction.ProductName
+	if transaction.Version != 0 && transaction.Version != existingTransaction.Version {
+		tx.Rollback()
+		return models.Transaction{}, models.ErrVersionConflict
 	}
-	if transaction.CompletedDate == nil {
-		transaction.CompletedDate = existingTransaction.CompletedDate
+
+	before, err := snapshotTransaction(ctx, tx, transaction.ID)
+	if err != nil {
+		tx.Rollback()
+		return models.Transaction{}, err
 	}
-	if transaction.Margin == nil {
-		transaction.Margin = existingTransaction.Margin
+
+	// Set the values to be updated, preserving existing ones if not provided
+	if preserve {
+		if transaction.TransactionNumber == nil {
+			transaction.TransactionNumber = existingTransaction.TransactionNumber
+		}
+		if transaction.Type == "" {
+			transaction.Type = existingTransaction.Type
+		}
+		if transaction.TenderNumber == nil {
+			transaction.TenderNumber = existingTransaction.TenderNumber
+		}
+		if transaction.UserID == nil {
+			transaction.UserID = existingTransaction.UserID
+		}
+		if transaction.CompanyID == nil {
+			transaction.CompanyID = existingTransaction.CompanyID
+		}
+		if transaction.Organization == nil {
+			transaction.Organization = existingTransaction.Organization
+		}
+		if transaction.Amount == 0 {
+			transaction.Amount = existingTransaction.Amount
+		}
+		if transaction.Total == 0 {
+			transaction.Total = existingTransaction.Total
+		}
+		if transaction.Sell == 0 {
+			transaction.Sell = existingTransaction.Sell
+		}
+		if transaction.ProductName == "" {
+			transaction.ProductName = existingTransaction.ProductName
+		}
+		if transaction.CompletedDate == nil {
+			transaction.CompletedDate = existingTransaction.CompletedDate
+		}
+		if transaction.Margin == nil {
+			transaction.Margin = existingTransaction.Margin
+		}
 	}
 
 	// Update the transaction
 	result, err := tx.ExecContext(ctx, `
 		UPDATE transactions SET transaction_number = ?, type = ?, tender_number = ?, user_id = ?, company_id = ?, 
-		organization = ?, amount = ?, total = ?, sell = ?, product_name = ?,  status = ?, completed_date = ?, margin = ? WHERE id = ?`,
+		organization = ?, amount = ?, total = ?, sell = ?, product_name = ?,  status = ?, completed_date = ?, margin = ?, version = version + 1 WHERE id = ?`,
 		transaction.TransactionNumber, transaction.Type, transaction.TenderNumber, transaction.UserID, transaction.CompanyID,
 		transaction.Organization, transaction.Amount, transaction.Total, transaction.Sell,
 		transaction.ProductName, transaction.Status, transaction.CompletedDate, transaction.Margin, transaction.ID)
@@ -630,6 +669,15 @@ func (r *TransactionRepository) UpdateTransaction(ctx context.Context, transacti
 		}
 	}
 
+	after, err := snapshotTransaction(ctx, tx, transaction.ID)
+	if err == nil {
+		err = recordAudit(ctx, tx, "transaction", transaction.ID, models.AuditUpdate, before, after)
+	}
+	if err != nil {
+		tx.Rollback()
+		return models.Transaction{}, err
+	}
+
 	// Commit the transaction
 	if err := tx.Commit(); err != nil {
 		fmt.Println("8")
@@ -638,7 +686,7 @@ func (r *TransactionRepository) UpdateTransaction(ctx context.Context, transacti
 
 	// Retrieve the updated transaction data including the user name, company name, and updated expenses
 	row = r.Db.QueryRowContext(ctx, `
-		SELECT t.id, t.transaction_number, t.type, t.tender_number, t.user_id, t.company_id, t.organization, t.amount, t.total, t.sell, t.product_name, t.completed_date, t.date, t.status, u.name, c.name, t.margin
+		SELECT t.id, t.transaction_number, t.type, t.tender_number, t.user_id, t.company_id, t.organization, t.amount, t.total, t.sell, t.product_name, t.completed_date, t.date, t.status, u.name, c.name, t.margin, t.version, t.updated_at
 		FROM transactions t
 		JOIN users u ON t.user_id = u.id
 		JOIN companies c ON t.company_id = c.id
@@ -646,7 +694,7 @@ func (r *TransactionRepository) UpdateTransaction(ctx context.Context, transacti
 	err = row.Scan(&transaction.ID, &transaction.TransactionNumber, &transaction.Type, &transaction.TenderNumber, &transaction.UserID,
 		&transaction.CompanyID, &transaction.Organization, &transaction.Amount, &transaction.Total, &transaction.Sell,
 		&transaction.ProductName, &transaction.CompletedDate, &transaction.Date, &transaction.Status,
-		&transaction.UserName, &transaction.CompanyName, &transaction.Margin)
+		&transaction.UserName, &transaction.CompanyName, &transaction.Margin, &transaction.Version, &transaction.UpdatedAt)
 	if err != nil {
 		fmt.Println("9")
 		return models.Transaction{}, err
@@ -660,6 +708,7 @@ func (r *TransactionRepository) UpdateTransaction(ctx context.Context, transacti
 	}
 	defer rows.Close()
 
+	transaction.Expenses = nil
 	for rows.Next() {
 		var expense models.Expense
 		err := rows.Scan(&expense.ID, &expense.Name, &expense.Amount, &expense.TransactionID)
@@ -673,830 +722,51 @@ func (r *TransactionRepository) UpdateTransaction(ctx context.Context, transacti
 	return transaction, nil
 }
 
-// DeleteTransaction removes a transaction and its expenses from the database by ID.
+// DeleteTransaction moves a transaction to the trash. Its expenses are kept so it can be restored.
 func (r *TransactionRepository) DeleteTransaction(ctx context.Context, id int) error {
-	// Begin a new database transaction
-	tx, err := r.Db.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-
-	// Delete expenses first due to foreign key constraints
-	_, err = tx.ExecContext(ctx, `DELETE FROM additional_expenses WHERE transaction_id = ?`, id)
-	if err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	// Delete the transaction
-	result, err := tx.ExecContext(ctx, `DELETE FROM transactions WHERE id = ?`, id)
-	if err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	if rowsAffected == 0 {
-		tx.Rollback()
-		return models.ErrTransactionNotFound
-	}
-
-	// Commit the transaction
-	if err := tx.Commit(); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (r *TransactionRepository) GetMonthlyAmountsByGlobal(ctx context.Context) ([]YearlyAmounts, error) {
-	query := `
-		SELECT 
-			YEAR(date) as year, 
-			MONTHNAME(date) as month, 
-			SUM(amount) as total_amount 
-		FROM 
-			transactions 
-		GROUP BY 
-			year, MONTH(date) 
-		ORDER BY 
-			year DESC, MONTH(date) DESC
-	`
-
-	rows, err := r.Db.QueryContext(ctx, query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var yearlyAmounts []YearlyAmounts
-	var currentYear int
-	var yearlyData YearlyAmounts
-	var monthlyData MonthlyAmount
-
-	for rows.Next() {
-		var year int
-		var month string
-		var totalAmount float64
-
-		if err := rows.Scan(&year, &month, &totalAmount); err != nil {
-			return nil, err
-		}
-
-		if currentYear != year {
-			if currentYear != 0 {
-				yearlyAmounts = append(yearlyAmounts, yearlyData)
-			}
-			yearlyData = YearlyAmounts{
-				Year:   year,
-				Months: []MonthlyAmount{},
-			}
-			currentYear = year
-		}
-
-		monthlyData = MonthlyAmount{
-			Month:  month,
-			Amount: totalAmount,
-		}
-		yearlyData.Months = append(yearlyData.Months, monthlyData)
-	}
-
-	if currentYear != 0 {
-		yearlyAmounts = append(yearlyAmounts, yearlyData)
-	}
-
-	return yearlyAmounts, nil
-}
-
-func (r *TransactionRepository) GetMonthlyAmountsByYear(ctx context.Context, year int) ([]MonthlyAmount, error) {
-	query := `
-		SELECT
-			MONTHNAME(date) as month,
-			SUM(amount) as total_amount
-		FROM
-			transactions
-		WHERE
-			YEAR(date) = ?
-		GROUP BY
-			MONTH(date)
-		ORDER BY
-			MONTH(date) DESC
-	`
-
-	rows, err := r.Db.QueryContext(ctx, query, year)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var monthlyAmounts []MonthlyAmount
-
-	for rows.Next() {
-		var month string
-		var totalAmount float64
-
-		if err := rows.Scan(&month, &totalAmount); err != nil {
-			return nil, err
-		}
-
-		monthlyAmount := MonthlyAmount{
-			Month:  month,
-			Amount: totalAmount,
-		}
-		monthlyAmounts = append(monthlyAmounts, monthlyAmount)
-	}
-
-	return monthlyAmounts, nil
-}
-
-func (r *TransactionRepository) GetMonthlyAmountsByCompany(ctx context.Context, companyID int) ([]MonthlyAmount, error) {
-	query := `
-		SELECT
-			MONTHNAME(date) as month,
-			SUM(amount) as total_amount
-		FROM
-			transactions
-		WHERE
-			company_id = ?
-		GROUP BY
-			MONTH(date)
-		ORDER BY
-			MONTH(date) DESC
-	`
-
-	rows, err := r.Db.QueryContext(ctx, query, companyID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var monthlyAmounts []MonthlyAmount
-
-	for rows.Next() {
-		var month string
-		var totalAmount float64
-
-		if err := rows.Scan(&month, &totalAmount); err != nil {
-			return nil, err
-		}
-
-		monthlyAmount := MonthlyAmount{
-			Month:  month,
-			Amount: totalAmount,
-		}
-		monthlyAmounts = append(monthlyAmounts, monthlyAmount)
-	}
-
-	return monthlyAmounts, nil
-}
-
-func (r *TransactionRepository) GetMonthlyAmountsByYearAndCompany(ctx context.Context, year int, companyID int) ([]MonthlyAmount, error) {
-	query := `
-		SELECT
-			MONTHNAME(t.date) as month,
-			SUM(t.amount) as total_amount
-		FROM
-			transactions t
-		JOIN 
-			companies c on c.id = t.company_id
-		WHERE
-			YEAR(t.date) = ? AND c.id = ?
-		GROUP BY
-			MONTH(t.date)
-		ORDER BY
-			MONTH(t.date) DESC
-	`
-
-	rows, err := r.Db.QueryContext(ctx, query, year, companyID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var monthlyAmounts []MonthlyAmount
-
-	for rows.Next() {
-		var month string
-		var totalAmount float64
-
-		if err := rows.Scan(&month, &totalAmount); err != nil {
-			return nil, err
-		}
-
-		monthlyAmount := MonthlyAmount{
-			Month:  month,
-			Amount: totalAmount,
-		}
-		monthlyAmounts = append(monthlyAmounts, monthlyAmount)
-	}
-
-	return monthlyAmounts, nil
-}
-
-func (r *TransactionRepository) GetMonthlyAmountsGroupedByYear(ctx context.Context) ([]YearlyAmounts, error) {
-	query := `
-		SELECT
-			YEAR(date) as year,
-			MONTHNAME(date) as month,
-			SUM(amount) as total_amount
-		FROM
-			transactions
-		GROUP BY
-			year, MONTH(date)
-		ORDER BY
-			year DESC, MONTH(date) DESC
-	`
-
-	rows, err := r.Db.QueryContext(ctx, query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var yearlyAmounts []YearlyAmounts
-	var currentYear int
-	var yearlyData YearlyAmounts
-	var monthlyData MonthlyAmount
-
-	for rows.Next() {
-		var year int
-		var month string
-		var totalAmount float64
-
-		if err := rows.Scan(&year, &month, &totalAmount); err != nil {
-			return nil, err
-		}
-
-		if currentYear != year {
-			if currentYear != 0 {
-				yearlyAmounts = append(yearlyAmounts, yearlyData)
-			}
-			yearlyData = YearlyAmounts{
-				Year:   year,
-				Months: []MonthlyAmount{},
-			}
-			currentYear = year
-		}
-
-		monthlyData = MonthlyAmount{
-			Month:  month,
-			Amount: totalAmount,
-		}
-		yearlyData.Months = append(yearlyData.Months, monthlyData)
-	}
-
-	if currentYear != 0 {
-		yearlyAmounts = append(yearlyAmounts, yearlyData)
-	}
-
-	return yearlyAmounts, nil
-}
-
-func (r *TransactionRepository) GetMonthlyAmountsGroupedByYearForUser(ctx context.Context, userID int) ([]YearlyAmounts, error) {
-	query := `
-		SELECT
-			YEAR(t.date) as year,
-			MONTHNAME(t.date) as month,
-			SUM(t.amount) as total_amount
-		FROM
-			transactions t
-		JOIN 
-			users u on u.id = t.user_id
-		WHERE 
-			u.id = ?
-		GROUP BY
-			year, MONTH(t.date)
-		ORDER BY
-			year DESC, MONTH(t.date) DESC
-	`
-
-	rows, err := r.Db.QueryContext(ctx, query, userID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var yearlyAmounts []YearlyAmounts
-	var currentYear int
-	var yearlyData YearlyAmounts
-	var monthlyData MonthlyAmount
-
-	for rows.Next() {
-		var year int
-		var month string
-		var totalAmount float64
-
-		if err := rows.Scan(&year, &month, &totalAmount); err != nil {
-			return nil, err
-		}
-
-		if currentYear != year {
-			if currentYear != 0 {
-				yearlyAmounts = append(yearlyAmounts, yearlyData)
-			}
-			yearlyData = YearlyAmounts{
-				Year:   year,
-				Months: []MonthlyAmount{},
-			}
-			currentYear = year
-		}
-
-		monthlyData = MonthlyAmount{
-			Month:  month,
-			Amount: totalAmount,
-		}
-		yearlyData.Months = append(yearlyData.Months, monthlyData)
-	}
-
-	if currentYear != 0 {
-		yearlyAmounts = append(yearlyAmounts, yearlyData)
-	}
-
-	return yearlyAmounts, nil
-}
-
-func (r *TransactionRepository) GetMonthlyAmountsForUserByYear(ctx context.Context, userID int, year int) ([]MonthlyAmount, error) {
-	query := `
-		SELECT
-			MONTHNAME(t.date) as month,
-			SUM(t.amount) as total_amount
-		FROM
-			transactions t
-		JOIN 
-			users u on u.id = t.user_id
-		WHERE 
-			u.id = ? AND YEAR(t.date) = ?
-		GROUP BY
-			MONTH(t.date)
-		ORDER BY
-			MONTH(t.date) DESC
-	`
-
-	rows, err := r.Db.QueryContext(ctx, query, userID, year)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var monthlyAmounts []MonthlyAmount
-
-	for rows.Next() {
-		var month string
-		var totalAmount float64
-
-		if err := rows.Scan(&month, &totalAmount); err != nil {
-			return nil, err
-		}
-
-		monthlyAmount := MonthlyAmount{
-			Month:  month,
-			Amount: totalAmount,
-		}
-		monthlyAmounts = append(monthlyAmounts, monthlyAmount)
-	}
-
-	return monthlyAmounts, nil
-}
-
-func (r *TransactionRepository) GetMonthlyAmountsForUserByYearAndCompany(ctx context.Context, userID int, year int, companyID int) ([]MonthlyAmount, error) {
-	query := `
-		SELECT
-			MONTHNAME(t.date) as month,
-			SUM(t.amount) as total_amount
-		FROM
-			transactions t
-		JOIN 
-			users u on u.id = t.user_id
-		JOIN
-			companies c on c.id = t.company_id
-		WHERE 
-			u.id = ? AND YEAR(t.date) = ? AND c.id = ?
-		GROUP BY
-			MONTH(t.date)
-		ORDER BY
-			MONTH(t.date) DESC
-	`
-
-	rows, err := r.Db.QueryContext(ctx, query, userID, year, companyID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var monthlyAmounts []MonthlyAmount
-
-	for rows.Next() {
-		var month string
-		var totalAmount float64
-
-		if err := rows.Scan(&month, &totalAmount); err != nil {
-			return nil, err
-		}
-
-		monthlyAmount := MonthlyAmount{
-			Month:  month,
-			Amount: totalAmount,
-		}
-		monthlyAmounts = append(monthlyAmounts, monthlyAmount)
-	}
-
-	return monthlyAmounts, nil
-}
-
-func (r *TransactionRepository) GetTotalAmountGroupedByCompany(ctx context.Context) ([]CompanyTotalAmount, error) {
-	query := `
-		SELECT
-			c.name,
-			SUM(t.amount) as total_amount
-		FROM
-			transactions t
-		JOIN 
-			companies c on c.id = t.company_id
-		GROUP BY
-			c.id
-		ORDER BY
-			c.id
-	`
-
-	rows, err := r.Db.QueryContext(ctx, query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var totalAmounts []CompanyTotalAmount
-
-	for rows.Next() {
-		var companyName string
-		var totalAmount float64
-
-		if err := rows.Scan(&companyName, &totalAmount); err != nil {
-			return nil, err
-		}
-
-		companyTotal := CompanyTotalAmount{
-			CompanyName: companyName,
-			TotalAmount: totalAmount,
-		}
-		totalAmounts = append(totalAmounts, companyTotal)
-	}
-
-	return totalAmounts, nil
-}
-
-func (r *TransactionRepository) GetTotalAmountByCompanyForYear(ctx context.Context, year int) ([]CompanyTotalAmount, error) {
-	query := `
-		SELECT
-			c.name,
-			SUM(t.amount) as total_amount
-		FROM
-			transactions t
-		JOIN 
-			companies c on c.id = t.company_id
-		WHERE
-			YEAR(t.date) = ?
-		GROUP BY
-			c.id
-		ORDER BY
-			c.id
-	`
-
-	rows, err := r.Db.QueryContext(ctx, query, year)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var totalAmounts []CompanyTotalAmount
-
-	for rows.Next() {
-		var companyName string
-		var totalAmount float64
-
-		if err := rows.Scan(&companyName, &totalAmount); err != nil {
-			return nil, err
-		}
-
-		companyTotal := CompanyTotalAmount{
-			CompanyName: companyName,
-			TotalAmount: totalAmount,
-		}
-		totalAmounts = append(totalAmounts, companyTotal)
-	}
-
-	return totalAmounts, nil
-}
-
-func (r *TransactionRepository) GetTotalAmountByCompanyForMonth(ctx context.Context, month int) ([]CompanyTotalAmount, error) {
-	query := `
-		SELECT
-			c.name,
-			SUM(t.amount) as total_amount
-		FROM
-			transactions t
-		JOIN
-			companies c on c.id = t.company_id
-		WHERE
-			MONTH(t.date) = ?
-		GROUP BY
-			c.id
-		ORDER BY
-			c.id
-	`
-
-	rows, err := r.Db.QueryContext(ctx, query, month)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var totalAmounts []CompanyTotalAmount
-
-	for rows.Next() {
-		var companyName string
-		var totalAmount float64
-
-		if err := rows.Scan(&companyName, &totalAmount); err != nil {
-			return nil, err
-		}
-
-		companyTotal := CompanyTotalAmount{
-			CompanyName: companyName,
-			TotalAmount: totalAmount,
-		}
-		totalAmounts = append(totalAmounts, companyTotal)
-	}
-
-	return totalAmounts, nil
-}
-
-func (r *TransactionRepository) GetTotalAmountByCompanyForYearAndMonth(ctx context.Context, year int, month int) ([]CompanyTotalAmount, error) {
-	query := `
-		SELECT
-			c.name,
-			SUM(t.amount) as total_amount
-		FROM
-			transactions t
-		JOIN 
-			companies c on c.id = t.company_id
-		WHERE
-			YEAR(t.date) = ? AND MONTH(t.date) = ?
-		GROUP BY
-			c.id
-		ORDER BY
-			c.id
-	`
-
-	rows, err := r.Db.QueryContext(ctx, query, year, month)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var totalAmounts []CompanyTotalAmount
-
-	for rows.Next() {
-		var companyName string
-		var totalAmount float64
-
-		if err := rows.Scan(&companyName, &totalAmount); err != nil {
-			return nil, err
-		}
-
-		companyTotal := CompanyTotalAmount{
-			CompanyName: companyName,
-			TotalAmount: totalAmount,
-		}
-		totalAmounts = append(totalAmounts, companyTotal)
-	}
-
-	return totalAmounts, nil
-}
-
-func (r *TransactionRepository) GetTotalAmountGroupedByCompanyForUsers(ctx context.Context) ([]CompanyTotalAmount, error) {
-	query := `
-		SELECT
-			c.name,
-			SUM(t.amount) as total_amount
-		FROM
-			transactions t
-		JOIN 
-			companies c on c.id = t.company_id
-		GROUP BY
-			c.id
-		ORDER BY
-			c.id
-	`
-
-	rows, err := r.Db.QueryContext(ctx, query)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var totalAmounts []CompanyTotalAmount
-
-	for rows.Next() {
-		var companyName string
-		var totalAmount float64
-
-		if err := rows.Scan(&companyName, &totalAmount); err != nil {
-			return nil, err
-		}
-
-		companyTotal := CompanyTotalAmount{
-			CompanyName: companyName,
-			TotalAmount: totalAmount,
-		}
-		totalAmounts = append(totalAmounts, companyTotal)
-	}
-
-	return totalAmounts, nil
-}
-
-func (r *TransactionRepository) GetTotalAmountByCompanyForUser(ctx context.Context, userID int) ([]CompanyTotalAmount, error) {
-	query := `
-		SELECT
-			c.name,
-			SUM(t.amount) as total_amount
-		FROM
-			transactions t
-		JOIN 
-			companies c on c.id = t.company_id
-		JOIN
-			users u on u.id = t.user_id
-		WHERE 
-			u.id = ?
-		GROUP BY
-			c.id
-		ORDER BY
-			c.id
-	`
-
-	rows, err := r.Db.QueryContext(ctx, query, userID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var totalAmounts []CompanyTotalAmount
-
-	for rows.Next() {
-		var companyName string
-		var totalAmount float64
-
-		if err := rows.Scan(&companyName, &totalAmount); err != nil {
-			return nil, err
+	_, err := auditedExec(ctx, r.Db, "transaction", models.AuditDelete, id, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, `
+			UPDATE transactions SET deleted_at = NOW(), deleted_by = ?
+			WHERE id = ? AND deleted_at IS NULL`, deletedBy(ctx), id)
+		if err != nil {
+			return 0, err
 		}
 
-		companyTotal := CompanyTotalAmount{
-			CompanyName: companyName,
-			TotalAmount: totalAmount,
-		}
-		totalAmounts = append(totalAmounts, companyTotal)
-	}
-
-	return totalAmounts, nil
-}
-
-func (r *TransactionRepository) GetTotalAmountByCompanyForUserAndMonth(ctx context.Context, userID int, month int) ([]CompanyTotalAmount, error) {
-	query := `
-		SELECT
-			c.name,
-			SUM(t.amount) as total_amount
-		FROM
-			transactions t
-		JOIN
-			companies c on c.id = t.company_id
-		JOIN
-			users u on u.id = t.user_id
-		WHERE
-			u.id = ? AND MONTH(t.date) = ?
-		GROUP BY
-			c.id
-		ORDER BY
-			c.id
-	`
-
-	rows, err := r.Db.QueryContext(ctx, query, userID, month)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var totalAmounts []CompanyTotalAmount
-
-	for rows.Next() {
-		var companyName string
-		var totalAmount float64
-
-		if err := rows.Scan(&companyName, &totalAmount); err != nil {
-			return nil, err
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
 		}
-
-		companyTotal := CompanyTotalAmount{
-			CompanyName: companyName,
-			TotalAmount: totalAmount,
+		if rowsAffected == 0 {
+			return 0, models.ErrTransactionNotFound
 		}
-		totalAmounts = append(totalAmounts, companyTotal)
-	}
-
-	return totalAmounts, nil
+		return id, nil
+	})
+	return err
 }
 
-func (r *TransactionRepository) GetTotalAmountByCompanyForUserAndYear(ctx context.Context, userID int, year int) ([]CompanyTotalAmount, error) {
-	query := `
-		SELECT
-			c.name,
-			SUM(t.amount) as total_amount
-		FROM
-			transactions t
-		JOIN
-			companies c on c.id = t.company_id
-		JOIN
-			users u on u.id = t.user_id
-		WHERE 
-			u.id = ? AND YEAR(t.date) = ?
-		GROUP BY
-			c.id
-		ORDER BY
-			c.id
-	`
-
-	rows, err := r.Db.QueryContext(ctx, query, userID, year)
-	if err != nil {
-		return nil, err
+// snapshotTransaction extends the audit snapshot of a transaction with its additional expenses.
+func snapshotTransaction(ctx context.Context, tx *sql.Tx, id int) (map[string]interface{}, error) {
+	snapshot, err := snapshotRow(ctx, tx, "transaction", id)
+	if err != nil || snapshot == nil {
+		return snapshot, err
 	}
-	defer rows.Close()
-
-	var totalAmounts []CompanyTotalAmount
-
-	for rows.Next() {
-		var companyName string
-		var totalAmount float64
 
-		if err := rows.Scan(&companyName, &totalAmount); err != nil {
-			return nil, err
-		}
-
-		companyTotal := CompanyTotalAmount{
-			CompanyName: companyName,
-			TotalAmount: totalAmount,
-		}
-		totalAmounts = append(totalAmounts, companyTotal)
-	}
-
-	return totalAmounts, nil
-}
-
-func (r *TransactionRepository) GetTotalAmountByCompanyForUserYearAndMonth(ctx context.Context, userID int, year int, month int) ([]CompanyTotalAmount, error) {
-	query := `
-		SELECT
-			c.name,
-			SUM(t.amount) as total_amount
-		FROM
-			transactions t
-		JOIN 
-			companies c on c.id = t.company_id
-		JOIN
-			users u on u.id = t.user_id
-		WHERE 
-			u.id = ? AND YEAR(t.date) = ? AND MONTH(t.date) = ?
-		GROUP BY
-			c.id
-		ORDER BY
-			c.id
-	`
-
-	rows, err := r.Db.QueryContext(ctx, query, userID, year, month)
+	rows, err := tx.QueryContext(ctx, `SELECT name, amount FROM additional_expenses WHERE transaction_id = ? ORDER BY id`, id)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var totalAmounts []CompanyTotalAmount
-
+	expenses := []interface{}{}
 	for rows.Next() {
-		var companyName string
-		var totalAmount float64
-
-		if err := rows.Scan(&companyName, &totalAmount); err != nil {
+		var name, amount string
+		if err := rows.Scan(&name, &amount); err != nil {
 			return nil, err
 		}
-
-		companyTotal := CompanyTotalAmount{
-			CompanyName: companyName,
-			TotalAmount: totalAmount,
-		}
-		totalAmounts = append(totalAmounts, companyTotal)
+		expenses = append(expenses, map[string]interface{}{"name": name, "amount": amount})
 	}
-
-	return totalAmounts, nil
+	snapshot["expenses"] = expenses
+	return snapshot, rows.Err()
 }
 
 func (r *TransactionRepository) FindAllTransactionsByUserIDAndStatus(ctx context.Context, userID, status int) ([]models.Transaction, error) {
@@ -1507,7 +777,7 @@ func (r *TransactionRepository) FindAllTransactionsByUserIDAndStatus(ctx context
 		FROM transactions t
 		LEFT JOIN users u ON t.user_id = u.id
 		LEFT JOIN companies c ON t.company_id = c.id
-		WHERE t.user_id = ? AND t.status = ?;
+		WHERE t.user_id = ? AND t.status = ? AND t.deleted_at IS NULL;
 	`
 
 	rows, err := r.Db.QueryContext(ctx, query, userID, status)
@@ -1560,7 +830,7 @@ func (r *TransactionRepository) FindAllTendersByUserIDAndStatus(ctx context.Cont
 		FROM tenders t
 		LEFT JOIN users u ON t.user_id = u.id
 		LEFT JOIN companies c ON t.company_id = c.id
-		WHERE t.user_id = ? AND t.status = ?;
+		WHERE t.user_id = ? AND t.status = ? AND t.deleted_at IS NULL;
 	`
 
 	rows, err := r.Db.QueryContext(ctx, query, userID, status)
@@ -1585,7 +855,7 @@ func (r *TransactionRepository) FindAllExtraTransactionsByUserIDAndStatus(ctx co
 		SELECT e.id, e.user_id, e.description, e.total, e.date, e.status, u.name as username
 		FROM extra_transactions e
 		LEFT JOIN users u ON e.user_id = u.id
-		WHERE e.user_id = ? AND e.status = ?;
+		WHERE e.user_id = ? AND e.status = ? AND e.deleted_at IS NULL;
 	`
 
 	rows, err := r.Db.QueryContext(ctx, query, userID, status)
@@ -1615,7 +885,7 @@ func (r *TransactionRepository) GetAllTransactionsByDateRange(ctx context.Contex
 		FROM transactions t 
 		JOIN companies c ON c.id = t.company_id 
 		JOIN users u ON u.id = t.user_id 
-		WHERE t.completed_date BETWEEN ? AND ? 
+		WHERE t.completed_date BETWEEN ? AND ? AND t.deleted_at IS NULL
 		ORDER BY t.completed_date DESC
 	`
 			rows, err := r.Db.QueryContext(ctx, query, startDate, endDate)
@@ -1675,7 +945,7 @@ func (r *TransactionRepository) GetAllTransactionsByDateRange(ctx context.Contex
 		FROM transactions t 
 		JOIN companies c ON c.id = t.company_id 
 		JOIN users u ON u.id = t.user_id 
-		WHERE t.user_id = ? AND t.completed_date BETWEEN ? AND ? 
+		WHERE t.user_id = ? AND t.completed_date BETWEEN ? AND ? AND t.deleted_at IS NULL
 		ORDER BY t.completed_date DESC
 	`
 			rows, err := r.Db.QueryContext(ctx, query, userId, startDate, endDate)
@@ -1742,7 +1012,7 @@ func (r *TransactionRepository) GetAllTransactionsByDateRangeCompany(ctx context
 		FROM transactions t 
 		JOIN companies c ON c.id = t.company_id 
 		JOIN users u ON u.id = t.user_id 
-		WHERE t.company_id = ? AND t.completed_date BETWEEN ? AND ? 
+		WHERE t.company_id = ? AND t.completed_date BETWEEN ? AND ? AND t.deleted_at IS NULL
 		ORDER BY t.completed_date DESC
 	`
 			rows, err := r.Db.QueryContext(ctx, query, companyId, startDate, endDate)
@@ -1802,7 +1072,7 @@ func (r *TransactionRepository) GetAllTransactionsByDateRangeCompany(ctx context
 		FROM transactions t 
 		JOIN companies c ON c.id = t.company_id 
 		JOIN users u ON u.id = t.user_id 
-		WHERE t.user_id = ? AND t.company_id = ? AND t.completed_date BETWEEN ? AND ? 
+		WHERE t.user_id = ? AND t.company_id = ? AND t.completed_date BETWEEN ? AND ? AND t.deleted_at IS NULL
 		ORDER BY t.completed_date DESC
 	`
 			rows, err := r.Db.QueryContext(ctx, query, userId, companyId, startDate, endDate)
@@ -1873,11 +1143,13 @@ func (r *TransactionRepository) GetCompanyDebt(ctx context.Context) ([]DebtResul
 				SUM(amount) AS total_tranche_amount
 			FROM 
 				tranches
+			WHERE 
+				deleted_at IS NULL
 			GROUP BY 
 				transaction_id
 		) tr ON t.id = tr.transaction_id
 		WHERE 
-			t.status = 2
+			t.status = 2 AND t.deleted_at IS NULL
 		GROUP BY 
 			t.company_id
 	`)
@@ -1918,10 +1190,12 @@ FROM
             SUM(amount) AS total_tranche_amount
         FROM
             tranches
+        WHERE
+            deleted_at IS NULL
         GROUP BY
             transaction_id
     ) tr ON t.id = tr.transaction_id
-WHERE transaction_id = ?
+WHERE transaction_id = ? AND t.deleted_at IS NULL
 	`, id)
 	if err != nil {
 		return nil, err
@@ -1955,9 +1229,9 @@ func (r *TransactionRepository) GetCompanyDebtById(ctx context.Context) ([]DebtR
 		FROM
 			transactions t
 				LEFT JOIN
-			tranches tr ON t.id = tr.transaction_id
+			tranches tr ON t.id = tr.transaction_id AND tr.deleted_at IS NULL
 		WHERE
-			t.status = 2
+			t.status = 2 AND t.deleted_at IS NULL
 		GROUP BY
 			t.id
 	`)
@@ -0,0 +1,232 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"tender/internal/models"
+	"tender/internal/requestctx"
+	"time"
+)
+
+// trashEntity describes a table that supports soft deletion.
+type trashEntity struct {
+	name    string
+	audit   string // entity name used in the audit log
+	summary string // SQL expression describing a row in the trash listing
+	amount  string // SQL expression for the row's amount, NULL when it has none
+	// children lists "table.column" references removed together with a purged row.
+	children []string
+	// keep is a condition that holds back a purged row still referenced by live data.
+	keep string
+}
+
+// trashEntities is ordered so that purging never removes a row another purged row depends on.
+var trashEntities = []trashEntity{
+	{name: "tranches", audit: "tranche", summary: "description", amount: "amount"},
+	{name: "changes", audit: "change", summary: "description", amount: "amount"},
+	{name: "debt_tranches", audit: "debt_tranche", summary: "description", amount: "amount"},
+	{name: "extra_transactions", audit: "extra_transaction", summary: "description", amount: "total"},
+	{name: "personal_expenses", audit: "personal_expense", summary: "reason", amount: "amount"},
+	{name: "balance_history", audit: "balance_history", summary: "description", amount: "amount"},
+	{
+		name:     "transactions",
+		audit:    "transaction",
+		summary:  "CONCAT_WS(' ', type, tender_number, product_name)",
+		amount:   "total",
+		children: []string{"additional_expenses.transaction_id", "tranches.transaction_id", "changes.transaction_id", "receivable_alerts.transaction_id"},
+	},
+	{name: "tenders", audit: "tender", summary: "CONCAT_WS(' ', type, tender_number, organization)", amount: "total"},
+	{
+		name:     "personal_debts",
+		audit:    "personal_debt",
+		summary:  "name",
+		amount:   "amount",
+		children: []string{"debt_tranches.debt_id", "personal_debt_reminders.debt_id"},
+	},
+	{
+		name:     "users",
+		audit:    "user",
+		summary:  "CONCAT_WS(' ', name, last_name, email)",
+		amount:   "NULL",
+		children: []string{"permissions.user_id", "notify_tokens.user_id", "notification_outbox.user_id", "notify_history.user_id"},
+		keep: `EXISTS (SELECT 1 FROM transactions WHERE transactions.user_id = users.id)
+			OR EXISTS (SELECT 1 FROM tenders WHERE tenders.user_id = users.id)
+			OR EXISTS (SELECT 1 FROM extra_transactions WHERE extra_transactions.user_id = users.id)
+			OR EXISTS (SELECT 1 FROM balance_history WHERE balance_history.user_id = users.id)`,
+	},
+}
+
+func findTrashEntity(name string) (trashEntity, bool) {
+	for _, entity := range trashEntities {
+		if entity.name == name {
+			return entity, true
+		}
+	}
+	return trashEntity{}, false
+}
+
+// deletedBy returns the caller recorded in deleted_by, or NULL when the request is anonymous.
+func deletedBy(ctx context.Context) interface{} {
+	if userID, ok := requestctx.CallerFrom(ctx); ok {
+		return userID
+	}
+	return nil
+}
+
+type TrashRepository struct {
+	Db *sql.DB
+}
+
+// GetTrash lists soft-deleted records, newest first. An empty entity lists every type.
+func (r *TrashRepository) GetTrash(ctx context.Context, entity string) ([]models.TrashItem, error) {
+	entities := trashEntities
+	if entity != "" {
+		found, ok := findTrashEntity(entity)
+		if !ok {
+			return nil, models.ErrUnknownEntity
+		}
+		entities = []trashEntity{found}
+	}
+
+	selects := make([]string, 0, len(entities))
+	for _, e := range entities {
+		selects = append(selects, fmt.Sprintf(
+			"SELECT '%s' AS entity, id, %s AS summary, %s AS amount, deleted_at, deleted_by FROM %s WHERE deleted_at IS NOT NULL",
+			e.name, e.summary, e.amount, e.name))
+	}
+	query := strings.Join(selects, " UNION ALL ") + " ORDER BY deleted_at DESC"
+
+	rows, err := r.Db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.TrashItem{}
+	for rows.Next() {
+		var item models.TrashItem
+		var amount sql.NullFloat64
+		if err := rows.Scan(&item.Entity, &item.ID, &item.Summary, &amount, &item.DeletedAt, &item.DeletedBy); err != nil {
+			return nil, err
+		}
+		if amount.Valid {
+			item.Amount = &amount.Float64
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// Restore takes a record out of the trash.
+func (r *TrashRepository) Restore(ctx context.Context, entity string, id int) error {
+	e, ok := findTrashEntity(entity)
+	if !ok {
+		return models.ErrUnknownEntity
+	}
+
+	_, err := auditedExec(ctx, r.Db, e.audit, models.AuditRestore, id, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx,
+			"UPDATE "+e.name+" SET deleted_at = NULL, deleted_by = NULL WHERE id = ? AND deleted_at IS NOT NULL", id)
+		if err != nil {
+			return 0, err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if rowsAffected == 0 {
+			return 0, models.ErrNotInTrash
+		}
+		return id, nil
+	})
+	return err
+}
+
+// Purge permanently removes records that were moved to the trash before the given time,
+// together with the rows that reference them.
+func (r *TrashRepository) Purge(ctx context.Context, before time.Time) (map[string]int64, error) {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	purged := make(map[string]int64, len(trashEntities))
+	for _, e := range trashEntities {
+		condition := "deleted_at IS NOT NULL AND deleted_at < ?"
+		if e.keep != "" {
+			condition += " AND NOT (" + e.keep + ")"
+		}
+
+		snapshots, err := r.snapshotPurged(ctx, tx, e, condition, before)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, child := range e.children {
+			table, column, _ := strings.Cut(child, ".")
+			_, err := tx.ExecContext(ctx, fmt.Sprintf(
+				"DELETE FROM %s WHERE %s IN (SELECT id FROM %s WHERE %s)", table, column, e.name, condition), before)
+			if err != nil {
+				return nil, fmt.Errorf("failed to purge %s of %s: %w", table, e.name, err)
+			}
+		}
+
+		result, err := tx.ExecContext(ctx, "DELETE FROM "+e.name+" WHERE "+condition, before)
+		if err != nil {
+			return nil, fmt.Errorf("failed to purge %s: %w", e.name, err)
+		}
+		purged[e.name], err = result.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+
+		for id, snapshot := range snapshots {
+			if err := recordAudit(ctx, tx, e.audit, id, models.AuditPurge, snapshot, nil); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return purged, nil
+}
+
+// snapshotPurged captures the rows about to be purged so their final state is kept in the audit log.
+func (r *TrashRepository) snapshotPurged(ctx context.Context, tx *sql.Tx, e trashEntity, condition string, before time.Time) (map[int]map[string]interface{}, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT id FROM "+e.name+" WHERE "+condition, before)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	snapshots := make(map[int]map[string]interface{}, len(ids))
+	for _, id := range ids {
+		snapshot, err := snapshotRow(ctx, tx, e.audit, id)
+		if err != nil {
+			return nil, err
+		}
+		snapshots[id] = snapshot
+	}
+	return snapshots, nil
+}
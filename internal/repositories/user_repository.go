@@ -24,7 +24,7 @@ var (
 
 func (r *UserRepository) GetAllUsers(ctx context.Context) ([]models.User, error) {
 
-	rows, err := r.Db.QueryContext(ctx, "SELECT id, name, last_name, email, phone, inn, balance, password, status FROM users WHERE id != 1")
+	rows, err := r.Db.QueryContext(ctx, "SELECT id, name, last_name, email, phone, inn, balance, password, status FROM users WHERE id != 1 AND deleted_at IS NULL")
 	if err != nil {
 		return nil, err
 	}
@@ -68,8 +68,15 @@ func (r *UserRepository) SignUp(ctx context.Context, user models.User) (models.U
 		return models.User{}, err
 	}
 
-	result, err := r.Db.ExecContext(ctx, "INSERT INTO users(name, last_name, email, phone, inn, password, balance) VALUES (?, ?, ?, ?, ?, ?, ?)",
-		user.Name, user.LastName, user.Email, user.Phone, user.INN, hashedPassword, 0)
+	userID, err := auditedExec(ctx, r.Db, "user", models.AuditCreate, 0, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, "INSERT INTO users(name, last_name, email, phone, inn, password, balance) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			user.Name, user.LastName, user.Email, user.Phone, user.INN, hashedPassword, 0)
+		if err != nil {
+			return 0, err
+		}
+		id, err := result.LastInsertId()
+		return int(id), err
+	})
 	if err != nil {
 		if mysqlErr, ok := err.(*mysql.MySQLError); ok && mysqlErr.Number == 1062 {
 			return models.User{}, models.ErrDuplicateEmail
@@ -77,11 +84,6 @@ func (r *UserRepository) SignUp(ctx context.Context, user models.User) (models.U
 		return models.User{}, err
 	}
 
-	userID, err := result.LastInsertId()
-	if err != nil {
-		return models.User{}, err
-	}
-
 	user.ID = int(userID)
 	user.Balance = 0
 
@@ -91,7 +93,7 @@ func (r *UserRepository) SignUp(ctx context.Context, user models.User) (models.U
 func (r *UserRepository) LogIn(ctx context.Context, user models.User) (models.User, error) {
 	var storedUser models.User
 
-	query := "SELECT id, name, last_name, email, phone, inn, password, balance,status FROM users WHERE email = ? OR phone = ?"
+	query := "SELECT id, name, last_name, email, phone, inn, password, balance,status FROM users WHERE (email = ? OR phone = ?) AND deleted_at IS NULL"
 	err := r.Db.QueryRowContext(ctx, query, user.Email, user.Phone).Scan(
 		&storedUser.ID,
 		&storedUser.Name,
@@ -128,7 +130,7 @@ func (r *UserRepository) LogIn(ctx context.Context, user models.User) (models.Us
 func (r *UserRepository) GetUserByID(ctx context.Context, id int) (models.User, error) {
 	var user models.User
 
-	query := "SELECT id, name, last_name, email, phone, inn, balance, password, status FROM users WHERE id = ?"
+	query := "SELECT id, name, last_name, email, phone, inn, balance, password, status, version FROM users WHERE id = ? AND deleted_at IS NULL"
 	err := r.Db.QueryRowContext(ctx, query, id).Scan(
 		&user.ID,
 		&user.Name,
@@ -139,6 +141,7 @@ func (r *UserRepository) GetUserByID(ctx context.Context, id int) (models.User,
 		&user.Balance,
 		&user.Password,
 		&user.Status,
+		&user.Version,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -150,22 +153,46 @@ func (r *UserRepository) GetUserByID(ctx context.Context, id int) (models.User,
 	return user, nil
 }
 
+// UpdateBalance credits a user's balance. Money given to any other user is taken from the admin's balance.
 func (r *UserRepository) UpdateBalance(ctx context.Context, id int, amount float64) error {
-	if id != 1 {
-		_, err := r.Db.ExecContext(ctx, "UPDATE users SET balance = balance + ? WHERE id = ?", amount, id)
-		_, err = r.Db.ExecContext(ctx, "UPDATE users SET balance = balance - ? WHERE id = ?", amount, 1)
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
 		return err
-	} else {
-		_, err := r.Db.ExecContext(ctx, "UPDATE users SET balance = balance + ? WHERE id = ?", amount, 1)
+	}
+	defer tx.Rollback()
+
+	if err := adjustBalance(ctx, tx, id, amount); err != nil {
 		return err
 	}
+	if id != models.AdminUserID {
+		if err := adjustBalance(ctx, tx, models.AdminUserID, -amount); err != nil {
+			return err
+		}
+	}
 
+	return tx.Commit()
+}
+
+// adjustBalance adds amount to a user's balance and records the change in the audit log.
+func adjustBalance(ctx context.Context, tx *sql.Tx, id int, amount float64) error {
+	before, err := snapshotRow(ctx, tx, "user", id)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE users SET balance = balance + ? WHERE id = ?", amount, id); err != nil {
+		return err
+	}
+	after, err := snapshotRow(ctx, tx, "user", id)
+	if err != nil {
+		return err
+	}
+	return recordAudit(ctx, tx, "user", id, models.AuditUpdate, before, after)
 }
 
 func (r *UserRepository) GetBalance(ctx context.Context, id int) (float64, error) {
 	var balance float64
 
-	query := "SELECT balance FROM users WHERE id = ?"
+	query := "SELECT balance FROM users WHERE id = ? AND deleted_at IS NULL"
 	err := r.Db.QueryRowContext(ctx, query, id).Scan(&balance)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -177,29 +204,25 @@ func (r *UserRepository) GetBalance(ctx context.Context, id int) (float64, error
 	return balance, nil
 }
 
-// DeleteUserByID removes a user from the database by ID.
+// DeleteUserByID moves a user to the trash by ID. Their transactions keep pointing at them.
 func (r *UserRepository) DeleteUserByID(ctx context.Context, id int) error {
+	_, err := auditedExec(ctx, r.Db, "user", models.AuditDelete, id, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx,
+			"UPDATE users SET deleted_at = NOW(), deleted_by = ? WHERE id = ? AND deleted_at IS NULL", deletedBy(ctx), id)
+		if err != nil {
+			return 0, err
+		}
 
-	_, err := r.Db.ExecContext(ctx, "UPDATE transactions SET user_id = NULL WHERE user_id = ?", id)
-	if err != nil {
-		return err
-	}
-
-	result, err := r.Db.ExecContext(ctx, "DELETE FROM users WHERE id = ?", id)
-	if err != nil {
-		return err
-	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	if rowsAffected == 0 {
-		return models.ErrUserNotFound
-	}
-
-	return nil
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		if rowsAffected == 0 {
+			return 0, models.ErrUserNotFound
+		}
+		return id, nil
+	})
+	return err
 }
 
 func (r *UserRepository) UpdateUser(ctx context.Context, user models.User) (models.User, error) {
@@ -241,37 +264,60 @@ func (r *UserRepository) UpdateUser(ctx context.Context, user models.User) (mode
 
 	}
 
-	// Trim the last comma from the query
-	query = query[:len(query)-1]
-	query += " WHERE id = ?"
-	params = append(params, user.ID)
+	query += " version = version + 1 WHERE id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)"
+	params = append(params, user.ID, user.Version, user.Version)
+
+	return r.execUserUpdate(ctx, user.ID, query, params...)
+}
+
+// ReplaceUser overwrites the profile columns of an existing user, storing zero values as given.
+// The password is expected to be hashed already.
+func (r *UserRepository) ReplaceUser(ctx context.Context, user models.User) (models.User, error) {
+	query := `UPDATE users SET name = ?, last_name = ?, email = ?, phone = ?, inn = ?, balance = ?, password = ?, status = ?,
+		version = version + 1 WHERE id = ? AND deleted_at IS NULL AND (? = 0 OR version = ?)`
 
-	_, err := r.Db.ExecContext(ctx, query, params...)
+	return r.execUserUpdate(ctx, user.ID, query,
+		user.Name, user.LastName, user.Email, user.Phone, user.INN, user.Balance, user.Password, user.Status,
+		user.ID, user.Version, user.Version)
+}
+
+// execUserUpdate runs a versioned update and tells a missing user apart from a stale version.
+func (r *UserRepository) execUserUpdate(ctx context.Context, id int, query string, params ...interface{}) (models.User, error) {
+	var rowsAffected int64
+	_, err := auditedExec(ctx, r.Db, "user", models.AuditUpdate, id, func(tx *sql.Tx) (int, error) {
+		result, err := tx.ExecContext(ctx, query, params...)
+		if err != nil {
+			return 0, err
+		}
+		rowsAffected, err = result.RowsAffected()
+		return id, err
+	})
 	if err != nil {
 		return models.User{}, err
 	}
 
 	// Retrieve the updated user
-	row := r.Db.QueryRowContext(ctx, "SELECT id, name, last_name, email, phone, inn, balance, password, status FROM users WHERE id = ?", user.ID)
-	var updatedUser models.User
-	err = row.Scan(&updatedUser.ID, &updatedUser.Name, &updatedUser.LastName, &updatedUser.Email, &updatedUser.Phone, &updatedUser.INN, &updatedUser.Balance, &updatedUser.Password, &updatedUser.Status)
+	updatedUser, err := r.GetUserByID(ctx, id)
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return models.User{}, models.ErrUserNotFound
-		}
 		return models.User{}, err
 	}
+	if rowsAffected == 0 {
+		return models.User{}, models.ErrVersionConflict
+	}
 
 	return updatedUser, nil
 }
 
 func (r *UserRepository) UpdatePassword(ctx context.Context, userID int, hashedPassword string) error {
-	_, err := r.Db.ExecContext(ctx, "UPDATE users SET password = ? WHERE id = ?", hashedPassword, userID)
+	_, err := auditedExec(ctx, r.Db, "user", models.AuditUpdate, userID, func(tx *sql.Tx) (int, error) {
+		_, err := tx.ExecContext(ctx, "UPDATE users SET password = ? WHERE id = ?", hashedPassword, userID)
+		return userID, err
+	})
 	return err
 }
 
 func (r *UserRepository) FindUserByEmail(ctx context.Context, email string) (int, error) {
-	stmt := `SELECT id FROM users WHERE email = ?`
+	stmt := `SELECT id FROM users WHERE email = ? AND deleted_at IS NULL`
 	var userId int
 	err := r.Db.QueryRowContext(ctx, stmt, email).Scan(&userId)
 	if err != nil {
@@ -295,7 +341,7 @@ func (r *UserRepository) GetUserTransactionDifferences(ctx context.Context) ([]m
         FROM
             users u
                 JOIN
-            extra_transactions et ON u.id = et.user_id
+            extra_transactions et ON u.id = et.user_id AND et.deleted_at IS NULL
         GROUP BY
             u.id, u.name, u.last_name
     `
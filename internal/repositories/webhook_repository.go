@@ -0,0 +1,340 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"tender/internal/models"
+	"time"
+)
+
+// WebhookRepository stores webhooks, the deliveries queued for them and every delivery attempt.
+type WebhookRepository struct {
+	Db *sql.DB
+}
+
+const webhookColumns = `id, url, secret, events, description, active, failure_count, disabled_at, disabled_reason,
+	created_by, created_at, updated_at`
+
+func scanWebhook(row interface{ Scan(...interface{}) error }) (models.Webhook, error) {
+	var w models.Webhook
+	var events []byte
+	err := row.Scan(&w.ID, &w.URL, &w.Secret, &events, &w.Description, &w.Active, &w.FailureCount, &w.DisabledAt,
+		&w.DisabledReason, &w.CreatedBy, &w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		return w, err
+	}
+	return w, json.Unmarshal(events, &w.Events)
+}
+
+func (r *WebhookRepository) Create(ctx context.Context, w models.Webhook) (int, error) {
+	events, err := json.Marshal(w.Events)
+	if err != nil {
+		return 0, err
+	}
+	result, err := r.Db.ExecContext(ctx,
+		"INSERT INTO webhooks (url, secret, events, description, created_by) VALUES (?, ?, ?, ?, ?)",
+		w.URL, w.Secret, events, w.Description, w.CreatedBy)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// Get returns a webhook with its secret.
+func (r *WebhookRepository) Get(ctx context.Context, id int) (models.Webhook, error) {
+	w, err := scanWebhook(r.Db.QueryRowContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return w, models.ErrWebhookNotFound
+	}
+	return w, err
+}
+
+// List returns every webhook, or only the active ones, with their secrets.
+func (r *WebhookRepository) List(ctx context.Context, activeOnly bool) ([]models.Webhook, error) {
+	query := "SELECT " + webhookColumns + " FROM webhooks"
+	if activeOnly {
+		query += " WHERE active"
+	}
+	rows, err := r.Db.QueryContext(ctx, query+" ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, rows.Err()
+}
+
+// Update replaces the endpoint, events and description of a webhook and turns it on or off.
+// Turning a webhook on clears its failures.
+func (r *WebhookRepository) Update(ctx context.Context, w models.Webhook) error {
+	events, err := json.Marshal(w.Events)
+	if err != nil {
+		return err
+	}
+	result, err := r.Db.ExecContext(ctx, `
+		UPDATE webhooks SET url = ?, events = ?, description = ?,
+			failure_count = IF(? AND NOT active, 0, failure_count),
+			disabled_at = IF(?, NULL, COALESCE(disabled_at, CURRENT_TIMESTAMP)),
+			disabled_reason = IF(?, NULL, COALESCE(disabled_reason, 'disabled by an administrator')),
+			active = ?
+		WHERE id = ?`,
+		w.URL, events, w.Description, w.Active, w.Active, w.Active, w.Active, w.ID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err == nil && affected == 0 {
+		_, err = r.Get(ctx, w.ID)
+	}
+	return err
+}
+
+func (r *WebhookRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.Db.ExecContext(ctx, "DELETE FROM webhooks WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err == nil && affected == 0 {
+		return models.ErrWebhookNotFound
+	}
+	return err
+}
+
+// Cursor returns the audit entry webhook events were last queued from. The first call starts
+// from the end of the audit log, so earlier changes are never sent.
+func (r *WebhookRepository) Cursor(ctx context.Context) (int64, error) {
+	var id int64
+	err := r.Db.QueryRowContext(ctx, "SELECT last_audit_id FROM webhook_cursor WHERE id = 1").Scan(&id)
+	if err != sql.ErrNoRows {
+		return id, err
+	}
+	_, err = r.Db.ExecContext(ctx, `
+		INSERT IGNORE INTO webhook_cursor (id, last_audit_id)
+		SELECT 1, COALESCE(MAX(id), 0) FROM audit_log`)
+	if err != nil {
+		return 0, err
+	}
+	err = r.Db.QueryRowContext(ctx, "SELECT last_audit_id FROM webhook_cursor WHERE id = 1").Scan(&id)
+	return id, err
+}
+
+// QueueDeliveries stores deliveries and moves the cursor to lastAuditID in one transaction, and
+// returns how many were stored. A delivery already queued for the same webhook, audit entry and
+// event type is skipped.
+func (r *WebhookRepository) QueueDeliveries(ctx context.Context, deliveries []models.WebhookDelivery, lastAuditID int64) (int, error) {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	queued := 0
+	for _, d := range deliveries {
+		result, err := tx.ExecContext(ctx,
+			"INSERT IGNORE INTO webhook_deliveries (webhook_id, audit_id, event_type, payload) VALUES (?, ?, ?, ?)",
+			d.WebhookID, d.AuditID, d.EventType, string(d.Payload))
+		if err != nil {
+			return 0, err
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return 0, err
+		}
+		queued += int(affected)
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE webhook_cursor SET last_audit_id = ? WHERE id = 1 AND last_audit_id < ?",
+		lastAuditID, lastAuditID)
+	if err != nil {
+		return 0, err
+	}
+	return queued, tx.Commit()
+}
+
+// CreateDelivery stores a single delivery outside the audit log, such as a ping.
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, d models.WebhookDelivery) (int64, error) {
+	result, err := r.Db.ExecContext(ctx,
+		"INSERT INTO webhook_deliveries (webhook_id, event_type, payload) VALUES (?, ?, ?)",
+		d.WebhookID, d.EventType, string(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+const webhookDeliveryColumns = `id, webhook_id, audit_id, event_type, payload, status, attempts, next_attempt_at,
+	response_status, last_error, created_at, delivered_at`
+
+func (r *WebhookRepository) queryDeliveries(ctx context.Context, query string, params ...interface{}) ([]models.WebhookDelivery, error) {
+	rows, err := r.Db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var payload []byte
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.AuditID, &d.EventType, &payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.ResponseStatus, &d.LastError, &d.CreatedAt, &d.DeliveredAt); err != nil {
+			return nil, err
+		}
+		d.Payload = payload
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// DueDeliveries returns pending deliveries of active webhooks whose next attempt is due, oldest
+// first. Deliveries of a disabled webhook wait until it is turned on again.
+func (r *WebhookRepository) DueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	return r.queryDeliveries(ctx, `
+		SELECT d.`+webhookDeliveryColumns+`
+		FROM webhook_deliveries d
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.status = ? AND d.next_attempt_at <= ? AND w.active
+		ORDER BY d.id
+		LIMIT ?`, models.OutboxPending, now, limit)
+}
+
+// ClaimDelivery counts an attempt at a delivery and holds it until lease, so that no other worker
+// picks it up meanwhile. It reports false when another worker got there first.
+func (r *WebhookRepository) ClaimDelivery(ctx context.Context, id int64, attempts int, lease time.Time) (bool, error) {
+	result, err := r.Db.ExecContext(ctx, `
+		UPDATE webhook_deliveries SET attempts = attempts + 1, next_attempt_at = ?
+		WHERE id = ? AND status = ? AND attempts = ?`,
+		lease, id, models.OutboxPending, attempts)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected == 1, err
+}
+
+// RecordAttempt logs an HTTP request made for a delivery.
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, a models.WebhookAttempt) error {
+	_, err := r.Db.ExecContext(ctx, `
+		INSERT INTO webhook_attempts (delivery_id, response_status, response_body, error, duration_ms)
+		VALUES (?, ?, ?, ?, ?)`,
+		a.DeliveryID, a.ResponseStatus, a.ResponseBody, a.Error, a.DurationMs)
+	return err
+}
+
+// MarkDelivered completes a delivery and clears the failures of its webhook.
+func (r *WebhookRepository) MarkDelivered(ctx context.Context, d models.WebhookDelivery, status int, now time.Time) error {
+	tx, err := r.Db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE webhook_deliveries SET status = ?, response_status = ?, last_error = NULL, delivered_at = ?
+		WHERE id = ?`, models.OutboxSent, status, now, d.ID)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE webhooks SET failure_count = 0 WHERE id = ?", d.WebhookID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MarkRetry schedules another attempt at next.
+func (r *WebhookRepository) MarkRetry(ctx context.Context, id int64, next time.Time, status *int, reason string) error {
+	_, err := r.Db.ExecContext(ctx,
+		"UPDATE webhook_deliveries SET next_attempt_at = ?, response_status = ?, last_error = ? WHERE id = ?",
+		next, status, reason, id)
+	return err
+}
+
+func (r *WebhookRepository) MarkFailed(ctx context.Context, id int64, status *int, reason string) error {
+	_, err := r.Db.ExecContext(ctx,
+		"UPDATE webhook_deliveries SET status = ?, response_status = ?, last_error = ? WHERE id = ?",
+		models.OutboxFailed, status, reason, id)
+	return err
+}
+
+// RecordFailure counts a failed attempt against a webhook and disables it once maxFailures
+// attempts in a row have failed. It reports whether the webhook was disabled.
+func (r *WebhookRepository) RecordFailure(ctx context.Context, webhookID, maxFailures int, reason string) (bool, error) {
+	_, err := r.Db.ExecContext(ctx, "UPDATE webhooks SET failure_count = failure_count + 1 WHERE id = ?", webhookID)
+	if err != nil {
+		return false, err
+	}
+	result, err := r.Db.ExecContext(ctx, `
+		UPDATE webhooks SET active = FALSE, disabled_at = CURRENT_TIMESTAMP, disabled_reason = ?
+		WHERE id = ? AND active AND failure_count >= ?`, reason, webhookID, maxFailures)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// GetDelivery returns a delivery of a webhook with the log of its attempts.
+func (r *WebhookRepository) GetDelivery(ctx context.Context, webhookID int, id int64) (models.WebhookDelivery, error) {
+	deliveries, err := r.queryDeliveries(ctx,
+		"SELECT "+webhookDeliveryColumns+" FROM webhook_deliveries WHERE id = ? AND webhook_id = ?", id, webhookID)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	if len(deliveries) == 0 {
+		return models.WebhookDelivery{}, models.ErrWebhookDeliveryNotFound
+	}
+	d := deliveries[0]
+
+	rows, err := r.Db.QueryContext(ctx, `
+		SELECT id, delivery_id, response_status, response_body, error, duration_ms, created_at
+		FROM webhook_attempts
+		WHERE delivery_id = ?
+		ORDER BY id`, id)
+	if err != nil {
+		return d, err
+	}
+	defer rows.Close()
+
+	d.AttemptLog = []models.WebhookAttempt{}
+	for rows.Next() {
+		var a models.WebhookAttempt
+		if err := rows.Scan(&a.ID, &a.DeliveryID, &a.ResponseStatus, &a.ResponseBody, &a.Error, &a.DurationMs,
+			&a.CreatedAt); err != nil {
+			return d, err
+		}
+		d.AttemptLog = append(d.AttemptLog, a)
+	}
+	return d, rows.Err()
+}
+
+// ListDeliveries returns a page of a webhook's deliveries, newest first, without their payloads.
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, f models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error) {
+	query := "SELECT " + webhookDeliveryColumns + " FROM webhook_deliveries WHERE webhook_id = ?"
+	params := []interface{}{f.WebhookID}
+	if f.Status != "" {
+		query += " AND status = ?"
+		params = append(params, f.Status)
+	}
+	if f.BeforeID != 0 {
+		query += " AND id < ?"
+		params = append(params, f.BeforeID)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	params = append(params, f.Limit)
+
+	deliveries, err := r.queryDeliveries(ctx, query, params...)
+	for i := range deliveries {
+		deliveries[i].Payload = nil
+	}
+	return deliveries, err
+}
@@ -0,0 +1,53 @@
+// Package requestctx carries per-request metadata, such as the calling user, through a context.
+package requestctx
+
+import "context"
+
+type contextKey int
+
+const (
+	callerKey contextKey = iota
+	requestKey
+	authenticatedKey
+)
+
+// WithCaller returns a copy of ctx that carries the ID of the user making the request.
+func WithCaller(ctx context.Context, userID int) context.Context {
+	return context.WithValue(ctx, callerKey, userID)
+}
+
+// CallerFrom returns the ID of the user making the request and whether one was supplied.
+func CallerFrom(ctx context.Context) (int, bool) {
+	userID, ok := ctx.Value(callerKey).(int)
+	return userID, ok
+}
+
+// WithAuthenticatedCaller is WithCaller for a user whose identity was verified, e.g. by a
+// session token.
+func WithAuthenticatedCaller(ctx context.Context, userID int) context.Context {
+	return context.WithValue(WithCaller(ctx, userID), authenticatedKey, userID)
+}
+
+// AuthenticatedCallerFrom returns the ID of the user making the request when their identity was
+// verified.
+func AuthenticatedCallerFrom(ctx context.Context) (int, bool) {
+	userID, ok := ctx.Value(authenticatedKey).(int)
+	return userID, ok
+}
+
+// RequestInfo identifies the HTTP request a change was made in.
+type RequestInfo struct {
+	ID string
+	IP string
+}
+
+// WithRequestInfo returns a copy of ctx that carries the request ID and client IP.
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestKey, info)
+}
+
+// RequestInfoFrom returns the request ID and client IP stored in ctx, if any.
+func RequestInfoFrom(ctx context.Context) (RequestInfo, bool) {
+	info, ok := ctx.Value(requestKey).(RequestInfo)
+	return info, ok
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"tender/internal/models"
+	"tender/internal/repositories"
+	"time"
+)
+
+type AggregateService struct {
+	Repo *repositories.AggregateRepository
+}
+
+// RefreshQueued brings the months changed since the last refresh up to date.
+func (s *AggregateService) RefreshQueued(ctx context.Context) (int, error) {
+	return s.Repo.RefreshQueued(ctx)
+}
+
+// Rebuild recomputes the report aggregates from scratch.
+func (s *AggregateService) Rebuild(ctx context.Context) error {
+	return s.Repo.Rebuild(ctx)
+}
+
+// Check compares the report aggregates with live data. Mismatches in months still waiting
+// for a refresh are reported as pending and do not make the aggregates inconsistent.
+func (s *AggregateService) Check(ctx context.Context) (models.AggregateCheck, error) {
+	mismatches, err := s.Repo.Check(ctx)
+	if err != nil {
+		return models.AggregateCheck{}, err
+	}
+	check := models.AggregateCheck{CheckedAt: time.Now().Format(time.RFC3339), Consistent: true, Mismatches: mismatches}
+	for _, mismatch := range mismatches {
+		if !mismatch.Pending {
+			check.Consistent = false
+			break
+		}
+	}
+	return check, nil
+}
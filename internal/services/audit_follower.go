@@ -0,0 +1,58 @@
+package services
+
+import (
+	"tender/internal/models"
+	"time"
+)
+
+// auditGapTimeout is how long an audit entry missing from the log is waited for. Entries are
+// written inside the transactions they record, so one can commit after an entry with a higher ID;
+// an ID still missing after this long belongs to a transaction that was rolled back.
+const auditGapTimeout = 2 * time.Minute
+
+// auditMaxGap bounds the IDs remembered for one jump; a longer run of missing IDs is not the
+// work of transactions in flight.
+const auditMaxGap = 1000
+
+// auditFollower reads the audit log in ID order without losing entries committed out of order.
+// IDs skipped over are remembered as gaps and read again until they turn up or time out.
+type auditFollower struct {
+	lastID int64               // newest entry read
+	gaps   map[int64]time.Time // missing IDs below lastID, with when they were noticed
+}
+
+// from returns the ID to read entries after: below every gap still waited for, or lastID.
+func (f *auditFollower) from(now time.Time) int64 {
+	from := f.lastID
+	for id, noticed := range f.gaps {
+		if now.Sub(noticed) > auditGapTimeout {
+			delete(f.gaps, id)
+		} else if id-1 < from {
+			from = id - 1
+		}
+	}
+	return from
+}
+
+// fresh returns the entries of changes not read before, in order, and notes the IDs they skip.
+func (f *auditFollower) fresh(changes []models.AuditChange, now time.Time) []models.AuditChange {
+	if f.gaps == nil {
+		f.gaps = map[int64]time.Time{}
+	}
+	var fresh []models.AuditChange
+	for _, c := range changes {
+		switch {
+		case c.ID > f.lastID:
+			for id := max(f.lastID+1, c.ID-auditMaxGap); id < c.ID; id++ {
+				f.gaps[id] = now
+			}
+			f.lastID = c.ID
+		case !f.gaps[c.ID].IsZero():
+			delete(f.gaps, c.ID)
+		default:
+			continue
+		}
+		fresh = append(fresh, c)
+	}
+	return fresh
+}
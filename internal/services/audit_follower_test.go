@@ -0,0 +1,38 @@
+package services
+
+import (
+	"tender/internal/models"
+	"testing"
+	"time"
+)
+
+func auditChanges(ids ...int64) []models.AuditChange {
+	changes := make([]models.AuditChange, len(ids))
+	for i, id := range ids {
+		changes[i].ID = id
+	}
+	return changes
+}
+
+func TestAuditFollowerWaitsForGaps(t *testing.T) {
+	now := time.Now()
+	f := &auditFollower{lastID: 10}
+
+	if fresh := f.fresh(auditChanges(11, 14), now); len(fresh) != 2 {
+		t.Fatalf("read %d entries, want 2", len(fresh))
+	}
+	if from := f.from(now); from != 11 {
+		t.Fatalf("reads after %d, want 11 below the gap", from)
+	}
+
+	fresh := f.fresh(auditChanges(13, 14), now)
+	if len(fresh) != 1 || fresh[0].ID != 13 {
+		t.Fatalf("read %v, want only entry 13", fresh)
+	}
+	if from := f.from(now); from != 11 {
+		t.Fatalf("reads after %d, want 11 while 12 is missing", from)
+	}
+	if from := f.from(now.Add(auditGapTimeout + time.Second)); from != 14 {
+		t.Errorf("reads after %d once the gap timed out, want 14", from)
+	}
+}
@@ -0,0 +1,19 @@
+package services
+
+import (
+	"context"
+	"tender/internal/models"
+	"tender/internal/repositories"
+)
+
+type AuditService struct {
+	Repo *repositories.AuditRepository
+}
+
+// GetAuditLog returns the audit entries matching the filter, newest first.
+func (s *AuditService) GetAuditLog(ctx context.Context, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+	return s.Repo.GetAuditLog(ctx, filter)
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"tender/internal/models"
+	"time"
+)
+
+// topDebtorCount and recentActivityCount bound the lists on the dashboard.
+const (
+	topDebtorCount      = 5
+	recentActivityCount = 10
+)
+
+type DashboardService struct {
+	Reports *ReportService
+	History *HistoryService
+	Users   *UserService
+	// CacheTTL is how long a computed dashboard is served before it is rebuilt.
+	CacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[int]cachedDashboard
+}
+
+type cachedDashboard struct {
+	dashboard models.Dashboard
+	expires   time.Time
+}
+
+// GetDashboard returns the dashboard of a user; the admin's covers every user.
+func (s *DashboardService) GetDashboard(ctx context.Context, userID int) (models.Dashboard, error) {
+	s.mu.Lock()
+	if cached, ok := s.cache[userID]; ok && time.Now().Before(cached.expires) {
+		s.mu.Unlock()
+		return cached.dashboard, nil
+	}
+	s.mu.Unlock()
+
+	dashboard, err := s.build(ctx, userID)
+	if err != nil {
+		return models.Dashboard{}, err
+	}
+
+	s.mu.Lock()
+	if s.cache == nil {
+		s.cache = map[int]cachedDashboard{}
+	}
+	for id, cached := range s.cache {
+		if time.Now().After(cached.expires) {
+			delete(s.cache, id)
+		}
+	}
+	s.cache[userID] = cachedDashboard{dashboard: dashboard, expires: time.Now().Add(s.CacheTTL)}
+	s.mu.Unlock()
+
+	return dashboard, nil
+}
+
+// build computes every part of the dashboard concurrently.
+func (s *DashboardService) build(ctx context.Context, userID int) (models.Dashboard, error) {
+	dashboard := models.Dashboard{GeneratedAt: time.Now().Format(time.RFC3339)}
+	var scope *int
+	if userID != models.AdminUserID {
+		scope = &userID
+		dashboard.UserID = scope
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	run := func(part func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := part(); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}()
+	}
+
+	run(func() error {
+		report, err := s.Reports.Query(ctx, models.ReportQuery{
+			Dimensions: []string{"status"},
+			Measures:   []string{"count"},
+			Filters:    models.ReportFilters{UserID: scope},
+		})
+		if err != nil {
+			return err
+		}
+		dashboard.DealsByStatus = make([]models.StatusCount, 0, len(report.Rows))
+		for _, row := range report.Rows {
+			status, _ := row["status"].(float64)
+			count, _ := row["count"].(float64)
+			dashboard.DealsByStatus = append(dashboard.DealsByStatus, models.StatusCount{Status: int(status), Count: int(count)})
+		}
+		return nil
+	})
+
+	run(func() error {
+		now := time.Now()
+		thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		lastMonth := thisMonth.AddDate(0, -1, 0)
+		revenue := func(month time.Time) (float64, error) {
+			year, m := month.Year(), int(month.Month())
+			report, err := s.Reports.Query(ctx, models.ReportQuery{
+				Measures: []string{"sell"},
+				Filters:  models.ReportFilters{UserID: scope, Year: &year, Month: &m},
+			})
+			if err != nil || len(report.Rows) == 0 {
+				return 0, err
+			}
+			sell, _ := report.Rows[0]["sell"].(float64)
+			return sell, nil
+		}
+
+		var err error
+		if dashboard.Revenue.ThisMonth, err = revenue(thisMonth); err != nil {
+			return err
+		}
+		if dashboard.Revenue.LastMonth, err = revenue(lastMonth); err != nil {
+			return err
+		}
+		dashboard.Revenue.ChangePct = percentChange(dashboard.Revenue.LastMonth, dashboard.Revenue.ThisMonth)
+		return nil
+	})
+
+	run(func() error {
+		aging, err := s.Reports.Aging(ctx, scope)
+		if err != nil {
+			return err
+		}
+		dashboard.Receivables = aging.Totals.Total
+		dashboard.TopDebtors = make([]models.Debtor, 0, len(aging.Companies))
+		for _, company := range aging.Companies {
+			dashboard.TopDebtors = append(dashboard.TopDebtors, models.Debtor{
+				CompanyID:   company.CompanyID,
+				CompanyName: company.CompanyName,
+				Outstanding: company.Buckets.Total,
+			})
+		}
+		sort.Slice(dashboard.TopDebtors, func(i, j int) bool {
+			return dashboard.TopDebtors[i].Outstanding > dashboard.TopDebtors[j].Outstanding
+		})
+		if len(dashboard.TopDebtors) > topDebtorCount {
+			dashboard.TopDebtors = dashboard.TopDebtors[:topDebtorCount]
+		}
+		return nil
+	})
+
+	run(func() error {
+		balance, err := s.Users.GetBalance(ctx, userID)
+		dashboard.Balance = balance
+		return err
+	})
+
+	run(func() error {
+		recent, err := s.History.GetAllHistory(ctx, models.HistoryRequest{UserID: scope, Limit: recentActivityCount})
+		dashboard.RecentActivity = recent
+		return err
+	})
+
+	wg.Wait()
+	if firstErr != nil {
+		return models.Dashboard{}, firstErr
+	}
+	return dashboard, nil
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"tender/internal/documents"
+	"tender/internal/models"
+	"tender/internal/repositories"
+	"time"
+)
+
+type DocumentService struct {
+	Repo         *repositories.DocumentRepository
+	Transactions *repositories.TransactionRepository
+	Tranches     *repositories.TrancheRepository
+	// Organization is our side of the documents, as printed on them.
+	Organization string
+}
+
+// ReconciliationPDF renders the reconciliation act with a company for a period of YYYY-MM-DD
+// dates, by default from the start of the year to today.
+func (s *DocumentService) ReconciliationPDF(ctx context.Context, companyID int, startDate, endDate string) ([]byte, models.ReconciliationAct, error) {
+	now := time.Now()
+	if startDate == "" {
+		startDate = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.UTC).Format("2006-01-02")
+	}
+	if endDate == "" {
+		endDate = now.Format("2006-01-02")
+	}
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return nil, models.ReconciliationAct{}, fmt.Errorf("%w: start_date must be YYYY-MM-DD", models.ErrInvalidReport)
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		return nil, models.ReconciliationAct{}, fmt.Errorf("%w: end_date must be YYYY-MM-DD", models.ErrInvalidReport)
+	}
+	if end.Before(start) {
+		return nil, models.ReconciliationAct{}, fmt.Errorf("%w: end_date is before start_date", models.ErrInvalidReport)
+	}
+
+	act, err := s.Repo.GetReconciliation(ctx, companyID, start, end)
+	if err != nil {
+		return nil, act, err
+	}
+	act.StartDate, act.EndDate = startDate, endDate
+	for _, entry := range act.Entries {
+		act.Sales += entry.Debit
+		act.Payments += entry.Credit
+	}
+	act.ClosingBalance = act.OpeningBalance + act.Sales - act.Payments
+
+	var buf bytes.Buffer
+	if err := documents.Reconciliation(&buf, act, s.Organization); err != nil {
+		return nil, act, err
+	}
+	return buf.Bytes(), act, nil
+}
+
+// StatementPDF renders a transaction with its additional expenses and the tranches paid.
+func (s *DocumentService) StatementPDF(ctx context.Context, transactionID int) ([]byte, models.TransactionStatement, error) {
+	transaction, err := s.Transactions.GetTransactionByID(ctx, transactionID)
+	if err != nil {
+		return nil, models.TransactionStatement{}, err
+	}
+	payments, err := s.Tranches.GetAllTranchesByTransactionID(ctx, transactionID)
+	if err != nil {
+		return nil, models.TransactionStatement{}, err
+	}
+
+	statement := models.TransactionStatement{Transaction: transaction, Payments: payments}
+	// Tranches come newest first; a statement reads in date order.
+	for i, j := 0, len(payments)-1; i < j; i, j = i+1, j-1 {
+		payments[i], payments[j] = payments[j], payments[i]
+	}
+	for _, expense := range transaction.Expenses {
+		statement.ExpensesTotal += expense.Amount
+	}
+	for _, payment := range payments {
+		statement.Paid += float64(payment.Amount)
+	}
+	statement.Outstanding = transaction.Sell - statement.Paid
+
+	var buf bytes.Buffer
+	if err := documents.Statement(&buf, statement, s.Organization, time.Now()); err != nil {
+		return nil, statement, err
+	}
+	return buf.Bytes(), statement, nil
+}
@@ -0,0 +1,22 @@
+package services
+
+import (
+	"context"
+	"tender/internal/export"
+	"tender/internal/models"
+	"tender/internal/repositories"
+)
+
+type ExportService struct {
+	Repo *repositories.ExportRepository
+}
+
+// Columns returns the columns of a list export.
+func (s *ExportService) Columns(list string) ([]export.Column, error) {
+	return s.Repo.ExportColumns(list)
+}
+
+// Stream calls fn with every row of a list export without loading the list into memory.
+func (s *ExportService) Stream(ctx context.Context, list string, filter models.ExportFilter, fn func(values []interface{}) error) error {
+	return s.Repo.StreamExport(ctx, list, filter, fn)
+}
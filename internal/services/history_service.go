@@ -10,7 +10,41 @@ type HistoryService struct {
 	Repo *repositories.HistoryRepository
 }
 
-// GetAllHistory retrieves combined history data from the repository.
-func (s *HistoryService) GetAllHistory(ctx context.Context, source *string, startDate, endDate string, limit, offset int) ([]models.CombinedAction, error) {
-	return s.Repo.GetAllHistory(ctx, source, startDate, endDate, limit, offset)
+// GetAllHistory retrieves the page of combined history data at req.Offset. Offsets count from 1,
+// as older clients send them; 0 is the first page too.
+func (s *HistoryService) GetAllHistory(ctx context.Context, req models.HistoryRequest) ([]models.CombinedAction, error) {
+	req = withHistoryDefaults(req)
+	offset := 0
+	if req.Offset > 0 {
+		offset = req.Offset - 1
+	}
+	return s.Repo.GetAllHistory(ctx, req, offset)
+}
+
+// GetHistoryPage retrieves the page of combined history data that follows req.Cursor.
+func (s *HistoryService) GetHistoryPage(ctx context.Context, req models.HistoryRequest) (models.HistoryPage, error) {
+	return s.Repo.GetHistoryPage(ctx, withHistoryDefaults(req))
+}
+
+// StreamHistory calls fn with every item matching the request's filters, for exports.
+func (s *HistoryService) StreamHistory(ctx context.Context, req models.HistoryRequest, fn func(models.CombinedAction) error) error {
+	return s.Repo.StreamHistory(ctx, withSingleSource(req), fn)
+}
+
+func withSingleSource(req models.HistoryRequest) models.HistoryRequest {
+	if req.Source != nil && *req.Source != "" {
+		req.Sources = append(req.Sources, *req.Source)
+	}
+	return req
+}
+
+func withHistoryDefaults(req models.HistoryRequest) models.HistoryRequest {
+	req = withSingleSource(req)
+	if req.Limit <= 0 {
+		req.Limit = 50
+	}
+	if req.Limit > 500 {
+		req.Limit = 500
+	}
+	return req
 }
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"tender/internal/models"
+	"tender/internal/repositories"
+	"time"
+)
+
+type IdempotencyService struct {
+	Repo *repositories.IdempotencyRepository
+	TTL  time.Duration
+}
+
+// Begin reserves the caller's key for a request. It returns nil when the request should be
+// processed, or the stored record when an identical request already completed.
+func (s *IdempotencyService) Begin(ctx context.Context, userID int, key, method, path string, body []byte) (*models.IdempotencyRecord, error) {
+	record := models.IdempotencyRecord{
+		Key:         key,
+		UserID:      userID,
+		Method:      method,
+		Path:        path,
+		RequestHash: requestHash(method, path, body),
+		ExpiresAt:   time.Now().Add(s.TTL),
+	}
+
+	// A second attempt covers a record that expired or was released between the insert and the lookup.
+	for attempt := 0; attempt < 2; attempt++ {
+		reserved, err := s.Repo.Reserve(ctx, record)
+		if err != nil {
+			return nil, err
+		}
+		if reserved {
+			return nil, nil
+		}
+
+		existing, err := s.Repo.GetByKey(ctx, userID, key)
+		if errors.Is(err, models.ErrNoRecord) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if existing.ExpiresAt.Before(time.Now()) {
+			if err := s.Repo.Delete(ctx, userID, key); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if existing.RequestHash != record.RequestHash {
+			return nil, models.ErrIdempotencyKeyReused
+		}
+		if existing.StatusCode == 0 {
+			return nil, models.ErrIdempotencyKeyInFlight
+		}
+		return &existing, nil
+	}
+
+	return nil, models.ErrIdempotencyKeyInFlight
+}
+
+// Complete stores the response of a reserved request so retries can replay it.
+func (s *IdempotencyService) Complete(ctx context.Context, userID int, key string, statusCode int, contentType string, body []byte) error {
+	return s.Repo.Complete(ctx, userID, key, statusCode, contentType, body)
+}
+
+// Release drops a reservation whose request failed, allowing the client to retry it.
+func (s *IdempotencyService) Release(ctx context.Context, userID int, key string) error {
+	return s.Repo.Delete(ctx, userID, key)
+}
+
+// PurgeExpired removes keys whose retention period has passed.
+func (s *IdempotencyService) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.Repo.DeleteExpired(ctx, time.Now())
+}
+
+func requestHash(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"tender/internal/models"
+)
+
+// applyMergePatch applies an RFC 7396 JSON merge patch to target, which must be a pointer.
+// Members set to null in the patch are reset to their zero value, objects are merged
+// recursively and every other value replaces the current one.
+func applyMergePatch(target interface{}, patch []byte) error {
+	var patchDoc interface{}
+	if err := json.Unmarshal(patch, &patchDoc); err != nil {
+		return models.ErrInvalidPatch
+	}
+	if _, ok := patchDoc.(map[string]interface{}); !ok {
+		return models.ErrInvalidPatch
+	}
+
+	current, err := json.Marshal(target)
+	if err != nil {
+		return err
+	}
+	var currentDoc interface{}
+	if err := json.Unmarshal(current, &currentDoc); err != nil {
+		return err
+	}
+
+	merged, err := json.Marshal(mergeValues(currentDoc, patchDoc))
+	if err != nil {
+		return err
+	}
+
+	// Decode into a zeroed value so removed members do not keep their old contents.
+	value := reflect.ValueOf(target).Elem()
+	value.Set(reflect.Zero(value.Type()))
+	if err := json.Unmarshal(merged, target); err != nil {
+		return models.ErrInvalidPatch
+	}
+	return nil
+}
+
+func mergeValues(current, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	currentObj, ok := current.(map[string]interface{})
+	if !ok {
+		currentObj = map[string]interface{}{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(currentObj, key)
+			continue
+		}
+		currentObj[key] = mergeValues(currentObj[key], value)
+	}
+	return currentObj
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"tender/internal/models"
+	"time"
+	"unicode/utf8"
+)
+
+// notificationEvents are the event types preferences can be set for.
+func notificationEvents() map[string]bool {
+	known := map[string]bool{models.AllEvents: true, "message": true}
+	for _, rule := range notificationRules {
+		known[rule.event] = true
+	}
+	return known
+}
+
+func validateNotificationSettings(settings models.NotificationSettings) error {
+	if _, err := settingsLocation(settings); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", models.ErrInvalidPreferences, settings.Timezone)
+	}
+	if (settings.QuietStart == "") != (settings.QuietEnd == "") {
+		return fmt.Errorf("%w: quiet_start and quiet_end must be set together", models.ErrInvalidPreferences)
+	}
+	if settings.QuietStart != "" {
+		start, err := parseClock(settings.QuietStart)
+		if err != nil {
+			return err
+		}
+		end, err := parseClock(settings.QuietEnd)
+		if err != nil {
+			return err
+		}
+		if start == end {
+			return fmt.Errorf("%w: quiet hours must not be empty", models.ErrInvalidPreferences)
+		}
+	}
+	if settings.DigestHour < 0 || settings.DigestHour > 23 {
+		return fmt.Errorf("%w: digest_hour must be between 0 and 23", models.ErrInvalidPreferences)
+	}
+
+	events := notificationEvents()
+	seen := map[[2]string]bool{}
+	for _, p := range settings.Preferences {
+		if !events[p.Event] {
+			return fmt.Errorf("%w: unknown event %q", models.ErrInvalidPreferences, p.Event)
+		}
+		switch p.Channel {
+		case models.ChannelPush, models.ChannelEmail, models.ChannelInApp:
+		default:
+			return fmt.Errorf("%w: channel must be push, email or in_app", models.ErrInvalidPreferences)
+		}
+		switch p.Mode {
+		case models.ModeInstant, models.ModeDigest, models.ModeOff:
+		default:
+			return fmt.Errorf("%w: mode must be instant, digest or off", models.ErrInvalidPreferences)
+		}
+		key := [2]string{p.Event, p.Channel}
+		if seen[key] {
+			return fmt.Errorf("%w: %s is set twice for %s", models.ErrInvalidPreferences, p.Channel, p.Event)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+func settingsLocation(settings models.NotificationSettings) (*time.Location, error) {
+	if settings.Timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(settings.Timezone)
+}
+
+// parseClock reads "HH:MM" as minutes since midnight.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not a HH:MM time", models.ErrInvalidPreferences, s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// preferredMode is the mode a channel delivers event in: the user's preference for the event,
+// else their preference for all events, else the channel's default. Email is off by default
+// except for the events in emailByDefault.
+func preferredMode(settings models.NotificationSettings, event, channel string) string {
+	mode := ""
+	for _, p := range settings.Preferences {
+		if p.Channel != channel {
+			continue
+		}
+		if p.Event == event {
+			return p.Mode
+		}
+		if p.Event == models.AllEvents {
+			mode = p.Mode
+		}
+	}
+	if mode != "" {
+		return mode
+	}
+	if channel == models.ChannelEmail && !emailByDefault[event] {
+		return models.ModeOff
+	}
+	return models.ModeInstant
+}
+
+// planDelivery decides how a notification of event reaches a user at now. Urgent notifications
+// skip the digest; instant pushes are held back until quiet hours end.
+func planDelivery(settings models.NotificationSettings, event string, urgent bool, now time.Time) models.NotificationDelivery {
+	d := models.NotificationDelivery{
+		InApp: preferredMode(settings, event, models.ChannelInApp) != models.ModeOff,
+		Push:  preferredMode(settings, event, models.ChannelPush),
+		Email: preferredMode(settings, event, models.ChannelEmail),
+	}
+	if urgent {
+		if d.Push == models.ModeDigest {
+			d.Push = models.ModeInstant
+		}
+		if d.Email == models.ModeDigest {
+			d.Email = models.ModeInstant
+		}
+	}
+	if d.Push == models.ModeInstant {
+		d.PushAfter = quietUntil(settings, now)
+	}
+	return d
+}
+
+// quietUntil returns the end of the quiet hours now falls in, or the zero time outside them.
+func quietUntil(settings models.NotificationSettings, now time.Time) time.Time {
+	if settings.QuietStart == "" || settings.QuietEnd == "" {
+		return time.Time{}
+	}
+	loc, err := settingsLocation(settings)
+	if err != nil {
+		return time.Time{}
+	}
+	start, err := parseClock(settings.QuietStart)
+	if err != nil {
+		return time.Time{}
+	}
+	end, err := parseClock(settings.QuietEnd)
+	if err != nil {
+		return time.Time{}
+	}
+
+	local := now.In(loc)
+	minutes := local.Hour()*60 + local.Minute()
+	quiet := minutes >= start && minutes < end
+	if start > end { // overnight, e.g. 22:00-08:00
+		quiet = minutes >= start || minutes < end
+	}
+	if !quiet {
+		return time.Time{}
+	}
+	until := time.Date(local.Year(), local.Month(), local.Day(), end/60, end%60, 0, 0, loc)
+	if !until.After(local) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until
+}
+
+// digestDue tells whether a user's daily digest should go out at now: it is past their digest
+// hour today and no digest was sent since.
+func digestDue(recipient models.DigestRecipient, now time.Time) bool {
+	loc, err := settingsLocation(models.NotificationSettings{Timezone: recipient.Timezone})
+	if err != nil {
+		loc = time.Local
+	}
+	local := now.In(loc)
+	due := time.Date(local.Year(), local.Month(), local.Day(), recipient.DigestHour, 0, 0, 0, loc)
+	if local.Before(due) {
+		return false
+	}
+	if recipient.LastDigestAt != nil {
+		last, err := time.Parse(time.RFC3339, *recipient.LastDigestAt)
+		if err == nil && !last.Before(due) {
+			return false
+		}
+	}
+	return true
+}
+
+// digestTitle heads every daily digest.
+const digestTitle = "Сводка уведомлений"
+
+// maxDigestPush bounds the body of a push digest, which only has room for the first few titles.
+const maxDigestPush = 200
+
+// composeDigests summarises a user's waiting notifications, one message per channel: a push
+// listing titles and an email listing every notification.
+func composeDigests(items []models.DigestItem) map[string]models.Notify {
+	titles := map[string][]string{}
+	lines := map[string][]string{}
+	for _, item := range items {
+		titles[item.Channel] = append(titles[item.Channel], item.Title)
+		lines[item.Channel] = append(lines[item.Channel], "• "+item.Title+": "+item.Body)
+	}
+
+	digests := map[string]models.Notify{}
+	if list := titles[models.ChannelPush]; len(list) > 0 {
+		body := fmt.Sprintf("%d новых: %s", len(list), strings.Join(list, "; "))
+		digests[models.ChannelPush] = models.Notify{
+			Title: digestTitle,
+			Body:  truncate(body, maxDigestPush),
+			Data:  map[string]string{"link": "notifications"},
+		}
+	}
+	if list := lines[models.ChannelEmail]; len(list) > 0 {
+		digests[models.ChannelEmail] = models.Notify{
+			Title: digestTitle,
+			Body:  strings.Join(list, "\n"),
+			Data:  map[string]string{},
+		}
+	}
+	return digests
+}
+
+// truncate shortens s to at most n runes, ending it with an ellipsis when cut.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + "…"
+}
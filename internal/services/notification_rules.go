@@ -0,0 +1,127 @@
+package services
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"tender/internal/events"
+	"text/template"
+)
+
+// Recipients a notification rule can address.
+const (
+	recipientOwner   = "owner"   // the user the changed record belongs to
+	recipientCompany = "company" // users holding a permission on the record's company
+	recipientAdmins  = "admins"
+)
+
+// notificationRule turns an event into a push notification. Title and body are templates over
+// the event's Data; link names the app screen to open, with the event's entity ID as its parameter.
+type notificationRule struct {
+	event      string
+	recipients []string
+	urgent     bool // skips the daily digest
+	title      *template.Template
+	body       *template.Template
+	link       string
+}
+
+func newNotificationRule(event string, recipients []string, urgent bool, title, body, link string) notificationRule {
+	return notificationRule{
+		event:      event,
+		recipients: recipients,
+		urgent:     urgent,
+		title:      template.Must(template.New(event + ".title").Funcs(notificationFuncs).Parse(title)),
+		body:       template.Must(template.New(event + ".body").Funcs(notificationFuncs).Parse(body)),
+		link:       link,
+	}
+}
+
+var notificationFuncs = template.FuncMap{"money": formatAmount}
+
+var notificationRules = []notificationRule{
+	newNotificationRule(events.TransactionStatusChanged, []string{recipientOwner, recipientAdmins}, false,
+		"Статус сделки изменён",
+		"Сделка {{.number}} «{{.product_name}}» {{if .completed}}завершена{{else}}переведена в статус {{.status}}{{end}}",
+		"transaction"),
+	newNotificationRule(events.TrancheReceived, []string{recipientOwner, recipientCompany, recipientAdmins}, false,
+		"Поступила оплата",
+		"По сделке {{.number}} «{{.product_name}}» поступило {{money .amount}}",
+		"tranche"),
+	newNotificationRule(events.TenderCompleted, []string{recipientOwner, recipientCompany, recipientAdmins}, false,
+		"Тендер завершён",
+		"Тендер {{.number}} {{.organization}} на сумму {{money .total}} завершён",
+		"tender"),
+	newNotificationRule(events.BalanceUpdated, []string{recipientOwner}, true,
+		"{{if .debit}}Списание с баланса{{else}}Баланс пополнен{{end}}",
+		"{{if .debit}}С вашего баланса списано{{else}}На ваш баланс зачислено{{end}} {{money .amount}}",
+		"balance"),
+	newNotificationRule(events.PermissionGranted, []string{recipientOwner}, false,
+		"Открыт доступ к компании",
+		"Вам открыт доступ к новой компании",
+		"company"),
+	newNotificationRule(events.PersonalDebtDue, []string{recipientAdmins}, false,
+		"Скоро срок возврата долга",
+		`{{if eq .direction "taken"}}Вернуть{{else if eq .direction "given"}}Получить{{else}}Остаток{{end}} {{money .remaining}} по долгу «{{.name}}» до {{.return_date}}`,
+		"personal_debt"),
+	newNotificationRule(events.PersonalDebtOverdue, []string{recipientAdmins}, true,
+		"Долг просрочен",
+		"Срок возврата {{money .remaining}} по долгу «{{.name}}» истёк {{.return_date}}",
+		"personal_debt"),
+	newNotificationRule(events.ReceivableOverdue, []string{recipientOwner, recipientAdmins}, false,
+		"Просрочена оплата",
+		"{{.company}}: не оплачено в срок сделок — {{.count}}, на сумму {{money .amount}}",
+		"receivable"),
+	newNotificationRule(events.ReceivableEscalated, []string{recipientOwner, recipientAdmins}, true,
+		"Долг компании требует внимания",
+		"{{.company}}: оплата по сделкам ({{.count}}) на сумму {{money .amount}} просрочена на {{.days}} дн.",
+		"receivable"),
+}
+
+// emailByDefault lists the events also sent by email unless the user turned email off.
+var emailByDefault = map[string]bool{
+	events.PersonalDebtDue:     true,
+	events.PersonalDebtOverdue: true,
+	events.ReceivableEscalated: true,
+}
+
+func (rule notificationRule) render(e events.Event) (string, string, error) {
+	var title, body bytes.Buffer
+	if err := rule.title.Execute(&title, e.Data); err != nil {
+		return "", "", err
+	}
+	if err := rule.body.Execute(&body, e.Data); err != nil {
+		return "", "", err
+	}
+	return title.String(), body.String(), nil
+}
+
+// formatAmount writes a sum of money as "1 234,56".
+func formatAmount(v interface{}) string {
+	var f float64
+	switch v := v.(type) {
+	case int:
+		f = float64(v)
+	case float64:
+		f = v
+	default:
+		return ""
+	}
+	s := strconv.FormatFloat(f, 'f', 2, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, fraction := s[:len(s)-3], s[len(s)-2:]
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + " " + whole[i:]
+	}
+	return sign + whole + "," + fraction
+}
+
+func derefOr(s *string, fallback string) string {
+	if s == nil {
+		return fallback
+	}
+	return *s
+}
@@ -0,0 +1,356 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"strconv"
+	"strings"
+	"tender/internal/events"
+	"tender/internal/mail"
+	"tender/internal/models"
+	"tender/internal/notify"
+	"tender/internal/repositories"
+	"time"
+)
+
+type NotificationService struct {
+	Repo     *repositories.NotificationRepository
+	Notifier notify.Notifier
+	Mailer   mail.Sender
+	// TokenTTL is how long a device token is kept without being registered again.
+	TokenTTL time.Duration
+}
+
+// Notify delivers a notification to req.UserId as their preferences ask: it is stored in their
+// inbox, queued for their devices and email, which DeliverPending sends, or held for the daily
+// digest. Notifications without an event are direct messages and are urgent.
+func (s *NotificationService) Notify(ctx context.Context, req models.NotificationRequest) error {
+	q, err := s.prepare(ctx, req)
+	if err != nil {
+		return err
+	}
+	_, err = s.Repo.Enqueue(ctx, q)
+	return err
+}
+
+// prepare plans the delivery of a notification by the preferences of req.UserId.
+func (s *NotificationService) prepare(ctx context.Context, req models.NotificationRequest) (repositories.QueuedNotification, error) {
+	category := req.Category
+	if category == "" {
+		category = "message"
+	}
+	if req.Event == "" {
+		req.Event, req.Urgent = "message", true
+	}
+
+	settings, err := s.Repo.GetSettings(ctx, req.UserId)
+	if err != nil {
+		return repositories.QueuedNotification{}, err
+	}
+	delivery := planDelivery(settings, req.Event, req.Urgent, time.Now())
+
+	return repositories.QueuedNotification{Notify: models.Notify{
+		UserID:   req.UserId,
+		Title:    req.Title,
+		Body:     req.Body,
+		Sender:   req.Sender,
+		Receiver: req.Receiver,
+		Category: category,
+		Data: map[string]string{
+			"link":   req.Link,
+			"param1": req.Param1,
+			"param2": req.Param2,
+		},
+	}, Delivery: delivery}, nil
+}
+
+// Outbox delivery tuning: a batch is claimed for outboxLease, and a failed delivery is retried
+// after outboxBaseDelay, doubling up to outboxMaxDelay, until outboxMaxAttempts attempts were made.
+const (
+	outboxBatchSize   = 100
+	outboxLease       = 2 * time.Minute
+	outboxBaseDelay   = 30 * time.Second
+	outboxMaxDelay    = time.Hour
+	outboxMaxAttempts = 8
+)
+
+// DeliverPending sends the queued notifications that are due and returns how many were sent.
+func (s *NotificationService) DeliverPending(ctx context.Context) (int, error) {
+	now := time.Now()
+	messages, err := s.Repo.DueOutbox(ctx, now, outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, m := range messages {
+		claimed, err := s.Repo.ClaimOutbox(ctx, m.ID, m.Attempts, now.Add(outboxLease))
+		if err != nil {
+			return sent, err
+		}
+		if !claimed {
+			continue
+		}
+		attempts := m.Attempts + 1
+
+		messageID, err := s.send(ctx, m)
+		switch {
+		case err == nil:
+			err = s.Repo.MarkSent(ctx, m.ID, messageID, time.Now())
+			sent++
+		case errors.Is(err, notify.ErrUnregistered):
+			log.Printf("Forgetting unregistered device token of user %d: %v", m.UserID, err)
+			err = s.Repo.ForgetToken(ctx, m.Token, err.Error())
+		case attempts >= outboxMaxAttempts:
+			err = s.Repo.MarkFailed(ctx, m.ID, err.Error())
+		default:
+			err = s.Repo.MarkRetry(ctx, m.ID, time.Now().Add(outboxBackoff(attempts)), err.Error())
+		}
+		if err != nil {
+			return sent, fmt.Errorf("updating outbox message %d: %w", m.ID, err)
+		}
+	}
+	return sent, nil
+}
+
+// send delivers an outbox message on its channel.
+func (s *NotificationService) send(ctx context.Context, m models.OutboxMessage) (string, error) {
+	if m.Channel == models.ChannelEmail {
+		body := strings.ReplaceAll(html.EscapeString(m.Body), "\n", "<br>")
+		return "", s.Mailer.Send(ctx, mail.Message{To: []string{m.Token}, Subject: m.Title, HTML: "<p>" + body + "</p>"})
+	}
+	return s.Notifier.Send(ctx, notify.Message{Token: m.Token, Title: m.Title, Body: m.Body, Data: m.Data})
+}
+
+// SendDigests queues the daily digest of every user whose digest hour has come and returns how
+// many digests were queued.
+func (s *NotificationService) SendDigests(ctx context.Context, now time.Time) (int, error) {
+	recipients, err := s.Repo.DigestRecipients(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	queued := 0
+	for _, recipient := range recipients {
+		if !digestDue(recipient, now) {
+			continue
+		}
+		items, err := s.Repo.DigestItems(ctx, recipient.UserID)
+		if err != nil {
+			return queued, err
+		}
+		if len(items) == 0 {
+			continue
+		}
+		digests := composeDigests(items)
+		for channel, n := range digests {
+			n.UserID, n.Category = recipient.UserID, "digest"
+			digests[channel] = n
+		}
+		if err := s.Repo.FlushDigest(ctx, recipient.UserID, items[len(items)-1].ID, digests, now); err != nil {
+			return queued, fmt.Errorf("digest of user %d: %w", recipient.UserID, err)
+		}
+		queued++
+	}
+	return queued, nil
+}
+
+// GetSettings returns a user's notification settings.
+func (s *NotificationService) GetSettings(ctx context.Context, userID int) (models.NotificationSettings, error) {
+	return s.Repo.GetSettings(ctx, userID)
+}
+
+// SaveSettings validates and replaces a user's notification settings.
+func (s *NotificationService) SaveSettings(ctx context.Context, settings models.NotificationSettings) (models.NotificationSettings, error) {
+	if settings.Preferences == nil {
+		settings.Preferences = []models.NotificationPreference{}
+	}
+	if err := validateNotificationSettings(settings); err != nil {
+		return settings, err
+	}
+	if err := s.Repo.SaveSettings(ctx, settings); err != nil {
+		return settings, err
+	}
+	return s.Repo.GetSettings(ctx, settings.UserID)
+}
+
+// outboxBackoff is the delay before the attempt after the given number of attempts.
+func outboxBackoff(attempts int) time.Duration {
+	delay := outboxBaseDelay
+	for i := 1; i < attempts && delay < outboxMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > outboxMaxDelay {
+		delay = outboxMaxDelay
+	}
+	return delay
+}
+
+// GetOutbox returns a page of the outbox for administrators.
+func (s *NotificationService) GetOutbox(ctx context.Context, f models.OutboxFilter) (models.OutboxPage, error) {
+	if f.Limit <= 0 || f.Limit > 500 {
+		f.Limit = 100
+	}
+	return s.Repo.ListOutbox(ctx, f)
+}
+
+// HandleEvent notifies the recipients of every rule matching e. The user who made the change is
+// never notified of it. All recipients are queued in one transaction, so an event handled again
+// after a failure reaches nobody twice.
+func (s *NotificationService) HandleEvent(ctx context.Context, e events.Event) error {
+	var queue []repositories.QueuedNotification
+	for _, rule := range notificationRules {
+		if rule.event != e.Type {
+			continue
+		}
+		title, body, err := rule.render(e)
+		if err != nil {
+			return fmt.Errorf("rendering %s notification: %w", e.Type, err)
+		}
+		recipients, err := s.recipients(ctx, rule.recipients, e)
+		if err != nil {
+			return err
+		}
+		for _, userID := range recipients {
+			q, err := s.prepare(ctx, models.NotificationRequest{
+				UserId:   userID,
+				Title:    title,
+				Body:     body,
+				Sender:   e.ActorID,
+				Receiver: userID,
+				Link:     rule.link,
+				Param1:   strconv.Itoa(e.EntityID),
+				Category: rule.link,
+				Event:    e.Type,
+				Urgent:   rule.urgent,
+			})
+			if err != nil {
+				return err
+			}
+			queue = append(queue, q)
+		}
+	}
+	if len(queue) == 0 {
+		return nil
+	}
+	_, err := s.Repo.Enqueue(ctx, queue...)
+	return err
+}
+
+// recipients resolves the recipient kinds of a rule to distinct user IDs, leaving out the actor.
+func (s *NotificationService) recipients(ctx context.Context, kinds []string, e events.Event) ([]int, error) {
+	var userIDs []int
+	seen := map[int]bool{e.ActorID: true}
+	add := func(id int) {
+		if !seen[id] {
+			seen[id] = true
+			userIDs = append(userIDs, id)
+		}
+	}
+
+	for _, kind := range kinds {
+		switch kind {
+		case recipientOwner:
+			if e.UserID != nil {
+				add(*e.UserID)
+			}
+		case recipientCompany:
+			if e.CompanyID == nil {
+				continue
+			}
+			holders, err := s.Repo.GetCompanyUserIDs(ctx, *e.CompanyID)
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range holders {
+				add(id)
+			}
+		case recipientAdmins:
+			add(models.AdminUserID)
+		}
+	}
+	return userIDs, nil
+}
+
+// RegisterToken stores a device token of t.UserId, or refreshes its metadata and last-seen time
+// when the token is already registered. Apps call it on every start.
+func (s *NotificationService) RegisterToken(ctx context.Context, t models.Token) (models.Token, error) {
+	t.Token = strings.TrimSpace(t.Token)
+	if t.Token == "" || len(t.Token) > 255 {
+		return models.Token{}, fmt.Errorf("%w: token must be 1 to 255 characters", models.ErrInvalidDeviceToken)
+	}
+	if t.Platform != nil {
+		platform := strings.ToLower(strings.TrimSpace(*t.Platform))
+		switch platform {
+		case models.PlatformAndroid, models.PlatformIOS, models.PlatformWeb:
+		default:
+			return models.Token{}, fmt.Errorf("%w: platform must be android, ios or web", models.ErrInvalidDeviceToken)
+		}
+		t.Platform = &platform
+	}
+	if t.AppVersion != nil && len(*t.AppVersion) > 50 {
+		return models.Token{}, fmt.Errorf("%w: app_version is too long", models.ErrInvalidDeviceToken)
+	}
+	if t.Locale != nil && len(*t.Locale) > 20 {
+		return models.Token{}, fmt.Errorf("%w: locale is too long", models.ErrInvalidDeviceToken)
+	}
+	return s.Repo.UpsertToken(ctx, t)
+}
+
+func (s *NotificationService) Devices(ctx context.Context, userID int) ([]models.Token, error) {
+	return s.Repo.ListTokens(ctx, userID)
+}
+
+func (s *NotificationService) DeleteToken(ctx context.Context, id, userID int) error {
+	return s.Repo.DeleteToken(ctx, id, userID)
+}
+
+// Logout stops pushes to a device the user signs out of, or to all of their devices when token
+// is empty. It returns how many tokens were removed.
+func (s *NotificationService) Logout(ctx context.Context, userID int, token string) (int, error) {
+	if token = strings.TrimSpace(token); token != "" {
+		return s.Repo.DeleteTokenValue(ctx, userID, token)
+	}
+	return s.Repo.DeleteTokensByUserID(ctx, userID)
+}
+
+// ExpireTokens removes the device tokens not registered again within TokenTTL.
+func (s *NotificationService) ExpireTokens(ctx context.Context, now time.Time) (int, error) {
+	if s.TokenTTL <= 0 {
+		return 0, nil
+	}
+	return s.Repo.ExpireTokens(ctx, now.Add(-s.TokenTTL))
+}
+
+func (s *NotificationService) GetHistory(ctx context.Context, userID int) ([]models.Notify, error) {
+	return s.Repo.GetHistoryByUserID(ctx, userID)
+}
+
+// maxInboxPage bounds a page of the inbox.
+const maxInboxPage = 100
+
+func (s *NotificationService) Inbox(ctx context.Context, req models.InboxRequest) (models.InboxPage, error) {
+	if req.Limit <= 0 || req.Limit > maxInboxPage {
+		req.Limit = 20
+	}
+	return s.Repo.Inbox(ctx, req)
+}
+
+func (s *NotificationService) UnreadCount(ctx context.Context, userID int) (int, error) {
+	return s.Repo.UnreadCount(ctx, userID)
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, id, userID int) error {
+	return s.Repo.MarkRead(ctx, id, userID)
+}
+
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID int) (int, error) {
+	return s.Repo.MarkAllRead(ctx, userID)
+}
+
+func (s *NotificationService) DeleteHistory(ctx context.Context, id int) error {
+	return s.Repo.DeleteHistory(ctx, id)
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"log"
+	"strings"
+	"tender/internal/events"
+	"tender/internal/models"
+	"tender/internal/notify"
+	"tender/internal/repositories"
+	"testing"
+	"time"
+)
+
+// capture is a sqlmock argument that matches anything and keeps the value it was given.
+type capture struct {
+	value driver.Value
+}
+
+func (c *capture) Match(v driver.Value) bool {
+	c.value = v
+	return true
+}
+
+// deliverEvent handles a permission.granted event for user 7 and delivers the push notification
+// it queues through notifier. The database is a mock; what HandleEvent writes to the outbox is
+// what DeliverPending reads back.
+func deliverEvent(t *testing.T, notifier notify.Notifier) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	service := &NotificationService{Repo: &repositories.NotificationRepository{Db: db}, Notifier: notifier}
+
+	userID, companyID := 7, 3
+	mock.ExpectQuery("FROM notification_settings").WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"timezone", "quiet_start", "quiet_end", "digest_hour"}))
+	mock.ExpectQuery("FROM notification_preferences").WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"event", "channel", "mode"}))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO notify_history").WillReturnResult(sqlmock.NewResult(11, 1))
+	var channel, title, body, data capture
+	mock.ExpectExec("INSERT INTO notification_outbox").
+		WithArgs(int64(11), &channel, &title, &body, &data, sqlmock.AnyArg(), userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = service.HandleEvent(context.Background(), events.Event{
+		Type:      events.PermissionGranted,
+		ActorID:   models.AdminUserID,
+		UserID:    &userID,
+		CompanyID: &companyID,
+		EntityID:  companyID,
+	})
+	if err != nil {
+		t.Fatalf("HandleEvent: %v", err)
+	}
+	if channel.value != models.ChannelPush {
+		t.Fatalf("queued on channel %v, want %s", channel.value, models.ChannelPush)
+	}
+
+	now := time.Now()
+	mock.ExpectQuery("FROM notification_outbox").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "history_id", "user_id", "channel", "token", "title", "body", "data",
+			"status", "attempts", "next_attempt_at", "last_error", "message_id", "created_at", "sent_at"}).
+			AddRow(5, 11, userID, channel.value, "device-token", title.value, body.value, data.value,
+				models.OutboxPending, 0, now, nil, nil, now, nil))
+	mock.ExpectExec("UPDATE notification_outbox SET attempts = attempts \\+ 1").
+		WithArgs(sqlmock.AnyArg(), int64(5), models.OutboxPending, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE notification_outbox SET status").
+		WithArgs(models.OutboxSent, sqlmock.AnyArg(), sqlmock.AnyArg(), int64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	sent, err := service.DeliverPending(context.Background())
+	if err != nil {
+		t.Fatalf("DeliverPending: %v", err)
+	}
+	if sent != 1 {
+		t.Fatalf("sent %d notifications, want 1", sent)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEventIsDeliveredToDevices(t *testing.T) {
+	recorder := &notify.Recorder{}
+	deliverEvent(t, recorder)
+
+	sent := recorder.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("recorded %d notifications, want 1", len(sent))
+	}
+	msg := sent[0]
+	if msg.Token != "device-token" || msg.Title != "Открыт доступ к компании" {
+		t.Errorf("sent %q to %q", msg.Title, msg.Token)
+	}
+	if msg.Data["link"] != "company" || msg.Data["param1"] != "3" {
+		t.Errorf("sent data %v, want the company link", msg.Data)
+	}
+}
+
+// Without Firebase credentials the server sends through notify.Log, which must deliver as well.
+func TestEventIsLoggedWithoutFirebase(t *testing.T) {
+	var out bytes.Buffer
+	deliverEvent(t, &notify.Log{Logger: log.New(&out, "", 0)})
+
+	if line := out.String(); !strings.Contains(line, "push to device-token") || !strings.Contains(line, "Открыт доступ к компании") {
+		t.Errorf("logged %q", line)
+	}
+}
+
+// When queueing fails for one recipient of an event, none of them is queued, so the event can be
+// handled again without notifying anyone twice.
+func TestEventRecipientsAreQueuedTogether(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	service := &NotificationService{Repo: &repositories.NotificationRepository{Db: db}}
+
+	ownerID := 7
+	for _, userID := range []int{ownerID, models.AdminUserID} {
+		mock.ExpectQuery("FROM notification_settings").WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows([]string{"timezone", "quiet_start", "quiet_end", "digest_hour"}))
+		mock.ExpectQuery("FROM notification_preferences").WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows([]string{"event", "channel", "mode"}))
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO notify_history").WillReturnResult(sqlmock.NewResult(11, 1))
+	mock.ExpectExec("INSERT INTO notification_outbox").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO notify_history").WillReturnError(errors.New("connection lost"))
+	mock.ExpectRollback()
+
+	err = service.HandleEvent(context.Background(), events.Event{
+		Type:     events.TransactionStatusChanged,
+		ActorID:  5,
+		UserID:   &ownerID,
+		EntityID: 9,
+		Data:     map[string]interface{}{"number": "T-1", "product_name": "Cable", "completed": true},
+	})
+	if err == nil {
+		t.Fatal("HandleEvent succeeded, want the failure of the second recipient")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
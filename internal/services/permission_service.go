@@ -2,12 +2,14 @@ package services
 
 import (
 	"context"
+	"tender/internal/events"
 	"tender/internal/models"
 	"tender/internal/repositories"
 )
 
 type PermissionService struct {
-	Repo *repositories.PermissionRepository
+	Repo   *repositories.PermissionRepository
+	Events *events.Bus
 }
 
 // AddPermission adds a new permission for a user.
@@ -19,6 +21,13 @@ func (s *PermissionService) AddPermission(ctx context.Context, permission models
 
 	statusValue := 1
 	permission.Status = &statusValue
+
+	s.Events.Publish(ctx, events.Event{
+		Type:      events.PermissionGranted,
+		UserID:    &permission.UserID,
+		CompanyID: &permission.CompanyID,
+		EntityID:  permission.ID,
+	})
 	return permission, nil
 }
 
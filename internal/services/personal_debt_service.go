@@ -2,12 +2,21 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"tender/internal/events"
 	"tender/internal/models"
 	"tender/internal/repositories"
+	"time"
 )
 
 type PersonalDebtService struct {
-	Repo *repositories.PersonalDebtRepository
+	Repo   *repositories.PersonalDebtRepository
+	Events *events.Bus
+	// ReminderDays is how many days before its return date a debt is reminded of.
+	ReminderDays int
+	// DebtTypes tells reminders whether a debt is to be returned or collected.
+	DebtTypes models.DebtTypes
 }
 
 func (s *PersonalDebtService) CreatePersonalDebt(ctx context.Context, debt *models.PersonalDebt) (int, error) {
@@ -22,6 +31,25 @@ func (s *PersonalDebtService) UpdatePersonalDebt(ctx context.Context, debt *mode
 	return s.Repo.UpdatePersonalDebt(ctx, debt)
 }
 
+// PatchPersonalDebt applies a JSON merge patch to a personal debt. It returns nil when the debt does not exist.
+func (s *PersonalDebtService) PatchPersonalDebt(ctx context.Context, id, version int, patch []byte) (*models.PersonalDebt, error) {
+	debt, err := s.Repo.GetPersonalDebtByID(ctx, id)
+	if err != nil || debt == nil {
+		return nil, err
+	}
+	if version == 0 {
+		version = debt.Version
+	}
+
+	if err := applyMergePatch(debt, patch); err != nil {
+		return nil, err
+	}
+	debt.ID = id
+	debt.Version = version
+
+	return s.Repo.UpdatePersonalDebt(ctx, debt)
+}
+
 func (s *PersonalDebtService) DeletePersonalDebt(ctx context.Context, id int) error {
 	return s.Repo.DeletePersonalDebt(ctx, id)
 }
@@ -37,3 +65,64 @@ func (s *PersonalDebtService) GetAllPersonalDebtsByStatus(ctx context.Context, i
 func (s *PersonalDebtService) GetAllPersonalDebtsByType(ctx context.Context, id int) ([]models.PersonalDebt, error) {
 	return s.Repo.GetAllPersonalDebtsByType(ctx, id)
 }
+
+// GetOverdueDebts returns the unpaid debts whose return date has passed, most overdue first.
+func (s *PersonalDebtService) GetOverdueDebts(ctx context.Context, now time.Time) ([]models.UnpaidDebt, error) {
+	today := startOfDay(now)
+	return s.Repo.GetUnpaidDebts(ctx, today.AddDate(0, 0, -1), today)
+}
+
+// SendReminders marks overdue debts and publishes one reminder per debt when its return date
+// comes within ReminderDays and another once it has passed. It returns how many were published.
+// A reminder is recorded only once its notifications are queued; one that fails is tried again
+// on the next run.
+func (s *PersonalDebtService) SendReminders(ctx context.Context, now time.Time) (int, error) {
+	today := startOfDay(now)
+	if _, err := s.Repo.SyncOverdue(ctx, today); err != nil {
+		return 0, err
+	}
+	debts, err := s.Repo.GetUnpaidDebts(ctx, today.AddDate(0, 0, s.ReminderDays), today)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, debt := range debts {
+		kind, eventType := models.DebtReminderUpcoming, events.PersonalDebtDue
+		if debt.Due.Before(today) {
+			kind, eventType = models.DebtReminderOverdue, events.PersonalDebtOverdue
+		}
+		reminded, err := s.Repo.HasReminder(ctx, debt.ID, kind, debt.Due)
+		if err != nil {
+			return sent, fmt.Errorf("checking %s reminder of personal debt %d: %w", kind, debt.ID, err)
+		}
+		if reminded {
+			continue
+		}
+		err = s.Events.Deliver(ctx, events.Event{
+			Type:     eventType,
+			EntityID: debt.ID,
+			Data: map[string]interface{}{
+				"name":         debt.Name,
+				"remaining":    debt.Remaining,
+				"return_date":  debt.Due.Format("02.01.2006"),
+				"days_overdue": debt.DaysOverdue,
+				"direction":    s.DebtTypes.Direction(debt.Type),
+			},
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s reminder of personal debt %d: %w", kind, debt.ID, err))
+			continue
+		}
+		if err := s.Repo.RecordReminder(ctx, debt.ID, kind, debt.Due); err != nil {
+			return sent, fmt.Errorf("recording %s reminder of personal debt %d: %w", kind, debt.ID, err)
+		}
+		sent++
+	}
+	return sent, errors.Join(errs...)
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"tender/internal/events"
+	"tender/internal/models"
+	"tender/internal/repositories"
+	"time"
+)
+
+// maxAlertsPage bounds a page of the alert log.
+const maxAlertsPage = 500
+
+type ReceivableService struct {
+	Repo      *repositories.ReceivableRepository
+	Reports   *repositories.ReportRepository
+	Companies *repositories.CompanyRepository
+	Events    *events.Bus
+	// Defaults are the payment terms of companies without terms of their own.
+	Defaults models.PaymentTerms
+}
+
+// GetPaymentTerms returns the payment terms of a company, or the defaults when it has none.
+func (s *ReceivableService) GetPaymentTerms(ctx context.Context, companyID int) (models.PaymentTerms, error) {
+	if _, err := s.Companies.GetCompanyByID(ctx, companyID); err != nil {
+		return models.PaymentTerms{}, err
+	}
+	terms, err := s.Repo.GetPaymentTerms(ctx, companyID)
+	if err != nil {
+		return models.PaymentTerms{}, err
+	}
+	if terms == nil {
+		return s.defaultTerms(companyID), nil
+	}
+	return *terms, nil
+}
+
+func (s *ReceivableService) SavePaymentTerms(ctx context.Context, terms models.PaymentTerms) (models.PaymentTerms, error) {
+	if terms.DueDays < 0 || terms.DueDays > 365 {
+		return models.PaymentTerms{}, fmt.Errorf("%w: due_days must be between 0 and 365", models.ErrInvalidPaymentTerms)
+	}
+	if terms.EscalateDays <= terms.DueDays {
+		return models.PaymentTerms{}, fmt.Errorf("%w: escalate_days must be greater than due_days", models.ErrInvalidPaymentTerms)
+	}
+	if _, err := s.Companies.GetCompanyByID(ctx, terms.CompanyID); err != nil {
+		return models.PaymentTerms{}, err
+	}
+	terms.Default = false
+	return terms, s.Repo.SavePaymentTerms(ctx, terms)
+}
+
+// ResetPaymentTerms returns a company to the default payment terms.
+func (s *ReceivableService) ResetPaymentTerms(ctx context.Context, companyID int) (models.PaymentTerms, error) {
+	if _, err := s.Companies.GetCompanyByID(ctx, companyID); err != nil {
+		return models.PaymentTerms{}, err
+	}
+	return s.defaultTerms(companyID), s.Repo.DeletePaymentTerms(ctx, companyID)
+}
+
+func (s *ReceivableService) defaultTerms(companyID int) models.PaymentTerms {
+	terms := s.Defaults
+	terms.CompanyID = companyID
+	terms.Default = true
+	return terms
+}
+
+// Overdue lists the completed transactions unpaid past their company's payment terms at now,
+// most overdue first. The outstanding amounts are those of GetCompanyDebt: the sell price less
+// the tranches received. Transactions with neither a completion date nor a date cannot fall due
+// and are left out.
+func (s *ReceivableService) Overdue(ctx context.Context, now time.Time) ([]models.OverdueReceivable, error) {
+	receivables, err := s.Reports.GetReceivables(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	terms, err := s.Repo.ListPaymentTerms(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	today := startOfDay(now)
+	overdue := []models.OverdueReceivable{}
+	for _, rec := range receivables {
+		if rec.CompletedAt.IsZero() {
+			continue
+		}
+		t := s.Defaults
+		if rec.CompanyID != nil {
+			if own, ok := terms[*rec.CompanyID]; ok {
+				t = own
+			}
+		}
+		completed := time.Date(rec.CompletedAt.Year(), rec.CompletedAt.Month(), rec.CompletedAt.Day(), 0, 0, 0, 0, today.Location())
+		due := completed.AddDate(0, 0, t.DueDays)
+		daysOverdue := int(math.Round(today.Sub(due).Hours() / 24))
+		if daysOverdue <= 0 {
+			continue
+		}
+
+		level := models.AlertOverdue
+		if today.After(completed.AddDate(0, 0, t.EscalateDays)) {
+			level = models.AlertEscalated
+		}
+		overdue = append(overdue, models.OverdueReceivable{
+			TransactionID: rec.Item.TransactionID,
+			CompanyID:     rec.CompanyID,
+			CompanyName:   rec.CompanyName,
+			UserID:        rec.UserID,
+			TenderNumber:  rec.Item.TenderNumber,
+			ProductName:   rec.Item.ProductName,
+			Outstanding:   math.Round((rec.Item.Sell-rec.Item.Paid)*100) / 100,
+			CompletedDate: completed.Format("2006-01-02"),
+			DueDate:       due.Format("2006-01-02"),
+			DaysOverdue:   daysOverdue,
+			Level:         level,
+		})
+	}
+	sort.SliceStable(overdue, func(i, j int) bool { return overdue[i].DaysOverdue > overdue[j].DaysOverdue })
+	return overdue, nil
+}
+
+// receivableAlertGroup collects the newly overdue transactions of one company and responsible
+// user at one level, which are announced together.
+type receivableAlertGroup struct {
+	companyID, userID *int
+	company           string
+	level             string
+	amount            float64
+	days              int
+	alerts            []models.ReceivableAlert
+	due               []time.Time
+}
+
+// SendAlerts alerts the responsible users and admins about transactions that became overdue or
+// crossed the escalation threshold since the last run. The alerts of a group are recorded once
+// its notifications are queued, so each transaction is announced once per level; a group that
+// fails is tried again on the next run. It returns how many groups were announced.
+func (s *ReceivableService) SendAlerts(ctx context.Context, now time.Time) (int, error) {
+	overdue, err := s.Overdue(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	var groups []*receivableAlertGroup
+	byKey := map[string]*receivableAlertGroup{}
+	for _, o := range overdue {
+		alerted, err := s.Repo.HasAlert(ctx, o.TransactionID, o.Level)
+		if err != nil {
+			return 0, fmt.Errorf("checking %s alert of transaction %d: %w", o.Level, o.TransactionID, err)
+		}
+		if alerted {
+			continue
+		}
+
+		key := fmt.Sprintf("%d/%d/%s", idOrZero(o.CompanyID), idOrZero(o.UserID), o.Level)
+		group, ok := byKey[key]
+		if !ok {
+			group = &receivableAlertGroup{companyID: o.CompanyID, userID: o.UserID, company: "Без компании", level: o.Level}
+			if o.CompanyName != nil {
+				group.company = *o.CompanyName
+			}
+			byKey[key] = group
+			groups = append(groups, group)
+		}
+		due, _ := time.ParseInLocation("2006-01-02", o.DueDate, now.Location())
+		group.alerts = append(group.alerts, models.ReceivableAlert{
+			TransactionID: o.TransactionID,
+			Level:         o.Level,
+			CompanyID:     o.CompanyID,
+			UserID:        o.UserID,
+			Outstanding:   o.Outstanding,
+		})
+		group.due = append(group.due, due)
+		group.amount += o.Outstanding
+		if o.DaysOverdue > group.days {
+			group.days = o.DaysOverdue
+		}
+	}
+
+	sent := 0
+	var errs []error
+	for _, group := range groups {
+		eventType := events.ReceivableOverdue
+		if group.level == models.AlertEscalated {
+			eventType = events.ReceivableEscalated
+		}
+		err := s.Events.Deliver(ctx, events.Event{
+			Type:      eventType,
+			UserID:    group.userID,
+			CompanyID: group.companyID,
+			EntityID:  idOrZero(group.companyID),
+			Data: map[string]interface{}{
+				"company": group.company,
+				"count":   len(group.alerts),
+				"amount":  math.Round(group.amount*100) / 100,
+				"days":    group.days,
+			},
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s alert of %s: %w", group.level, group.company, err))
+			continue
+		}
+		for i, alert := range group.alerts {
+			if err := s.Repo.RecordAlert(ctx, alert, group.due[i]); err != nil {
+				return sent, fmt.Errorf("recording %s alert of transaction %d: %w", alert.Level, alert.TransactionID, err)
+			}
+		}
+		sent++
+	}
+	return sent, errors.Join(errs...)
+}
+
+func (s *ReceivableService) Alerts(ctx context.Context, companyID *int, limit int) ([]models.ReceivableAlert, error) {
+	if limit <= 0 || limit > maxAlertsPage {
+		limit = 100
+	}
+	return s.Repo.ListAlerts(ctx, companyID, limit)
+}
+
+func idOrZero(id *int) int {
+	if id == nil {
+		return 0
+	}
+	return *id
+}
@@ -0,0 +1,407 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"tender/internal/models"
+	"tender/internal/repositories"
+	"time"
+)
+
+type ReportService struct {
+	Repo      *repositories.ReportRepository
+	DebtTypes models.DebtTypes
+}
+
+// maxReportRows bounds the size of a single report.
+const maxReportRows = 10000
+
+// Query runs a pivot report over transactions.
+func (s *ReportService) Query(ctx context.Context, q models.ReportQuery) (models.ReportResult, error) {
+	if q.Limit <= 0 || q.Limit > maxReportRows {
+		q.Limit = maxReportRows
+	}
+	return s.Repo.Query(ctx, q)
+}
+
+// ProfitAndLoss builds the profit and loss statement of a period, month by month, with each
+// month compared to the one before it.
+func (s *ReportService) ProfitAndLoss(ctx context.Context, f models.PnLFilter) (models.PnLReport, error) {
+	start, err := time.Parse("2006-01-02", f.StartDate)
+	if err != nil {
+		return models.PnLReport{}, fmt.Errorf("%w: start_date must be YYYY-MM-DD", models.ErrInvalidReport)
+	}
+	end, err := time.Parse("2006-01-02", f.EndDate)
+	if err != nil {
+		return models.PnLReport{}, fmt.Errorf("%w: end_date must be YYYY-MM-DD", models.ErrInvalidReport)
+	}
+	if end.Before(start) {
+		return models.PnLReport{}, fmt.Errorf("%w: end_date is before start_date", models.ErrInvalidReport)
+	}
+
+	figures, err := s.Repo.ProfitAndLoss(ctx, f, start, end)
+	if err != nil {
+		return models.PnLReport{}, err
+	}
+
+	report := models.PnLReport{PnLFilter: f, Months: []models.PnLMonth{}, Total: newPnLFigures()}
+	var previous *models.PnLFigures
+	for month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(end); month = month.AddDate(0, 1, 0) {
+		key := month.Format("2006-01")
+		current := figures[key]
+		if current == nil {
+			empty := newPnLFigures()
+			current = &empty
+		}
+		*current = withMargins(*current)
+
+		entry := models.PnLMonth{Month: key, PnLFigures: *current}
+		if previous != nil {
+			entry.Change = &models.PnLChange{
+				Revenue:        current.Revenue - previous.Revenue,
+				RevenuePct:     percentChange(previous.Revenue, current.Revenue),
+				GrossMargin:    current.GrossMargin - previous.GrossMargin,
+				GrossMarginPct: percentChange(previous.GrossMargin, current.GrossMargin),
+				NetMargin:      current.NetMargin - previous.NetMargin,
+				NetMarginPct:   percentChange(previous.NetMargin, current.NetMargin),
+			}
+		}
+		report.Months = append(report.Months, entry)
+		addPnLFigures(&report.Total, *current)
+		previous = current
+	}
+	report.Total = withMargins(report.Total)
+
+	return report, nil
+}
+
+func newPnLFigures() models.PnLFigures {
+	return models.PnLFigures{TenderCommissions: map[string]float64{}, PersonalExpenses: map[string]float64{}}
+}
+
+// withMargins fills in the gross and net margin. Tender commissions are income; extra
+// transactions and personal expenses are costs.
+func withMargins(f models.PnLFigures) models.PnLFigures {
+	f.GrossMargin = f.Revenue - f.Cost - f.AdditionalExpenses
+	f.NetMargin = f.GrossMargin + f.TenderCommissionTotal() - f.ExtraTransactions - f.PersonalExpenseTotal()
+	return f
+}
+
+func addPnLFigures(total *models.PnLFigures, f models.PnLFigures) {
+	total.Revenue += f.Revenue
+	total.Cost += f.Cost
+	total.AdditionalExpenses += f.AdditionalExpenses
+	total.ExtraTransactions += f.ExtraTransactions
+	for k, v := range f.TenderCommissions {
+		total.TenderCommissions[k] += v
+	}
+	for k, v := range f.PersonalExpenses {
+		total.PersonalExpenses[k] += v
+	}
+}
+
+// percentChange returns the change from previous to current in percent, or nil when
+// previous is zero.
+func percentChange(previous, current float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	pct := math.Round((current-previous)/math.Abs(previous)*10000) / 100
+	return &pct
+}
+
+// Aging buckets the outstanding debt of completed transactions per company by days since
+// completion.
+func (s *ReportService) Aging(ctx context.Context, userID *int) (models.AgingReport, error) {
+	receivables, err := s.Repo.GetReceivables(ctx, userID)
+	if err != nil {
+		return models.AgingReport{}, err
+	}
+
+	now := time.Now()
+	report := models.AgingReport{AsOf: now.Format(time.RFC3339), UserID: userID, Companies: []models.AgingCompany{}}
+	companies := map[int]int{} // company ID (0 for none) -> index in report.Companies
+	var lastPayments []*time.Time
+
+	// Receivables come oldest first, undated ones before any other, so the first dated item of each
+	// company is its oldest unpaid one.
+	for _, rec := range receivables {
+		item := rec.Item
+		item.Outstanding = math.Round((item.Sell-item.Paid)*100) / 100
+		if !rec.CompletedAt.IsZero() {
+			completed := rec.CompletedAt.Format(time.RFC3339)
+			days := max(int(now.Sub(rec.CompletedAt).Hours()/24), 0)
+			item.CompletedDate, item.DaysOutstanding = &completed, &days
+		}
+		if rec.LastPaymentAt != nil {
+			paid := rec.LastPaymentAt.Format(time.RFC3339)
+			item.LastPaymentDate = &paid
+		}
+
+		key := 0
+		if rec.CompanyID != nil {
+			key = *rec.CompanyID
+		}
+		index, ok := companies[key]
+		if !ok {
+			index = len(report.Companies)
+			companies[key] = index
+			report.Companies = append(report.Companies, models.AgingCompany{CompanyID: rec.CompanyID, CompanyName: rec.CompanyName})
+			lastPayments = append(lastPayments, nil)
+		}
+		company := &report.Companies[index]
+
+		item.Bucket = addToAgingBucket(&company.Buckets, item.DaysOutstanding, item.Outstanding)
+		addToAgingBucket(&report.Totals, item.DaysOutstanding, item.Outstanding)
+		company.Items = append(company.Items, item)
+		if rec.LastPaymentAt != nil && (lastPayments[index] == nil || rec.LastPaymentAt.After(*lastPayments[index])) {
+			lastPayments[index] = rec.LastPaymentAt
+			company.LastPaymentDate = item.LastPaymentDate
+		}
+	}
+	for i := range report.Companies {
+		company := &report.Companies[i]
+		for j := range company.Items {
+			if company.Items[j].DaysOutstanding != nil {
+				company.OldestUnpaid = &company.Items[j]
+				break
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// addToAgingBucket adds amount to the bucket for days and to the total, returning the bucket's name.
+// Without days the amount goes to the undated bucket alone.
+func addToAgingBucket(b *models.AgingBuckets, days *int, amount float64) string {
+	if days == nil {
+		b.Undated += amount
+		return "undated"
+	}
+	b.Total += amount
+	switch {
+	case *days <= 30:
+		b.Days0To30 += amount
+		return "0_30"
+	case *days <= 60:
+		b.Days31To60 += amount
+		return "31_60"
+	case *days <= 90:
+		b.Days61To90 += amount
+		return "61_90"
+	default:
+		b.Days90Plus += amount
+		return "90_plus"
+	}
+}
+
+// expenseHistoryMonths is how many past months recurring expenses are averaged over.
+const expenseHistoryMonths = 3
+
+// CashFlowForecast projects the treasury balance over the coming weeks or months from
+// receivables, planned tranches, personal debts and recurring expenses.
+func (s *ReportService) CashFlowForecast(ctx context.Context, interval string, periods int) (models.CashFlowForecast, error) {
+	var maxPeriods int
+	switch interval {
+	case "week":
+		maxPeriods = 104
+	case "month":
+		maxPeriods = 24
+	default:
+		return models.CashFlowForecast{}, fmt.Errorf("%w: interval must be week or month", models.ErrInvalidReport)
+	}
+	if periods <= 0 || periods > maxPeriods {
+		return models.CashFlowForecast{}, fmt.Errorf("%w: periods must be between 1 and %d", models.ErrInvalidReport, maxPeriods)
+	}
+
+	now := time.Now()
+	balance, err := s.Repo.GetTreasuryBalance(ctx)
+	if err != nil {
+		return models.CashFlowForecast{}, err
+	}
+	events, err := s.Repo.GetCashFlowEvents(ctx, now, s.DebtTypes)
+	if err != nil {
+		return models.CashFlowForecast{}, err
+	}
+	monthlyExpenses, err := s.Repo.GetAverageMonthlyExpenses(ctx, now, expenseHistoryMonths)
+	if err != nil {
+		return models.CashFlowForecast{}, err
+	}
+
+	forecast := models.CashFlowForecast{
+		Interval:        interval,
+		OpeningBalance:  balance,
+		Periods:         make([]models.CashFlowPeriod, periods),
+		NegativePeriods: []string{},
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	bounds := make([]time.Time, periods+1)
+	for i := range bounds {
+		if interval == "week" {
+			bounds[i] = today.AddDate(0, 0, 7*i)
+		} else {
+			bounds[i] = time.Date(today.Year(), today.Month()+time.Month(i), 1, 0, 0, 0, 0, today.Location())
+		}
+	}
+	expensesPerPeriod := monthlyExpenses
+	if interval == "week" {
+		expensesPerPeriod = monthlyExpenses * 12 / 52
+	}
+
+	for _, event := range events {
+		// Anything due before the first period is already late and expected in the first one.
+		i := 0
+		for i < periods && !event.Date.Before(bounds[i+1]) {
+			i++
+		}
+		if i == periods {
+			continue
+		}
+		period := &forecast.Periods[i]
+		switch event.Kind {
+		case repositories.CashFlowReceivable:
+			period.Inflows.Receivables += event.Amount
+		case repositories.CashFlowTranche:
+			period.Inflows.PlannedTranches += event.Amount
+		case repositories.CashFlowDebtGiven:
+			period.Inflows.PersonalDebts += event.Amount
+		case repositories.CashFlowDebtTaken:
+			period.Outflows.PersonalDebts += event.Amount
+		case repositories.CashFlowDebtUnknown:
+			forecast.UnclassifiedDebts += event.Amount
+		}
+	}
+
+	forecast.UnclassifiedDebts = math.Round(forecast.UnclassifiedDebts*100) / 100
+	for i := range forecast.Periods {
+		period := &forecast.Periods[i]
+		period.Start = bounds[i].Format("2006-01-02")
+		period.End = bounds[i+1].Format("2006-01-02")
+		period.Outflows.RecurringExpenses = math.Round(expensesPerPeriod*100) / 100
+		period.Inflows.Total = period.Inflows.Receivables + period.Inflows.PlannedTranches + period.Inflows.PersonalDebts
+		period.Outflows.Total = period.Outflows.PersonalDebts + period.Outflows.RecurringExpenses
+		period.Net = period.Inflows.Total - period.Outflows.Total
+		balance += period.Net
+		period.Balance = math.Round(balance*100) / 100
+		if period.Balance < 0 {
+			period.Negative = true
+			forecast.NegativePeriods = append(forecast.NegativePeriods, period.Start)
+		}
+	}
+
+	return forecast, nil
+}
+
+// leaderboardSorts maps the sort_by values of the leaderboard to the metric they rank by.
+var leaderboardSorts = map[string]func(m models.LeaderboardMetrics) float64{
+	"closed_deals":      func(m models.LeaderboardMetrics) float64 { return float64(m.ClosedDeals) },
+	"revenue":           func(m models.LeaderboardMetrics) float64 { return m.Revenue },
+	"margin":            func(m models.LeaderboardMetrics) float64 { return m.Margin },
+	"tender_commission": func(m models.LeaderboardMetrics) float64 { return m.TenderCommission },
+	// A shorter cycle ranks higher; users without one rank last.
+	"avg_cycle_days": func(m models.LeaderboardMetrics) float64 {
+		if m.AvgCycleDays == nil {
+			return math.Inf(-1)
+		}
+		return -*m.AvgCycleDays
+	},
+	"collection_rate": func(m models.LeaderboardMetrics) float64 {
+		if m.CollectionRate == nil {
+			return math.Inf(-1)
+		}
+		return *m.CollectionRate
+	},
+}
+
+// Leaderboard ranks users by the deals they closed between start and end, comparing each
+// with the preceding period of the same length.
+func (s *ReportService) Leaderboard(ctx context.Context, startDate, endDate string, companyID *int, sortBy string) (models.Leaderboard, error) {
+	if sortBy == "" {
+		sortBy = "revenue"
+	}
+	metric, ok := leaderboardSorts[sortBy]
+	if !ok {
+		return models.Leaderboard{}, fmt.Errorf("%w: unknown sort_by %q", models.ErrInvalidReport, sortBy)
+	}
+	start, err := time.Parse("2006-01-02", startDate)
+	if err != nil {
+		return models.Leaderboard{}, fmt.Errorf("%w: start_date must be YYYY-MM-DD", models.ErrInvalidReport)
+	}
+	end, err := time.Parse("2006-01-02", endDate)
+	if err != nil {
+		return models.Leaderboard{}, fmt.Errorf("%w: end_date must be YYYY-MM-DD", models.ErrInvalidReport)
+	}
+	if end.Before(start) {
+		return models.Leaderboard{}, fmt.Errorf("%w: end_date is before start_date", models.ErrInvalidReport)
+	}
+	days := int(end.Sub(start).Hours()/24) + 1
+	previousEnd := start.AddDate(0, 0, -1)
+	previousStart := previousEnd.AddDate(0, 0, -(days - 1))
+
+	board := models.Leaderboard{
+		StartDate:     startDate,
+		EndDate:       endDate,
+		PreviousStart: previousStart.Format("2006-01-02"),
+		PreviousEnd:   previousEnd.Format("2006-01-02"),
+		CompanyID:     companyID,
+		SortBy:        sortBy,
+		Entries:       []models.LeaderboardEntry{},
+	}
+
+	current, err := s.Repo.GetUserPerformance(ctx, board.StartDate, board.EndDate, companyID)
+	if err != nil {
+		return models.Leaderboard{}, err
+	}
+	previous, err := s.Repo.GetUserPerformance(ctx, board.PreviousStart, board.PreviousEnd, companyID)
+	if err != nil {
+		return models.Leaderboard{}, err
+	}
+
+	previousRanks := map[int]int{}
+	previousMetrics := map[int]models.LeaderboardMetrics{}
+	for i, p := range rankPerformance(previous, metric) {
+		previousRanks[p.UserID] = i + 1
+		previousMetrics[p.UserID] = p.LeaderboardMetrics
+	}
+
+	for i, p := range rankPerformance(current, metric) {
+		entry := models.LeaderboardEntry{Rank: i + 1, UserID: p.UserID, UserName: p.UserName, LeaderboardMetrics: p.LeaderboardMetrics, Trend: "new"}
+		if rank, ok := previousRanks[p.UserID]; ok {
+			metrics := previousMetrics[p.UserID]
+			entry.Previous, entry.PreviousRank = &metrics, &rank
+			switch {
+			case entry.Rank < rank:
+				entry.Trend = "up"
+			case entry.Rank > rank:
+				entry.Trend = "down"
+			default:
+				entry.Trend = "same"
+			}
+		}
+		board.Entries = append(board.Entries, entry)
+	}
+
+	return board, nil
+}
+
+// rankPerformance fills in the collection rate and sorts users best first by metric.
+func rankPerformance(stats []repositories.UserPerformance, metric func(models.LeaderboardMetrics) float64) []repositories.UserPerformance {
+	for i := range stats {
+		if stats[i].Revenue > 0 {
+			rate := math.Round(stats[i].Paid/stats[i].Revenue*10000) / 10000
+			stats[i].CollectionRate = &rate
+		}
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		a, b := metric(stats[i].LeaderboardMetrics), metric(stats[j].LeaderboardMetrics)
+		if a != b {
+			return a > b
+		}
+		return stats[i].UserID < stats[j].UserID
+	})
+	return stats
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"tender/internal/repositories"
+	"testing"
+	"time"
+)
+
+// A receivable with neither a completed date nor a date is reported as undated, not as the
+// oldest debt of all.
+func TestAgingKeepsUndatedReceivablesApart(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	completed := time.Now().AddDate(0, 0, -45)
+	mock.ExpectQuery("FROM transactions t").WillReturnRows(sqlmock.NewRows([]string{"company_id", "name", "user_id",
+		"id", "tender_number", "product_name", "sell", "paid", "completed", "last_payment"}).
+		AddRow(3, "Acme", 1, 10, nil, nil, 500.0, 0.0, nil, nil).
+		AddRow(3, "Acme", 1, 11, nil, nil, 200.0, 50.0, completed, nil))
+
+	service := &ReportService{Repo: &repositories.ReportRepository{Db: db}}
+	report, err := service.Aging(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Totals.Undated != 500 || report.Totals.Days90Plus != 0 || report.Totals.Days31To60 != 150 ||
+		report.Totals.Total != 150 {
+		t.Errorf("totals %+v, want 500 undated and 150 in 31_60", report.Totals)
+	}
+	company := report.Companies[0]
+	undated := company.Items[0]
+	if undated.Bucket != "undated" || undated.CompletedDate != nil || undated.DaysOutstanding != nil {
+		t.Errorf("undated item %+v", undated)
+	}
+	if company.OldestUnpaid == nil || company.OldestUnpaid.TransactionID != 11 {
+		t.Errorf("oldest unpaid %+v, want transaction 11", company.OldestUnpaid)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
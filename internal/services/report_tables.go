@@ -0,0 +1,141 @@
+package services
+
+import (
+	"fmt"
+	"tender/internal/export"
+	"tender/internal/models"
+)
+
+// QueryTable flattens a pivot report: the company and user dimensions expand to an ID and a name,
+// and every measure is totalled.
+func QueryTable(result models.ReportResult) export.Table {
+	var columns []export.Column
+	for _, dimension := range result.Dimensions {
+		switch dimension {
+		case "company", "user":
+			columns = append(columns, export.Column{Key: dimension + "_id", Kind: export.Integer}, export.Column{Key: dimension + "_name"})
+		case "type":
+			columns = append(columns, export.Column{Key: dimension})
+		default:
+			columns = append(columns, export.Column{Key: dimension, Kind: export.Integer})
+		}
+	}
+	for _, measure := range result.Measures {
+		kind := export.Number
+		if measure == "count" {
+			kind = export.Integer
+		}
+		columns = append(columns, export.Column{Key: measure, Kind: kind, Total: true})
+	}
+
+	rows := make([][]interface{}, len(result.Rows))
+	for i, row := range result.Rows {
+		rows[i] = make([]interface{}, len(columns))
+		for j, column := range columns {
+			rows[i][j] = row[column.Key]
+		}
+	}
+	return export.Table{Name: "report", Columns: columns, Rows: rows}
+}
+
+// PnLTable lists the months of a P&L with commissions and personal expenses summed up.
+func PnLTable(report models.PnLReport) export.Table {
+	table := export.Table{
+		Name: fmt.Sprintf("pnl_%s_%s", report.StartDate, report.EndDate),
+		Columns: []export.Column{
+			{Key: "month"},
+			{Key: "revenue", Kind: export.Number, Total: true},
+			{Key: "cost", Kind: export.Number, Total: true},
+			{Key: "additional_expenses", Kind: export.Number, Total: true},
+			{Key: "gross_margin", Kind: export.Number, Total: true},
+			{Key: "tender_commissions", Kind: export.Number, Total: true},
+			{Key: "extra_transactions", Kind: export.Number, Total: true},
+			{Key: "personal_expenses", Kind: export.Number, Total: true},
+			{Key: "net_margin", Kind: export.Number, Total: true},
+		},
+	}
+	for _, month := range report.Months {
+		f := month.PnLFigures
+		table.Rows = append(table.Rows, []interface{}{month.Month, f.Revenue, f.Cost, f.AdditionalExpenses, f.GrossMargin,
+			f.TenderCommissionTotal(), f.ExtraTransactions, f.PersonalExpenseTotal(), f.NetMargin})
+	}
+	return table
+}
+
+// AgingTable lists every unpaid transaction with its company.
+func AgingTable(report models.AgingReport) export.Table {
+	table := export.Table{
+		Name: "aging",
+		Columns: []export.Column{
+			{Key: "company"},
+			{Key: "transaction_id", Kind: export.Integer},
+			{Key: "tender_number"},
+			{Key: "product_name"},
+			{Key: "completed_date", Kind: export.Date},
+			{Key: "days_outstanding", Kind: export.Integer},
+			{Key: "bucket"},
+			{Key: "sell", Kind: export.Number, Total: true},
+			{Key: "paid", Kind: export.Number, Total: true},
+			{Key: "outstanding", Kind: export.Number, Total: true},
+			{Key: "last_payment_date", Kind: export.Date},
+		},
+	}
+	for _, company := range report.Companies {
+		for _, item := range company.Items {
+			table.Rows = append(table.Rows, []interface{}{company.CompanyName, item.TransactionID, item.TenderNumber,
+				item.ProductName, item.CompletedDate, item.DaysOutstanding, item.Bucket, item.Sell, item.Paid,
+				item.Outstanding, item.LastPaymentDate})
+		}
+	}
+	return table
+}
+
+// CashFlowTable lists the periods of a forecast.
+func CashFlowTable(forecast models.CashFlowForecast) export.Table {
+	table := export.Table{
+		Name: "cashflow",
+		Columns: []export.Column{
+			{Key: "start", Kind: export.Date},
+			{Key: "end", Kind: export.Date},
+			{Key: "receivables", Kind: export.Number, Total: true},
+			{Key: "planned_tranches", Kind: export.Number, Total: true},
+			{Key: "debts_in", Kind: export.Number, Total: true},
+			{Key: "debts_out", Kind: export.Number, Total: true},
+			{Key: "recurring_expenses", Kind: export.Number, Total: true},
+			{Key: "inflows", Kind: export.Number, Total: true},
+			{Key: "outflows", Kind: export.Number, Total: true},
+			{Key: "net", Kind: export.Number, Total: true},
+			{Key: "balance", Kind: export.Number},
+		},
+	}
+	for _, p := range forecast.Periods {
+		table.Rows = append(table.Rows, []interface{}{p.Start, p.End, p.Inflows.Receivables, p.Inflows.PlannedTranches,
+			p.Inflows.PersonalDebts, p.Outflows.PersonalDebts, p.Outflows.RecurringExpenses, p.Inflows.Total,
+			p.Outflows.Total, p.Net, p.Balance})
+	}
+	return table
+}
+
+// LeaderboardTable lists the ranked users.
+func LeaderboardTable(board models.Leaderboard) export.Table {
+	table := export.Table{
+		Name: "leaderboard",
+		Columns: []export.Column{
+			{Key: "rank", Kind: export.Integer},
+			{Key: "user_name"},
+			{Key: "closed_deals", Kind: export.Integer, Total: true},
+			{Key: "revenue", Kind: export.Number, Total: true},
+			{Key: "margin", Kind: export.Number, Total: true},
+			{Key: "avg_cycle_days", Kind: export.Number},
+			{Key: "collection_rate", Kind: export.Number},
+			{Key: "tender_commission", Kind: export.Number, Total: true},
+			{Key: "previous_rank", Kind: export.Integer},
+			{Key: "trend"},
+		},
+	}
+	for _, e := range board.Entries {
+		table.Rows = append(table.Rows, []interface{}{e.Rank, e.UserName, e.ClosedDeals, e.Revenue, e.Margin,
+			e.AvgCycleDays, e.CollectionRate, e.TenderCommission, e.PreviousRank, e.Trend})
+	}
+	return table
+}
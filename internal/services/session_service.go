@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"tender/internal/repositories"
+	"time"
+)
+
+// SessionService issues the bearer tokens that authenticate callers after they log in.
+type SessionService struct {
+	Repo *repositories.SessionRepository
+	TTL  time.Duration
+}
+
+// Start opens a session for userID and returns its token, which is not stored.
+func (s *SessionService) Start(ctx context.Context, userID int) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+	if err := s.Repo.Create(ctx, userID, tokenHash(token), time.Now().Add(s.TTL)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Authenticate returns the user a token was issued to, or models.ErrSessionNotFound when it is
+// unknown or expired.
+func (s *SessionService) Authenticate(ctx context.Context, token string) (int, error) {
+	return s.Repo.UserID(ctx, tokenHash(token), time.Now())
+}
+
+// End closes the session of a token.
+func (s *SessionService) End(ctx context.Context, token string) error {
+	return s.Repo.Delete(ctx, tokenHash(token))
+}
+
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"tender/internal/models"
+	"tender/internal/repositories"
+	"time"
+)
+
+// Change stream tuning.
+const (
+	streamBufferSize = 1000 // events kept for clients reconnecting with Last-Event-ID
+	streamBatchSize  = 500  // audit entries read per poll
+	streamQueueSize  = 64   // events a connection may fall behind before it is dropped
+)
+
+// entitiesVisibleToAll lists reference data every user sees changes of.
+var entitiesVisibleToAll = map[string]bool{"company": true, "category": true, "balance_category": true}
+
+// StreamService follows the audit log and fans its entries out to connected clients as change
+// events. Poll reads new entries; Subscribe attaches a client.
+type StreamService struct {
+	Repo        *repositories.AuditRepository
+	Permissions *repositories.PermissionRepository
+
+	mu          sync.Mutex
+	closed      bool
+	started     bool
+	follower    auditFollower
+	start       int64                // newest event when the stream started
+	evicted     bool                 // whether events have been dropped from buffer
+	buffer      []models.ChangeEvent // in the order they were sent, which is not always ID order
+	subscribers map[chan models.ChangeEvent]struct{}
+}
+
+// Poll reads the audit entries written since the last poll, buffers them and sends them to every
+// subscriber. An entry committed after one with a higher ID is sent when it turns up. The first
+// poll only notes where the log ends. A subscriber too slow to keep up is dropped; its client
+// reconnects and replays what it missed from the buffer.
+func (s *StreamService) Poll(ctx context.Context) error {
+	s.mu.Lock()
+	started := s.started
+	now := time.Now()
+	after := s.follower.from(now)
+	s.mu.Unlock()
+
+	if !started {
+		latest, err := s.Repo.LatestID(ctx)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		s.started, s.follower.lastID, s.start = true, latest, latest
+		s.mu.Unlock()
+		return nil
+	}
+
+	for {
+		changes, err := s.Repo.ChangesAfter(ctx, after, streamBatchSize)
+		if err != nil || len(changes) == 0 {
+			return err
+		}
+		s.broadcast(changes, now)
+		after = changes[len(changes)-1].ID
+		if len(changes) < streamBatchSize {
+			return nil
+		}
+	}
+}
+
+func (s *StreamService) broadcast(read []models.AuditChange, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fresh := s.follower.fresh(read, now)
+	if len(fresh) == 0 {
+		return
+	}
+	changes := make([]models.ChangeEvent, len(fresh))
+	for i, c := range fresh {
+		changes[i] = c.ChangeEvent
+	}
+
+	s.buffer = append(s.buffer, changes...)
+	if excess := len(s.buffer) - streamBufferSize; excess > 0 {
+		s.evicted = true
+		s.buffer = append([]models.ChangeEvent(nil), s.buffer[excess:]...)
+	}
+
+	for ch := range s.subscribers {
+		for _, e := range changes {
+			select {
+			case ch <- e:
+				continue
+			default:
+			}
+			delete(s.subscribers, ch)
+			close(ch)
+			break
+		}
+	}
+}
+
+// Subscribe attaches a client that has seen every event up to lastEventID, the last one it
+// received, or a new client when it is 0. It returns the buffered events the client missed and a channel of the events that
+// follow, which is closed when the client falls behind. complete is false when events the client
+// missed are no longer buffered, so it must reload its data instead. The client calls unsubscribe
+// when it disconnects.
+func (s *StreamService) Subscribe(lastEventID int64) (missed []models.ChangeEvent, complete bool, events <-chan models.ChangeEvent, unsubscribe func()) {
+	ch := make(chan models.ChangeEvent, streamQueueSize)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subscribers == nil {
+		s.subscribers = map[chan models.ChangeEvent]struct{}{}
+	}
+	if s.closed {
+		close(ch)
+	} else {
+		s.subscribers[ch] = struct{}{}
+	}
+
+	complete = true
+	if lastEventID > 0 {
+		seen := -1
+		for i, e := range s.buffer {
+			if e.ID == lastEventID {
+				seen = i
+				break
+			}
+		}
+		switch {
+		case seen >= 0:
+			missed = append(missed, s.buffer[seen+1:]...)
+		case s.started && !s.evicted && lastEventID == s.start:
+			missed = append(missed, s.buffer...)
+		default:
+			complete = false
+		}
+	}
+
+	return missed, complete, ch, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, ok := s.subscribers[ch]; ok {
+			delete(s.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Close ends every subscription, so that open streams return when the server shuts down.
+func (s *StreamService) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	for ch := range s.subscribers {
+		delete(s.subscribers, ch)
+		close(ch)
+	}
+}
+
+// StreamViewer is a connected user and the companies they hold a permission on.
+type StreamViewer struct {
+	UserID    int
+	Companies map[int]bool
+}
+
+// Viewer loads who userID is for the stream's permission filtering.
+func (s *StreamService) Viewer(ctx context.Context, userID int) (StreamViewer, error) {
+	viewer := StreamViewer{UserID: userID, Companies: map[int]bool{}}
+	if userID == models.AdminUserID {
+		return viewer, nil
+	}
+	permissions, err := s.Permissions.GetPermissionsByUserID(ctx, userID)
+	if err != nil {
+		return viewer, err
+	}
+	for _, p := range permissions {
+		if p.Status != nil && *p.Status == 1 {
+			viewer.Companies[p.CompanyID] = true
+		}
+	}
+	return viewer, nil
+}
+
+// CanSee reports whether the viewer may see a change: admins see everything, other users the
+// records they own, those of companies they hold a permission on, and reference data.
+func (v StreamViewer) CanSee(e models.ChangeEvent) bool {
+	switch {
+	case v.UserID == models.AdminUserID, entitiesVisibleToAll[e.Entity]:
+		return true
+	case e.UserID != nil && *e.UserID == v.UserID:
+		return true
+	case e.Entity == "permission" || e.Entity == "user":
+		return false
+	}
+	return e.CompanyID != nil && v.Companies[*e.CompanyID]
+}
+
+// AffectsPermissions reports whether a change may alter which companies the viewer can see.
+func (v StreamViewer) AffectsPermissions(e models.ChangeEvent) bool {
+	return e.Entity == "permission" && e.UserID != nil && *e.UserID == v.UserID
+}
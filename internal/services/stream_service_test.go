@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"tender/internal/models"
+	"tender/internal/repositories"
+	"testing"
+)
+
+func changeRows(ids ...int64) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "entity", "entity_id", "action", "actor_id", "user_id", "company_id",
+		"created_at", "changes"})
+	for _, id := range ids {
+		rows.AddRow(id, "company", 3, models.AuditCreate, nil, nil, 3, "2026-01-01", `{}`)
+	}
+	return rows
+}
+
+func eventIDs(events []models.ChangeEvent) []int64 {
+	ids := make([]int64, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+// An audit entry committed after one with a higher ID is still sent, and replayed to a client
+// that had already seen the higher one.
+func TestStreamSendsChangesCommittedOutOfOrder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	s := &StreamService{Repo: &repositories.AuditRepository{Db: db}}
+	ctx := context.Background()
+
+	mock.ExpectQuery("SELECT COALESCE\\(MAX\\(id\\), 0\\) FROM audit_log").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+	mock.ExpectQuery("FROM audit_log").WithArgs(int64(10), streamBatchSize).WillReturnRows(changeRows(11, 13))
+	for i := 0; i < 2; i++ {
+		if err := s.Poll(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, _, events, unsubscribe := s.Subscribe(0)
+	defer unsubscribe()
+
+	mock.ExpectQuery("FROM audit_log").WithArgs(int64(11), streamBatchSize).WillReturnRows(changeRows(12, 13))
+	if err := s.Poll(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if e := <-events; e.ID != 12 {
+		t.Fatalf("sent event %d, want 12", e.ID)
+	}
+	select {
+	case e := <-events:
+		t.Fatalf("sent event %d again", e.ID)
+	default:
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	missed, complete, _, unsubscribe := s.Subscribe(13)
+	defer unsubscribe()
+	if ids := eventIDs(missed); !complete || len(ids) != 1 || ids[0] != 12 {
+		t.Errorf("replayed %v (complete %v) after event 13, want [12]", ids, complete)
+	}
+	if _, complete, _, unsubscribe := s.Subscribe(5); complete {
+		t.Error("replay from before the stream started is complete")
+	} else {
+		unsubscribe()
+	}
+}
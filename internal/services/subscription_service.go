@@ -0,0 +1,303 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	netmail "net/mail"
+	"strings"
+	"tender/internal/export"
+	"tender/internal/mail"
+	"tender/internal/models"
+	"tender/internal/repositories"
+	"tender/internal/schedule"
+	"time"
+)
+
+// subscriptionReports maps the reports that can be subscribed to to their email titles.
+var subscriptionReports = map[string]string{
+	"pnl":         "Прибыли и убытки",
+	"aging":       "Дебиторская задолженность",
+	"cashflow":    "Прогноз движения денежных средств",
+	"leaderboard": "Рейтинг сотрудников",
+}
+
+// adminReports are business-wide; only the admin may subscribe to them.
+var adminReports = map[string]bool{"cashflow": true, "leaderboard": true}
+
+// maxSubscriptionRuns bounds the run history returned for a subscription.
+const maxSubscriptionRuns = 100
+
+type SubscriptionService struct {
+	Repo    *repositories.SubscriptionRepository
+	Reports *ReportService
+	Users   *UserService
+	Mailer  mail.Sender
+}
+
+// Create validates and stores a subscription of the given user, scheduling its first run.
+func (s *SubscriptionService) Create(ctx context.Context, sub models.ReportSubscription) (models.ReportSubscription, error) {
+	if _, ok := subscriptionReports[sub.Report]; !ok {
+		return sub, fmt.Errorf("%w: report must be pnl, aging, cashflow or leaderboard", models.ErrInvalidSubscription)
+	}
+	if sub.Format == "" {
+		sub.Format = string(export.XLSX)
+	}
+	if sub.Format != string(export.CSV) && sub.Format != string(export.XLSX) {
+		return sub, fmt.Errorf("%w: format must be csv or xlsx", models.ErrInvalidSubscription)
+	}
+	sched, err := schedule.Parse(sub.Schedule)
+	if err != nil {
+		return sub, fmt.Errorf("%w: %v", models.ErrInvalidSubscription, err)
+	}
+	next := sched.Next(time.Now())
+	if next.IsZero() {
+		return sub, fmt.Errorf("%w: schedule never runs", models.ErrInvalidSubscription)
+	}
+	if err := validateSubscriptionFilters(sub.Filters); err != nil {
+		return sub, err
+	}
+	user, err := s.Users.GetUserByID(ctx, sub.UserID)
+	if err != nil {
+		return sub, err
+	}
+	if sub.Email == "" {
+		sub.Email = user.Email
+	}
+	if err := authorizeSubscription(&sub, user.Email); err != nil {
+		return sub, err
+	}
+	sub.Active = true
+
+	id, err := s.Repo.Create(ctx, sub, next)
+	if err != nil {
+		return sub, err
+	}
+	return s.Repo.GetByID(ctx, id)
+}
+
+// authorizeSubscription limits what a subscription may send where. The admin may send any
+// report to any address; other users only receive their own figures, at their own address.
+func authorizeSubscription(sub *models.ReportSubscription, ownEmail string) error {
+	if sub.UserID == models.AdminUserID {
+		address, err := netmail.ParseAddress(sub.Email)
+		if err != nil || address.Address != sub.Email {
+			return fmt.Errorf("%w: invalid email", models.ErrInvalidSubscription)
+		}
+		return nil
+	}
+	if !strings.EqualFold(sub.Email, ownEmail) {
+		return fmt.Errorf("%w: reports can only be sent to your own email", models.ErrInvalidSubscription)
+	}
+	if adminReports[sub.Report] {
+		return fmt.Errorf("%w: only the admin can subscribe to the %s report", models.ErrInvalidSubscription, sub.Report)
+	}
+	if sub.Filters.UserID != nil && *sub.Filters.UserID != sub.UserID {
+		return fmt.Errorf("%w: user_id must be your own", models.ErrInvalidSubscription)
+	}
+	sub.Filters.UserID = &sub.UserID
+	return nil
+}
+
+func validateSubscriptionFilters(f models.SubscriptionFilters) error {
+	if _, _, err := subscriptionPeriod(f.Period, time.Now()); err != nil {
+		return err
+	}
+	if f.Interval != "" && f.Interval != "week" && f.Interval != "month" {
+		return fmt.Errorf("%w: interval must be week or month", models.ErrInvalidSubscription)
+	}
+	if f.Periods < 0 {
+		return fmt.Errorf("%w: periods must be positive", models.ErrInvalidSubscription)
+	}
+	if _, ok := leaderboardSorts[f.SortBy]; f.SortBy != "" && !ok {
+		return fmt.Errorf("%w: unknown sort_by %q", models.ErrInvalidSubscription, f.SortBy)
+	}
+	return nil
+}
+
+func (s *SubscriptionService) List(ctx context.Context, userID int) ([]models.ReportSubscription, error) {
+	return s.Repo.ListByUser(ctx, userID)
+}
+
+// get returns a subscription of userID; other users' subscriptions are reported as not found.
+func (s *SubscriptionService) get(ctx context.Context, id, userID int) (models.ReportSubscription, error) {
+	sub, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return sub, err
+	}
+	if sub.UserID != userID {
+		return models.ReportSubscription{}, models.ErrSubscriptionNotFound
+	}
+	return sub, nil
+}
+
+func (s *SubscriptionService) Delete(ctx context.Context, id, userID int) error {
+	if _, err := s.get(ctx, id, userID); err != nil {
+		return err
+	}
+	return s.Repo.Delete(ctx, id)
+}
+
+// Runs returns the latest deliveries of one of userID's subscriptions.
+func (s *SubscriptionService) Runs(ctx context.Context, id, userID int) ([]models.SubscriptionRun, error) {
+	if _, err := s.get(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	return s.Repo.ListRuns(ctx, id, maxSubscriptionRuns)
+}
+
+// RunDue delivers every subscription due at now and returns how many were sent. A failed delivery
+// is recorded in the run history and not retried before the subscription's next run.
+func (s *SubscriptionService) RunDue(ctx context.Context, now time.Time) (int, error) {
+	subs, err := s.Repo.Due(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	var errs []error
+	for _, sub := range subs {
+		sched, err := schedule.Parse(sub.Schedule)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("subscription %d: %w", sub.ID, err))
+			continue
+		}
+		due, err := time.Parse(time.RFC3339, sub.NextRunAt)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("subscription %d: %w", sub.ID, err))
+			continue
+		}
+		// Claiming first makes sure a run is delivered once even when several servers poll.
+		claimed, err := s.Repo.Claim(ctx, sub.ID, due, sched.Next(now), now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("subscription %d: %w", sub.ID, err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		started := time.Now()
+		err = s.refreshRecipient(ctx, &sub)
+		run := models.SubscriptionRun{SubscriptionID: sub.ID, Status: "sent", Recipient: sub.Email}
+		if err == nil {
+			err = s.deliver(ctx, sub, now)
+		}
+		if err != nil {
+			message := err.Error()
+			run.Status, run.Error = "failed", &message
+			errs = append(errs, fmt.Errorf("subscription %d: %w", sub.ID, err))
+		} else {
+			sent++
+		}
+		if err := s.Repo.RecordRun(ctx, run, started, time.Now()); err != nil {
+			errs = append(errs, fmt.Errorf("subscription %d: recording run: %w", sub.ID, err))
+		}
+	}
+	return sent, errors.Join(errs...)
+}
+
+// refreshRecipient sends a user's reports to their current email, which may have changed since
+// they subscribed.
+func (s *SubscriptionService) refreshRecipient(ctx context.Context, sub *models.ReportSubscription) error {
+	if sub.UserID == models.AdminUserID {
+		return nil
+	}
+	user, err := s.Users.GetUserByID(ctx, sub.UserID)
+	if err != nil {
+		return err
+	}
+	sub.Email = user.Email
+	return nil
+}
+
+// deliver renders a subscription's report as of now and emails it as an attachment.
+func (s *SubscriptionService) deliver(ctx context.Context, sub models.ReportSubscription, now time.Time) error {
+	if err := authorizeSubscription(&sub, sub.Email); err != nil {
+		return err
+	}
+	table, period, err := s.render(ctx, sub, now)
+	if err != nil {
+		return err
+	}
+
+	format := export.Format(sub.Format)
+	var file bytes.Buffer
+	// The attachment is in Russian, like the email it comes with.
+	if err := export.WriteTable(&file, format, export.LocaleNamed("ru"), table); err != nil {
+		return err
+	}
+
+	title := subscriptionReports[sub.Report]
+	return s.Mailer.Send(ctx, mail.Message{
+		To:      []string{sub.Email},
+		Subject: fmt.Sprintf("%s: %s", title, period),
+		HTML: fmt.Sprintf("<p><b>%s</b>, %s</p><p>Отчёт во вложении.</p>",
+			html.EscapeString(title), html.EscapeString(period)),
+		Attachments: []mail.Attachment{{
+			Filename:    format.Filename(sub.Report),
+			ContentType: format.ContentType(),
+			Data:        file.Bytes(),
+		}},
+	})
+}
+
+// render builds a subscription's report and describes the period it covers.
+func (s *SubscriptionService) render(ctx context.Context, sub models.ReportSubscription, now time.Time) (export.Table, string, error) {
+	f := sub.Filters
+	start, end, err := subscriptionPeriod(f.Period, now)
+	if err != nil {
+		return export.Table{}, "", err
+	}
+	startDate, endDate := start.Format("2006-01-02"), end.Format("2006-01-02")
+	period := start.Format("02.01.2006") + " – " + end.Format("02.01.2006")
+	asOf := "на " + now.Format("02.01.2006")
+
+	switch sub.Report {
+	case "pnl":
+		report, err := s.Reports.ProfitAndLoss(ctx, models.PnLFilter{
+			StartDate: startDate, EndDate: endDate, CompanyID: f.CompanyID, UserID: f.UserID,
+		})
+		return PnLTable(report), period, err
+	case "aging":
+		report, err := s.Reports.Aging(ctx, f.UserID)
+		return AgingTable(report), asOf, err
+	case "cashflow":
+		interval, periods := f.Interval, f.Periods
+		if interval == "" {
+			interval = "week"
+		}
+		if periods == 0 {
+			periods = 12
+			if interval == "month" {
+				periods = 6
+			}
+		}
+		forecast, err := s.Reports.CashFlowForecast(ctx, interval, periods)
+		return CashFlowTable(forecast), asOf, err
+	case "leaderboard":
+		board, err := s.Reports.Leaderboard(ctx, startDate, endDate, f.CompanyID, f.SortBy)
+		return LeaderboardTable(board), period, err
+	}
+	return export.Table{}, "", fmt.Errorf("%w: unknown report %q", models.ErrInvalidSubscription, sub.Report)
+}
+
+// subscriptionPeriod returns the first and last day of a relative period ending at now.
+func subscriptionPeriod(period string, now time.Time) (time.Time, time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch period {
+	case "", "previous_week":
+		monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+		return monday.AddDate(0, 0, -7), monday.AddDate(0, 0, -1), nil
+	case "previous_month":
+		first := today.AddDate(0, 0, 1-today.Day())
+		return first.AddDate(0, -1, 0), first.AddDate(0, 0, -1), nil
+	case "month_to_date":
+		return today.AddDate(0, 0, 1-today.Day()), today, nil
+	case "year_to_date":
+		return time.Date(today.Year(), 1, 1, 0, 0, 0, 0, today.Location()), today, nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("%w: period must be previous_week, previous_month, month_to_date or year_to_date", models.ErrInvalidSubscription)
+}
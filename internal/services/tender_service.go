@@ -2,12 +2,14 @@ package services
 
 import (
 	"context"
+	"tender/internal/events"
 	"tender/internal/models"
 	"tender/internal/repositories"
 )
 
 type TenderService struct {
-	Repo *repositories.TenderRepository
+	Repo   *repositories.TenderRepository
+	Events *events.Bus
 }
 
 // CreateTender creates a new tender.
@@ -28,7 +30,60 @@ func (s *TenderService) DeleteTender(ctx context.Context, id int) error {
 
 // UpdateTender updates an existing tender.
 func (s *TenderService) UpdateTender(ctx context.Context, tender models.Tender) (models.Tender, error) {
-	return s.Repo.UpdateTender(ctx, tender)
+	previous, lookupErr := s.Repo.GetTenderByID(ctx, tender.ID)
+	updated, err := s.Repo.UpdateTender(ctx, tender)
+	if err != nil {
+		return models.Tender{}, err
+	}
+	if lookupErr == nil {
+		s.publishCompletion(ctx, previous.Status, updated)
+	}
+	return updated, nil
+}
+
+// PatchTender applies a JSON merge patch to a tender. When version is zero the
+// version that was read is enforced.
+func (s *TenderService) PatchTender(ctx context.Context, id, version int, patch []byte) (models.Tender, error) {
+	tender, err := s.Repo.GetTenderByID(ctx, id)
+	if err != nil {
+		return models.Tender{}, err
+	}
+	if version == 0 {
+		version = tender.Version
+	}
+	previousStatus := tender.Status
+
+	if err := applyMergePatch(&tender, patch); err != nil {
+		return models.Tender{}, err
+	}
+	tender.ID = id
+	tender.Version = version
+
+	updated, err := s.Repo.ReplaceTender(ctx, tender)
+	if err != nil {
+		return models.Tender{}, err
+	}
+	s.publishCompletion(ctx, previousStatus, updated)
+	return updated, nil
+}
+
+// publishCompletion announces a tender that has just become completed.
+func (s *TenderService) publishCompletion(ctx context.Context, previous int, tender models.Tender) {
+	if previous == models.DealCompleted || tender.Status != models.DealCompleted {
+		return
+	}
+	s.Events.Publish(ctx, events.Event{
+		Type:      events.TenderCompleted,
+		UserID:    &tender.UserID,
+		CompanyID: &tender.CompanyID,
+		EntityID:  tender.ID,
+		Data: map[string]interface{}{
+			"number":       derefOr(tender.TenderNumber, ""),
+			"organization": tender.Organization,
+			"total":        tender.Total,
+			"commission":   tender.Commission,
+		},
+	})
 }
 
 // GetTenderByID retrieves a tender by ID.
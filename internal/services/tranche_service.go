@@ -2,16 +2,45 @@ package services
 
 import (
 	"context"
+	"log"
+	"tender/internal/events"
 	"tender/internal/models"
 	"tender/internal/repositories"
 )
 
 type TrancheService struct {
-	Repo *repositories.TrancheRepository
+	Repo         *repositories.TrancheRepository
+	Transactions *repositories.TransactionRepository
+	Events       *events.Bus
 }
 
+// CreateTranche records a payment received for a transaction.
 func (s *TrancheService) CreateTranche(ctx context.Context, tranche *models.Tranche) (int, error) {
-	return s.Repo.CreateTranche(ctx, tranche)
+	id, err := s.Repo.CreateTranche(ctx, tranche)
+	if err != nil {
+		return 0, err
+	}
+
+	if s.Events != nil {
+		transaction, err := s.Transactions.GetTransactionByID(ctx, tranche.TransactionID)
+		if err != nil {
+			log.Printf("Error loading transaction %d of tranche %d: %v", tranche.TransactionID, id, err)
+			return id, nil
+		}
+		s.Events.Publish(ctx, events.Event{
+			Type:      events.TrancheReceived,
+			UserID:    transaction.UserID,
+			CompanyID: transaction.CompanyID,
+			EntityID:  id,
+			Data: map[string]interface{}{
+				"transaction_id": transaction.ID,
+				"number":         derefOr(transaction.TransactionNumber, ""),
+				"product_name":   transaction.ProductName,
+				"amount":         tranche.Amount,
+			},
+		})
+	}
+	return id, nil
 }
 
 func (s *TrancheService) GetTrancheByID(ctx context.Context, id int) (*models.Tranche, error) {
@@ -22,6 +51,25 @@ func (s *TrancheService) UpdateTranche(ctx context.Context, tranche *models.Tran
 	return s.Repo.UpdateTranche(ctx, tranche)
 }
 
+// PatchTranche applies a JSON merge patch to a tranche. It returns nil when the tranche does not exist.
+func (s *TrancheService) PatchTranche(ctx context.Context, id, version int, patch []byte) (*models.Tranche, error) {
+	tranche, err := s.Repo.GetTrancheByID(ctx, id)
+	if err != nil || tranche == nil {
+		return nil, err
+	}
+	if version == 0 {
+		version = tranche.Version
+	}
+
+	if err := applyMergePatch(tranche, patch); err != nil {
+		return nil, err
+	}
+	tranche.ID = id
+	tranche.Version = version
+
+	return s.Repo.UpdateTranche(ctx, tranche)
+}
+
 func (s *TrancheService) DeleteTranche(ctx context.Context, id int) error {
 	return s.Repo.DeleteTranche(ctx, id)
 }
@@ -2,13 +2,17 @@ package services
 
 import (
 	"context"
+	"tender/internal/events"
 	"tender/internal/models"
 	"tender/internal/repositories"
+	"time"
 )
 
 type TransactionService struct {
 	Repo                 *repositories.TransactionRepository
 	ExtraTransactionRepo *repositories.ExtraTransactionRepository
+	Reports              *repositories.ReportRepository
+	Events               *events.Bus
 }
 
 type CombinedTransactions struct {
@@ -66,7 +70,60 @@ func (s *TransactionService) GetTransactionsDebt(ctx context.Context, transactio
 
 // UpdateTransaction updates an existing transaction and its expenses.
 func (s *TransactionService) UpdateTransaction(ctx context.Context, transaction models.Transaction) (models.Transaction, error) {
-	return s.Repo.UpdateTransaction(ctx, transaction)
+	previous, lookupErr := s.Repo.GetTransactionByID(ctx, transaction.ID)
+	updated, err := s.Repo.UpdateTransaction(ctx, transaction)
+	if err != nil {
+		return models.Transaction{}, err
+	}
+	if lookupErr == nil {
+		s.publishStatusChange(ctx, previous.Status, updated)
+	}
+	return updated, nil
+}
+
+// PatchTransaction applies a JSON merge patch to a transaction. When version is zero the
+// version that was read is enforced, so a concurrent write in between is still detected.
+func (s *TransactionService) PatchTransaction(ctx context.Context, id, version int, patch []byte) (models.Transaction, error) {
+	transaction, err := s.Repo.GetTransactionByID(ctx, id)
+	if err != nil {
+		return models.Transaction{}, err
+	}
+	if version == 0 {
+		version = transaction.Version
+	}
+	previousStatus := transaction.Status
+
+	if err := applyMergePatch(&transaction, patch); err != nil {
+		return models.Transaction{}, err
+	}
+	transaction.ID = id
+	transaction.Version = version
+
+	updated, err := s.Repo.ReplaceTransaction(ctx, transaction)
+	if err != nil {
+		return models.Transaction{}, err
+	}
+	s.publishStatusChange(ctx, previousStatus, updated)
+	return updated, nil
+}
+
+func (s *TransactionService) publishStatusChange(ctx context.Context, previous int, transaction models.Transaction) {
+	if previous == transaction.Status {
+		return
+	}
+	s.Events.Publish(ctx, events.Event{
+		Type:      events.TransactionStatusChanged,
+		UserID:    transaction.UserID,
+		CompanyID: transaction.CompanyID,
+		EntityID:  transaction.ID,
+		Data: map[string]interface{}{
+			"number":          derefOr(transaction.TransactionNumber, ""),
+			"product_name":    transaction.ProductName,
+			"previous_status": previous,
+			"status":          transaction.Status,
+			"completed":       transaction.Status == models.DealCompleted,
+		},
+	})
 }
 
 // DeleteTransaction deletes a transaction and its expenses by ID.
@@ -74,72 +131,157 @@ func (s *TransactionService) DeleteTransaction(ctx context.Context, id int) erro
 	return s.Repo.DeleteTransaction(ctx, id)
 }
 
+// The /reports endpoints predate the report engine and are kept as fixed queries over it.
+
 func (s *TransactionService) GetMonthlyAmountsByGlobal(ctx context.Context) ([]repositories.YearlyAmounts, error) {
-	return s.Repo.GetMonthlyAmountsByGlobal(ctx)
+	return s.yearlyAmounts(ctx, models.ReportFilters{})
 }
 
 func (s *TransactionService) GetMonthlyAmountsByYear(ctx context.Context, year int) ([]repositories.MonthlyAmount, error) {
-	return s.Repo.GetMonthlyAmountsByYear(ctx, year)
+	return s.monthlyAmounts(ctx, models.ReportFilters{Year: &year})
 }
 
 func (s *TransactionService) GetMonthlyAmountsByCompany(ctx context.Context, companyID int) ([]repositories.MonthlyAmount, error) {
-	return s.Repo.GetMonthlyAmountsByCompany(ctx, companyID)
+	return s.monthlyAmounts(ctx, models.ReportFilters{CompanyID: &companyID})
 }
 
 func (s *TransactionService) GetMonthlyAmountsByYearAndCompany(ctx context.Context, year int, companyID int) ([]repositories.MonthlyAmount, error) {
-	return s.Repo.GetMonthlyAmountsByYearAndCompany(ctx, year, companyID)
+	return s.monthlyAmounts(ctx, models.ReportFilters{Year: &year, CompanyID: &companyID})
 }
 
 func (s *TransactionService) GetMonthlyAmountsGroupedByYear(ctx context.Context) ([]repositories.YearlyAmounts, error) {
-	return s.Repo.GetMonthlyAmountsGroupedByYear(ctx)
+	return s.yearlyAmounts(ctx, models.ReportFilters{})
 }
 
 func (s *TransactionService) GetMonthlyAmountsGroupedByYearForUser(ctx context.Context, userID int) ([]repositories.YearlyAmounts, error) {
-	return s.Repo.GetMonthlyAmountsGroupedByYearForUser(ctx, userID)
+	return s.yearlyAmounts(ctx, models.ReportFilters{UserID: &userID})
 }
 
 func (s *TransactionService) GetMonthlyAmountsForUserByYear(ctx context.Context, userID int, year int) ([]repositories.MonthlyAmount, error) {
-	return s.Repo.GetMonthlyAmountsForUserByYear(ctx, userID, year)
+	return s.monthlyAmounts(ctx, models.ReportFilters{UserID: &userID, Year: &year})
 }
 
 func (s *TransactionService) GetMonthlyAmountsForUserByYearAndCompany(ctx context.Context, userID int, year int, companyID int) ([]repositories.MonthlyAmount, error) {
-	return s.Repo.GetMonthlyAmountsForUserByYearAndCompany(ctx, userID, year, companyID)
+	return s.monthlyAmounts(ctx, models.ReportFilters{UserID: &userID, Year: &year, CompanyID: &companyID})
 }
 
 func (s *TransactionService) GetTotalAmountGroupedByCompany(ctx context.Context) ([]repositories.CompanyTotalAmount, error) {
-	return s.Repo.GetTotalAmountGroupedByCompany(ctx)
+	return s.companyTotals(ctx, models.ReportFilters{})
 }
 
 func (s *TransactionService) GetTotalAmountByCompanyForYear(ctx context.Context, year int) ([]repositories.CompanyTotalAmount, error) {
-	return s.Repo.GetTotalAmountByCompanyForYear(ctx, year)
+	return s.companyTotals(ctx, models.ReportFilters{Year: &year})
 }
 
 func (s *TransactionService) GetTotalAmountByCompanyForMonth(ctx context.Context, month int) ([]repositories.CompanyTotalAmount, error) {
-	return s.Repo.GetTotalAmountByCompanyForMonth(ctx, month)
+	return s.companyTotals(ctx, models.ReportFilters{Month: &month})
 }
 
 func (s *TransactionService) GetTotalAmountByCompanyForYearAndMonth(ctx context.Context, year int, month int) ([]repositories.CompanyTotalAmount, error) {
-	return s.Repo.GetTotalAmountByCompanyForYearAndMonth(ctx, year, month)
+	return s.companyTotals(ctx, models.ReportFilters{Year: &year, Month: &month})
 }
 
 func (s *TransactionService) GetTotalAmountGroupedByCompanyForUsers(ctx context.Context) ([]repositories.CompanyTotalAmount, error) {
-	return s.Repo.GetTotalAmountGroupedByCompany(ctx)
+	return s.companyTotals(ctx, models.ReportFilters{})
 }
 
 func (s *TransactionService) GetTotalAmountByCompanyForUser(ctx context.Context, userID int) ([]repositories.CompanyTotalAmount, error) {
-	return s.Repo.GetTotalAmountByCompanyForUser(ctx, userID)
+	return s.companyTotals(ctx, models.ReportFilters{UserID: &userID})
 }
 
 func (s *TransactionService) GetTotalAmountByCompanyForUserAndMonth(ctx context.Context, userID int, month int) ([]repositories.CompanyTotalAmount, error) {
-	return s.Repo.GetTotalAmountByCompanyForUserAndMonth(ctx, userID, month)
+	return s.companyTotals(ctx, models.ReportFilters{UserID: &userID, Month: &month})
 }
 
 func (s *TransactionService) GetTotalAmountByCompanyForUserAndYear(ctx context.Context, userID int, year int) ([]repositories.CompanyTotalAmount, error) {
-	return s.Repo.GetTotalAmountByCompanyForUserAndYear(ctx, userID, year)
+	return s.companyTotals(ctx, models.ReportFilters{UserID: &userID, Year: &year})
 }
 
 func (s *TransactionService) GetTotalAmountByCompanyForUserYearAndMonth(ctx context.Context, userID int, year int, month int) ([]repositories.CompanyTotalAmount, error) {
-	return s.Repo.GetTotalAmountByCompanyForUserYearAndMonth(ctx, userID, year, month)
+	return s.companyTotals(ctx, models.ReportFilters{UserID: &userID, Year: &year, Month: &month})
+}
+
+// monthlyAmounts sums transaction amounts per month, latest month first.
+func (s *TransactionService) monthlyAmounts(ctx context.Context, filters models.ReportFilters) ([]repositories.MonthlyAmount, error) {
+	report, err := s.Reports.Query(ctx, models.ReportQuery{
+		Dimensions: []string{"month"},
+		Measures:   []string{"amount"},
+		Filters:    filters,
+		OrderBy:    []string{"-month"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var monthlyAmounts []repositories.MonthlyAmount
+	for _, row := range report.Rows {
+		monthlyAmounts = append(monthlyAmounts, repositories.MonthlyAmount{
+			Month:  monthName(row["month"]),
+			Amount: row["amount"].(float64),
+		})
+	}
+	return monthlyAmounts, nil
+}
+
+// yearlyAmounts sums transaction amounts per month, grouped into years, latest first.
+func (s *TransactionService) yearlyAmounts(ctx context.Context, filters models.ReportFilters) ([]repositories.YearlyAmounts, error) {
+	report, err := s.Reports.Query(ctx, models.ReportQuery{
+		Dimensions: []string{"year", "month"},
+		Measures:   []string{"amount"},
+		Filters:    filters,
+		OrderBy:    []string{"-year", "-month"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var yearlyAmounts []repositories.YearlyAmounts
+	for _, row := range report.Rows {
+		year, ok := row["year"].(float64)
+		if !ok {
+			continue
+		}
+		if n := len(yearlyAmounts); n == 0 || yearlyAmounts[n-1].Year != int(year) {
+			yearlyAmounts = append(yearlyAmounts, repositories.YearlyAmounts{Year: int(year), Months: []repositories.MonthlyAmount{}})
+		}
+		current := &yearlyAmounts[len(yearlyAmounts)-1]
+		current.Months = append(current.Months, repositories.MonthlyAmount{
+			Month:  monthName(row["month"]),
+			Amount: row["amount"].(float64),
+		})
+	}
+	return yearlyAmounts, nil
+}
+
+// companyTotals sums transaction amounts per company, skipping transactions without one.
+func (s *TransactionService) companyTotals(ctx context.Context, filters models.ReportFilters) ([]repositories.CompanyTotalAmount, error) {
+	report, err := s.Reports.Query(ctx, models.ReportQuery{
+		Dimensions: []string{"company"},
+		Measures:   []string{"amount"},
+		Filters:    filters,
+		OrderBy:    []string{"company"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var totalAmounts []repositories.CompanyTotalAmount
+	for _, row := range report.Rows {
+		name, ok := row["company_name"].(string)
+		if !ok {
+			continue
+		}
+		totalAmounts = append(totalAmounts, repositories.CompanyTotalAmount{
+			CompanyName: name,
+			TotalAmount: row["amount"].(float64),
+		})
+	}
+	return totalAmounts, nil
+}
+
+func monthName(value interface{}) string {
+	month, _ := value.(float64)
+	return time.Month(month).String()
 }
 
 func (s *TransactionService) GetAllByUserIDAndStatus(ctx context.Context, userID, status int) (*CombinedTransactions, error) {
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+	"tender/internal/models"
+	"tender/internal/repositories"
+	"time"
+)
+
+type TrashService struct {
+	Repo *repositories.TrashRepository
+	// Retention is how long a record stays in the trash before it may be purged.
+	Retention time.Duration
+}
+
+// GetTrash lists soft-deleted records, optionally limited to one entity type.
+func (s *TrashService) GetTrash(ctx context.Context, entity string) ([]models.TrashItem, error) {
+	return s.Repo.GetTrash(ctx, entity)
+}
+
+// Restore takes a soft-deleted record out of the trash.
+func (s *TrashService) Restore(ctx context.Context, entity string, id int) error {
+	return s.Repo.Restore(ctx, entity, id)
+}
+
+// Purge permanently removes records that have been in the trash longer than the retention period.
+func (s *TrashService) Purge(ctx context.Context) (models.TrashPurgeResult, error) {
+	before := time.Now().Add(-s.Retention)
+	purged, err := s.Repo.Purge(ctx, before)
+	if err != nil {
+		return models.TrashPurgeResult{}, err
+	}
+	return models.TrashPurgeResult{DeletedBefore: before.Format(time.RFC3339), Purged: purged}, nil
+}
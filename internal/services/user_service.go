@@ -3,12 +3,15 @@ package services
 import (
 	"context"
 	"golang.org/x/crypto/bcrypt"
+	"math"
+	"tender/internal/events"
 	"tender/internal/models"
 	"tender/internal/repositories"
 )
 
 type UserService struct {
-	Repo *repositories.UserRepository
+	Repo   *repositories.UserRepository
+	Events *events.Bus
 }
 
 func (s *UserService) GetAllUsers(ctx context.Context) ([]models.User, error) {
@@ -27,8 +30,21 @@ func (s *UserService) GetUserByID(ctx context.Context, id int) (models.User, err
 	return s.Repo.GetUserByID(ctx, id)
 }
 
+// UpdateBalance adds amount to the user's balance; a negative amount is a debit.
 func (s *UserService) UpdateBalance(ctx context.Context, id int, amount float64) error {
-	return s.Repo.UpdateBalance(ctx, id, amount)
+	if err := s.Repo.UpdateBalance(ctx, id, amount); err != nil {
+		return err
+	}
+	if amount == 0 {
+		return nil
+	}
+	s.Events.Publish(ctx, events.Event{
+		Type:     events.BalanceUpdated,
+		UserID:   &id,
+		EntityID: id,
+		Data:     map[string]interface{}{"amount": math.Abs(amount), "debit": amount < 0},
+	})
+	return nil
 }
 
 func (s *UserService) GetBalance(ctx context.Context, id int) (float64, error) {
@@ -50,6 +66,36 @@ func (s *UserService) UpdateUser(ctx context.Context, user models.User) (models.
 	return s.Repo.UpdateUser(ctx, user)
 }
 
+// PatchUser applies a JSON merge patch to a user. A changed password is hashed before it is stored.
+func (s *UserService) PatchUser(ctx context.Context, id, version int, patch []byte) (models.User, error) {
+	user, err := s.Repo.GetUserByID(ctx, id)
+	if err != nil {
+		return models.User{}, err
+	}
+	if version == 0 {
+		version = user.Version
+	}
+	storedPassword := user.Password
+
+	if err := applyMergePatch(&user, patch); err != nil {
+		return models.User{}, err
+	}
+	user.ID = id
+	user.Version = version
+
+	if user.Password == "" {
+		user.Password = storedPassword
+	} else if user.Password != storedPassword {
+		newPass, err := bcrypt.GenerateFromPassword([]byte(user.Password), 12)
+		if err != nil {
+			return models.User{}, err
+		}
+		user.Password = string(newPass)
+	}
+
+	return s.Repo.ReplaceUser(ctx, user)
+}
+
 func (s *UserService) ChangePassword(ctx context.Context, userID int, oldPassword, newPassword string) error {
 	user, err := s.Repo.GetUserByID(ctx, userID)
 	if err != nil {
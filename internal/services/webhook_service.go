@@ -0,0 +1,525 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"tender/internal/models"
+	"tender/internal/repositories"
+	"time"
+)
+
+// Webhook delivery tuning. Deliveries are retried with the backoff of the notification outbox;
+// a webhook is disabled after webhookMaxFailures failed attempts in a row.
+const (
+	webhookBatchSize    = 100
+	webhookAuditBatch   = 500
+	webhookLease        = 2 * time.Minute
+	webhookMaxAttempts  = 8
+	webhookMaxFailures  = 20
+	webhookTimeout      = 10 * time.Second
+	webhookResponseKept = 1024 // bytes of a response body kept in the attempt log
+	maxWebhookDelivery  = 500
+)
+
+// webhookActions names the audit actions in event types, e.g. "transaction.created".
+var webhookActions = map[string]string{
+	models.AuditCreate:  "created",
+	models.AuditUpdate:  "updated",
+	models.AuditDelete:  "deleted",
+	models.AuditRestore: "restored",
+	models.AuditPurge:   "purged",
+}
+
+// webhookBlockedNetworks are the ranges, besides loopback, private, link-local and multicast
+// addresses, that webhooks are not delivered to: "this network", carrier-grade NAT, benchmarking
+// and reserved addresses.
+var webhookBlockedNetworks = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+}
+
+var errWebhookAddress = errors.New("webhook endpoint address is not public")
+
+// webhookStatusEntities lists the entities that also announce "<entity>.status_changed" when an
+// update changes their status.
+var webhookStatusEntities = map[string]bool{"transaction": true, "tender": true}
+
+// WebhookService sends record changes to the endpoints of other systems. QueueEvents turns new
+// audit log entries into deliveries; DeliverPending posts them.
+//
+// Every request is signed: X-Webhook-Signature is "t=<unix time>,v1=<hex HMAC-SHA256 of
+// "<unix time>.<body>" keyed with the webhook's secret>". X-Webhook-Event and X-Webhook-Delivery
+// carry the event type and the delivery ID, which stays the same across retries.
+type WebhookService struct {
+	Repo   *repositories.WebhookRepository
+	Audit  *repositories.AuditRepository
+	Client *http.Client
+	// AllowedNetworks are the local receivers webhooks may point to; see webhookAddressAllowed.
+	AllowedNetworks []netip.Prefix
+
+	mu       sync.Mutex
+	follower *auditFollower // audit entries read, loaded from the stored cursor on first use
+}
+
+// NewWebhookClient returns an HTTP client for webhook deliveries. Redirects are not followed, so
+// they count as failures.
+//
+// The client refuses to connect to addresses that are not public unless they are in allowed.
+// The check is made on the address actually dialed, so a host name resolving to an internal
+// address, at registration or only later, is refused too.
+func NewWebhookClient(allowed []netip.Prefix) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !webhookAddressAllowed(addr.Addr(), allowed) {
+				return fmt.Errorf("%w: %s", errWebhookAddress, addr.Addr())
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Deliveries connect directly, so that the address checked is the endpoint's own.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   webhookTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// webhookAddressAllowed reports whether webhooks may be delivered to ip: a public address, or
+// one in allowed.
+func webhookAddressAllowed(ip netip.Addr, allowed []netip.Prefix) bool {
+	ip = ip.Unmap()
+	for _, p := range allowed {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, p := range webhookBlockedNetworks {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// EventTypes lists the event types a webhook can subscribe to.
+func (s *WebhookService) EventTypes() []string {
+	var types []string
+	for _, entity := range repositories.AuditEntities() {
+		for _, action := range []string{models.AuditCreate, models.AuditUpdate, models.AuditDelete, models.AuditRestore, models.AuditPurge} {
+			types = append(types, entity+"."+webhookActions[action])
+		}
+		if webhookStatusEntities[entity] {
+			types = append(types, entity+".status_changed")
+		}
+	}
+	return types
+}
+
+func (s *WebhookService) validate(w *models.Webhook) error {
+	w.URL = strings.TrimSpace(w.URL)
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(w.URL) > 2048 {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", models.ErrInvalidWebhook)
+	}
+	if ip, err := netip.ParseAddr(u.Hostname()); err == nil && !webhookAddressAllowed(ip, s.AllowedNetworks) {
+		return fmt.Errorf("%w: url must not point to a loopback, private or link-local address", models.ErrInvalidWebhook)
+	}
+
+	known := map[string]bool{models.WebhookAllEvents: true}
+	for _, t := range s.EventTypes() {
+		known[t] = true
+	}
+	seen := map[string]bool{}
+	events := make([]string, 0, len(w.Events))
+	for _, t := range w.Events {
+		t = strings.TrimSpace(t)
+		if !known[t] {
+			return fmt.Errorf("%w: unknown event type %q", models.ErrInvalidWebhook, t)
+		}
+		if !seen[t] {
+			seen[t] = true
+			events = append(events, t)
+		}
+	}
+	if len(events) == 0 {
+		return fmt.Errorf("%w: events must not be empty", models.ErrInvalidWebhook)
+	}
+	w.Events = events
+	return nil
+}
+
+// Create registers a webhook and returns it with its signing secret, which is not shown again.
+func (s *WebhookService) Create(ctx context.Context, w models.Webhook, createdBy int) (models.Webhook, error) {
+	if err := s.validate(&w); err != nil {
+		return models.Webhook{}, err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return models.Webhook{}, err
+	}
+	w.Secret = "whsec_" + hex.EncodeToString(secret)
+	w.CreatedBy = &createdBy
+
+	id, err := s.Repo.Create(ctx, w)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	created, err := s.Repo.Get(ctx, id)
+	return created, err
+}
+
+func (s *WebhookService) List(ctx context.Context) ([]models.Webhook, error) {
+	webhooks, err := s.Repo.List(ctx, false)
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, err
+}
+
+func (s *WebhookService) Get(ctx context.Context, id int) (models.Webhook, error) {
+	w, err := s.Repo.Get(ctx, id)
+	w.Secret = ""
+	return w, err
+}
+
+// Update replaces a webhook's settings. Turning a disabled webhook on clears its failures and
+// resumes its pending deliveries.
+func (s *WebhookService) Update(ctx context.Context, w models.Webhook) (models.Webhook, error) {
+	if err := s.validate(&w); err != nil {
+		return models.Webhook{}, err
+	}
+	if err := s.Repo.Update(ctx, w); err != nil {
+		return models.Webhook{}, err
+	}
+	return s.Get(ctx, w.ID)
+}
+
+func (s *WebhookService) Delete(ctx context.Context, id int) error {
+	return s.Repo.Delete(ctx, id)
+}
+
+func (s *WebhookService) Deliveries(ctx context.Context, f models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error) {
+	if _, err := s.Repo.Get(ctx, f.WebhookID); err != nil {
+		return nil, err
+	}
+	if f.Limit <= 0 || f.Limit > maxWebhookDelivery {
+		f.Limit = 100
+	}
+	return s.Repo.ListDeliveries(ctx, f)
+}
+
+func (s *WebhookService) Delivery(ctx context.Context, webhookID int, id int64) (models.WebhookDelivery, error) {
+	return s.Repo.GetDelivery(ctx, webhookID, id)
+}
+
+// webhookEventTypes returns the event types an audit entry announces.
+func webhookEventTypes(c models.AuditChange) []string {
+	action, ok := webhookActions[c.Action]
+	if !ok {
+		return nil
+	}
+	types := []string{c.Entity + "." + action}
+	if c.Action == models.AuditUpdate && webhookStatusEntities[c.Entity] {
+		var changes map[string]json.RawMessage
+		if json.Unmarshal(c.Changes, &changes) == nil && changes["status"] != nil {
+			types = append(types, c.Entity+".status_changed")
+		}
+	}
+	return types
+}
+
+func subscribed(w models.Webhook, eventType string) bool {
+	for _, t := range w.Events {
+		if t == eventType || t == models.WebhookAllEvents {
+			return true
+		}
+	}
+	return false
+}
+
+// QueueEvents queues a delivery for every active webhook subscribed to the events of the audit
+// entries written since the last call, and returns how many were queued.
+//
+// The stored cursor stays behind audit entries that may still be committed out of order, so after
+// a restart some entries are read again; a delivery already queued is not queued twice.
+func (s *WebhookService) QueueEvents(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.follower == nil {
+		cursor, err := s.Repo.Cursor(ctx)
+		if err != nil {
+			return 0, err
+		}
+		s.follower = &auditFollower{lastID: cursor}
+	}
+	webhooks, err := s.Repo.List(ctx, true)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	after := s.follower.from(now)
+	queued := 0
+	for {
+		changes, err := s.Audit.ChangesAfter(ctx, after, webhookAuditBatch)
+		if err != nil || len(changes) == 0 {
+			return queued, err
+		}
+
+		var deliveries []models.WebhookDelivery
+		for _, c := range s.follower.fresh(changes, now) {
+			auditID := c.ID
+			for _, eventType := range webhookEventTypes(c) {
+				payload, err := json.Marshal(models.WebhookEvent{
+					ID:         fmt.Sprintf("evt_%d_%s", c.ID, eventType),
+					Type:       eventType,
+					OccurredAt: c.At,
+					Data: models.WebhookEventData{
+						Entity:    c.Entity,
+						EntityID:  c.EntityID,
+						Action:    c.Action,
+						ActorID:   c.ActorID,
+						UserID:    c.UserID,
+						CompanyID: c.CompanyID,
+						Changes:   c.Changes,
+					},
+				})
+				if err != nil {
+					return queued, err
+				}
+				for _, w := range webhooks {
+					if subscribed(w, eventType) {
+						deliveries = append(deliveries, models.WebhookDelivery{
+							WebhookID: w.ID, AuditID: &auditID, EventType: eventType, Payload: payload,
+						})
+					}
+				}
+			}
+		}
+
+		after = changes[len(changes)-1].ID
+		n, err := s.Repo.QueueDeliveries(ctx, deliveries, s.follower.from(now))
+		if err != nil {
+			// Read the entries again from the stored cursor next time.
+			s.follower = nil
+			return queued, err
+		}
+		queued += n
+		if len(changes) < webhookAuditBatch {
+			return queued, nil
+		}
+	}
+}
+
+// DeliverPending posts the deliveries that are due and returns how many succeeded.
+func (s *WebhookService) DeliverPending(ctx context.Context) (int, error) {
+	now := time.Now()
+	deliveries, err := s.Repo.DueDeliveries(ctx, now, webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	webhooks := map[int]models.Webhook{}
+	delivered := 0
+	for _, d := range deliveries {
+		w, ok := webhooks[d.WebhookID]
+		if !ok {
+			if w, err = s.Repo.Get(ctx, d.WebhookID); err != nil {
+				return delivered, err
+			}
+			webhooks[d.WebhookID] = w
+		}
+		if !w.Active {
+			// Disabled by an earlier delivery of this batch.
+			continue
+		}
+		claimed, err := s.Repo.ClaimDelivery(ctx, d.ID, d.Attempts, now.Add(webhookLease))
+		if err != nil {
+			return delivered, err
+		}
+		if !claimed {
+			continue
+		}
+		attempts := d.Attempts + 1
+
+		status, sendErr, err := s.attempt(ctx, w, d)
+		if err != nil {
+			return delivered, err
+		}
+		if sendErr == nil {
+			if err := s.Repo.MarkDelivered(ctx, d, *status, time.Now()); err != nil {
+				return delivered, fmt.Errorf("updating webhook delivery %d: %w", d.ID, err)
+			}
+			delivered++
+			continue
+		}
+
+		if attempts >= webhookMaxAttempts {
+			err = s.Repo.MarkFailed(ctx, d.ID, status, sendErr.Error())
+		} else {
+			err = s.Repo.MarkRetry(ctx, d.ID, time.Now().Add(outboxBackoff(attempts)), status, sendErr.Error())
+		}
+		if err != nil {
+			return delivered, fmt.Errorf("updating webhook delivery %d: %w", d.ID, err)
+		}
+		disabled, err := s.Repo.RecordFailure(ctx, w.ID, webhookMaxFailures,
+			fmt.Sprintf("%d deliveries in a row failed, the last with: %v", webhookMaxFailures, sendErr))
+		if err != nil {
+			return delivered, err
+		}
+		if disabled {
+			w.Active = false
+			webhooks[w.ID] = w
+		}
+	}
+	return delivered, nil
+}
+
+// Redeliver posts a delivery again right away, whatever its status, and returns it with its
+// attempt log. Manual attempts do not count towards disabling the webhook.
+func (s *WebhookService) Redeliver(ctx context.Context, webhookID int, id int64) (models.WebhookDelivery, error) {
+	w, err := s.Repo.Get(ctx, webhookID)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	d, err := s.Repo.GetDelivery(ctx, webhookID, id)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	if err := s.sendNow(ctx, w, d); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	return s.Repo.GetDelivery(ctx, webhookID, id)
+}
+
+// Ping posts a test event to a webhook, even a disabled one, and returns the delivery.
+func (s *WebhookService) Ping(ctx context.Context, webhookID int) (models.WebhookDelivery, error) {
+	w, err := s.Repo.Get(ctx, webhookID)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	now := time.Now()
+	payload, err := json.Marshal(models.WebhookEvent{
+		ID:         fmt.Sprintf("evt_ping_%d_%d", webhookID, now.UnixNano()),
+		Type:       models.WebhookPing,
+		OccurredAt: now.Format(time.RFC3339),
+		Data:       models.WebhookEventData{Entity: "webhook", EntityID: webhookID, Action: models.WebhookPing},
+	})
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	d := models.WebhookDelivery{WebhookID: webhookID, EventType: models.WebhookPing, Payload: payload}
+	if d.ID, err = s.Repo.CreateDelivery(ctx, d); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	if err := s.sendNow(ctx, w, d); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+	return s.Repo.GetDelivery(ctx, webhookID, d.ID)
+}
+
+// sendNow makes one manual attempt at a delivery. A failed ping is not retried.
+func (s *WebhookService) sendNow(ctx context.Context, w models.Webhook, d models.WebhookDelivery) error {
+	status, sendErr, err := s.attempt(ctx, w, d)
+	if err != nil {
+		return err
+	}
+	switch {
+	case sendErr == nil:
+		return s.Repo.MarkDelivered(ctx, d, *status, time.Now())
+	case d.EventType == models.WebhookPing:
+		return s.Repo.MarkFailed(ctx, d.ID, status, sendErr.Error())
+	}
+	return nil
+}
+
+// attempt posts a delivery and logs the attempt. It returns the response status when there was
+// a response, and sendErr when the delivery failed; err reports a failure to log the attempt.
+func (s *WebhookService) attempt(ctx context.Context, w models.Webhook, d models.WebhookDelivery) (status *int, sendErr, err error) {
+	start := time.Now()
+	status, body, sendErr := s.post(ctx, w, d)
+	a := models.WebhookAttempt{
+		DeliveryID:     d.ID,
+		ResponseStatus: status,
+		DurationMs:     int(time.Since(start).Milliseconds()),
+	}
+	if body != "" {
+		a.ResponseBody = &body
+	}
+	if sendErr != nil {
+		reason := sendErr.Error()
+		a.Error = &reason
+	}
+	if err := s.Repo.RecordAttempt(ctx, a); err != nil {
+		return status, sendErr, fmt.Errorf("logging webhook attempt of delivery %d: %w", d.ID, err)
+	}
+	return status, sendErr, nil
+}
+
+func (s *WebhookService) post(ctx context.Context, w models.Webhook, d models.WebhookDelivery) (*int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return nil, "", err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "tender-webhooks/1")
+	req.Header.Set("X-Webhook-Event", d.EventType)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatInt(d.ID, 10))
+	req.Header.Set("X-Webhook-Signature", SignWebhookPayload(w.Secret, timestamp, d.Payload))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseKept))
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	status := resp.StatusCode
+	if status < 200 || status > 299 {
+		return &status, string(body), errors.New("endpoint responded " + resp.Status)
+	}
+	return &status, string(body), nil
+}
+
+// SignWebhookPayload returns the X-Webhook-Signature of a payload sent at timestamp.
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(payload)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
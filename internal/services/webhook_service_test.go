@@ -0,0 +1,350 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"tender/internal/models"
+	"tender/internal/repositories"
+	"testing"
+	"time"
+)
+
+const testWebhookSecret = "whsec_test"
+
+// loopback lets the webhook client reach httptest servers.
+var loopback = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+
+// webhookTest is a webhook service on a mock database whose webhook 1 points at a test server.
+type webhookTest struct {
+	service  *WebhookService
+	mock     sqlmock.Sqlmock
+	server   *httptest.Server
+	requests atomic.Int32
+}
+
+// newWebhookTest starts a test server answering every delivery with status after checking its
+// signature.
+func newWebhookTest(t *testing.T, status int) *webhookTest {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	wt := &webhookTest{mock: mock}
+	wt.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wt.requests.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		if err := verifySignature(r.Header.Get("X-Webhook-Signature"), body); err != nil {
+			t.Errorf("delivery %s: %v", r.Header.Get("X-Webhook-Delivery"), err)
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, "ok")
+	}))
+	t.Cleanup(wt.server.Close)
+
+	wt.service = &WebhookService{
+		Repo:            &repositories.WebhookRepository{Db: db},
+		Audit:           &repositories.AuditRepository{Db: db},
+		Client:          NewWebhookClient(loopback),
+		AllowedNetworks: loopback,
+	}
+	return wt
+}
+
+// verifySignature checks a signature the way a receiver would, without SignWebhookPayload.
+func verifySignature(header string, body []byte) error {
+	var timestamp, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature = value
+		}
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(unix, 0)).Abs() > time.Minute {
+		return errors.New("bad signature timestamp in " + header)
+	}
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	if !hmac.Equal([]byte(signature), []byte(hex.EncodeToString(mac.Sum(nil)))) {
+		return errors.New("signature mismatch in " + header)
+	}
+	return nil
+}
+
+func (wt *webhookTest) expectWebhook() {
+	wt.mock.ExpectQuery("FROM webhooks WHERE id = ?").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "secret", "events", "description", "active",
+			"failure_count", "disabled_at", "disabled_reason", "created_by", "created_at", "updated_at"}).
+			AddRow(1, wt.server.URL, testWebhookSecret, `["*"]`, nil, true, 0, nil, nil, nil, "2026-01-01", "2026-01-01"))
+}
+
+func (wt *webhookTest) expectWebhooks() {
+	wt.mock.ExpectQuery("FROM webhooks WHERE active").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "secret", "events", "description", "active",
+			"failure_count", "disabled_at", "disabled_reason", "created_by", "created_at", "updated_at"}).
+			AddRow(1, wt.server.URL, testWebhookSecret, `["*"]`, nil, true, 0, nil, nil, nil, "2026-01-01", "2026-01-01"))
+}
+
+func deliveryRows(deliveries ...models.WebhookDelivery) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "webhook_id", "audit_id", "event_type", "payload", "status", "attempts",
+		"next_attempt_at", "response_status", "last_error", "created_at", "delivered_at"})
+	for _, d := range deliveries {
+		rows.AddRow(d.ID, 1, nil, "transaction.created", `{"id":"evt"}`, models.OutboxPending, d.Attempts,
+			"2026-01-01", nil, nil, "2026-01-01", nil)
+	}
+	return rows
+}
+
+func (wt *webhookTest) expectAttempt(id int64, attempts int) {
+	wt.mock.ExpectExec("UPDATE webhook_deliveries SET attempts = attempts \\+ 1").
+		WithArgs(sqlmock.AnyArg(), id, models.OutboxPending, attempts).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	wt.mock.ExpectExec("INSERT INTO webhook_attempts").WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func (wt *webhookTest) expectDelivered(id int64) {
+	wt.mock.ExpectBegin()
+	wt.mock.ExpectExec("UPDATE webhook_deliveries SET status").
+		WithArgs(models.OutboxSent, http.StatusOK, sqlmock.AnyArg(), id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	wt.mock.ExpectExec("UPDATE webhooks SET failure_count = 0").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	wt.mock.ExpectCommit()
+}
+
+// expectFailure expects a failed attempt to be counted against the webhook, disabling it when
+// disable is set.
+func (wt *webhookTest) expectFailure(disable bool) {
+	wt.mock.ExpectExec("SET failure_count = failure_count \\+ 1").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	disabled := int64(0)
+	if disable {
+		disabled = 1
+	}
+	wt.mock.ExpectExec("UPDATE webhooks SET active = FALSE").
+		WithArgs(sqlmock.AnyArg(), 1, webhookMaxFailures).
+		WillReturnResult(sqlmock.NewResult(0, disabled))
+}
+
+func (wt *webhookTest) done(t *testing.T) {
+	t.Helper()
+	if err := wt.mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	payload := []byte(`{"id":"evt_1"}`)
+	if err := verifySignature(SignWebhookPayload(testWebhookSecret, time.Now().Unix(), payload), payload); err != nil {
+		t.Fatal(err)
+	}
+	stale := SignWebhookPayload(testWebhookSecret, time.Now().Add(-time.Hour).Unix(), payload)
+	if verifySignature(stale, payload) == nil {
+		t.Error("accepted a signature an hour old")
+	}
+	if verifySignature(SignWebhookPayload("whsec_other", time.Now().Unix(), payload), payload) == nil {
+		t.Error("accepted a signature made with another secret")
+	}
+}
+
+func TestWebhookIsDelivered(t *testing.T) {
+	wt := newWebhookTest(t, http.StatusOK)
+	wt.mock.ExpectQuery("FROM webhook_deliveries d").WillReturnRows(deliveryRows(models.WebhookDelivery{ID: 5}))
+	wt.expectWebhook()
+	wt.expectAttempt(5, 0)
+	wt.expectDelivered(5)
+
+	delivered, err := wt.service.DeliverPending(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivered != 1 || wt.requests.Load() != 1 {
+		t.Errorf("delivered %d with %d requests, want 1", delivered, wt.requests.Load())
+	}
+	wt.done(t)
+}
+
+func TestFailedWebhookIsRetriedWithBackoff(t *testing.T) {
+	wt := newWebhookTest(t, http.StatusInternalServerError)
+	wt.mock.ExpectQuery("FROM webhook_deliveries d").WillReturnRows(deliveryRows(models.WebhookDelivery{ID: 5, Attempts: 2}))
+	wt.expectWebhook()
+	wt.expectAttempt(5, 2)
+	var next capture
+	wt.mock.ExpectExec("UPDATE webhook_deliveries SET next_attempt_at").
+		WithArgs(&next, http.StatusInternalServerError, "endpoint responded 500 Internal Server Error", int64(5)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	wt.expectFailure(false)
+
+	start := time.Now()
+	delivered, err := wt.service.DeliverPending(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivered != 0 {
+		t.Errorf("delivered %d, want 0", delivered)
+	}
+	wt.done(t)
+
+	at, _ := next.value.(time.Time)
+	if backoff := outboxBackoff(3); at.Before(start.Add(backoff)) || at.After(time.Now().Add(backoff)) {
+		t.Errorf("next attempt at %v, want %v after %v", at, backoff, start)
+	}
+}
+
+func TestFailingWebhookIsDisabled(t *testing.T) {
+	wt := newWebhookTest(t, http.StatusBadGateway)
+	wt.mock.ExpectQuery("FROM webhook_deliveries d").
+		WillReturnRows(deliveryRows(models.WebhookDelivery{ID: 5}, models.WebhookDelivery{ID: 6}))
+	wt.expectWebhook()
+	wt.expectAttempt(5, 0)
+	wt.mock.ExpectExec("UPDATE webhook_deliveries SET next_attempt_at").WillReturnResult(sqlmock.NewResult(0, 1))
+	wt.expectFailure(true)
+	// Delivery 6 waits until the webhook is turned on again.
+
+	if _, err := wt.service.DeliverPending(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if wt.requests.Load() != 1 {
+		t.Errorf("made %d requests, want 1", wt.requests.Load())
+	}
+	wt.done(t)
+}
+
+func TestWebhookIsRedelivered(t *testing.T) {
+	wt := newWebhookTest(t, http.StatusOK)
+	attemptColumns := []string{"id", "delivery_id", "response_status", "response_body", "error", "duration_ms", "created_at"}
+	wt.expectWebhook()
+	wt.mock.ExpectQuery("FROM webhook_deliveries WHERE id = ?").WithArgs(int64(5), 1).
+		WillReturnRows(deliveryRows(models.WebhookDelivery{ID: 5, Attempts: webhookMaxAttempts}))
+	wt.mock.ExpectQuery("FROM webhook_attempts").WillReturnRows(sqlmock.NewRows(attemptColumns))
+	// A manual attempt is not claimed and does not count towards disabling the webhook.
+	wt.mock.ExpectExec("INSERT INTO webhook_attempts").WillReturnResult(sqlmock.NewResult(1, 1))
+	wt.expectDelivered(5)
+	wt.mock.ExpectQuery("FROM webhook_deliveries WHERE id = ?").WithArgs(int64(5), 1).
+		WillReturnRows(deliveryRows(models.WebhookDelivery{ID: 5, Attempts: webhookMaxAttempts}))
+	wt.mock.ExpectQuery("FROM webhook_attempts").
+		WillReturnRows(sqlmock.NewRows(attemptColumns).AddRow(1, 5, http.StatusOK, "ok", nil, 3, "2026-01-01"))
+
+	d, err := wt.service.Redeliver(context.Background(), 1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wt.requests.Load() != 1 || len(d.AttemptLog) != 1 {
+		t.Errorf("made %d requests and returned %d attempts, want 1", wt.requests.Load(), len(d.AttemptLog))
+	}
+	wt.done(t)
+}
+
+func TestWebhookClientRefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	_, err := NewWebhookClient(nil).Post(server.URL, "application/json", nil)
+	if !errors.Is(err, errWebhookAddress) {
+		t.Errorf("posted to %s: %v, want the address refused", server.URL, err)
+	}
+	resp, err := NewWebhookClient(loopback).Post(server.URL, "application/json", nil)
+	if err != nil {
+		t.Fatalf("posted to an allowed address: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestWebhookAddressAllowed(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"93.184.216.34":          true,
+		"2606:2800:220:1::1":     true,
+		"127.0.0.1":              false,
+		"10.1.2.3":               false,
+		"172.16.0.1":             false,
+		"192.168.1.1":            false,
+		"169.254.169.254":        false,
+		"100.64.0.1":             false,
+		"0.0.0.0":                false,
+		"::1":                    false,
+		"fd00::1":                false,
+		"fe80::1":                false,
+		"::ffff:169.254.169.254": false,
+	} {
+		if got := webhookAddressAllowed(netip.MustParseAddr(addr), nil); got != want {
+			t.Errorf("webhookAddressAllowed(%s) = %v, want %v", addr, got, want)
+		}
+	}
+	if !webhookAddressAllowed(netip.MustParseAddr("10.1.2.3"), []netip.Prefix{netip.MustParsePrefix("10.1.2.0/24")}) {
+		t.Error("refused an allowed network")
+	}
+}
+
+func TestWebhookURLMustNotBeInternal(t *testing.T) {
+	s := &WebhookService{}
+	for _, url := range []string{"http://169.254.169.254/latest/meta-data", "http://127.0.0.1:8080/hook", "https://[::1]/hook"} {
+		w := models.Webhook{URL: url, Events: []string{models.WebhookAllEvents}}
+		if err := s.validate(&w); !errors.Is(err, models.ErrInvalidWebhook) {
+			t.Errorf("validate(%s) = %v, want it refused", url, err)
+		}
+	}
+	w := models.Webhook{URL: "https://hooks.example.com/tender", Events: []string{models.WebhookAllEvents}}
+	if err := s.validate(&w); err != nil {
+		t.Errorf("validate(%s) = %v", w.URL, err)
+	}
+}
+
+func auditRows(ids ...int64) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "entity", "entity_id", "action", "actor_id", "user_id", "company_id",
+		"created_at", "changes"})
+	for _, id := range ids {
+		rows.AddRow(id, "company", 3, models.AuditCreate, nil, nil, 3, "2026-01-01", `{}`)
+	}
+	return rows
+}
+
+// An audit entry committed after one with a higher ID is still announced, and the stored cursor
+// waits for it.
+func TestWebhookEventsCommittedOutOfOrderAreQueued(t *testing.T) {
+	wt := newWebhookTest(t, http.StatusOK)
+	wt.mock.ExpectQuery("SELECT last_audit_id FROM webhook_cursor").
+		WillReturnRows(sqlmock.NewRows([]string{"last_audit_id"}).AddRow(10))
+	wt.expectWebhooks()
+	wt.mock.ExpectQuery("FROM audit_log").WithArgs(int64(10), webhookAuditBatch).WillReturnRows(auditRows(11, 13))
+	wt.mock.ExpectBegin()
+	wt.mock.ExpectExec("INSERT IGNORE INTO webhook_deliveries").WithArgs(1, int64(11), "company.created", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	wt.mock.ExpectExec("INSERT IGNORE INTO webhook_deliveries").WithArgs(1, int64(13), "company.created", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	wt.mock.ExpectExec("UPDATE webhook_cursor").WithArgs(int64(11), int64(11)).WillReturnResult(sqlmock.NewResult(0, 1))
+	wt.mock.ExpectCommit()
+
+	if queued, err := wt.service.QueueEvents(context.Background()); err != nil || queued != 2 {
+		t.Fatalf("queued %d: %v, want 2", queued, err)
+	}
+
+	// Entry 12 commits; entry 13 is read again but not queued twice.
+	wt.expectWebhooks()
+	wt.mock.ExpectQuery("FROM audit_log").WithArgs(int64(11), webhookAuditBatch).WillReturnRows(auditRows(12, 13))
+	wt.mock.ExpectBegin()
+	wt.mock.ExpectExec("INSERT IGNORE INTO webhook_deliveries").WithArgs(1, int64(12), "company.created", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	wt.mock.ExpectExec("UPDATE webhook_cursor").WithArgs(int64(13), int64(13)).WillReturnResult(sqlmock.NewResult(0, 1))
+	wt.mock.ExpectCommit()
+
+	if queued, err := wt.service.QueueEvents(context.Background()); err != nil || queued != 1 {
+		t.Fatalf("queued %d: %v, want 1", queued, err)
+	}
+	wt.done(t)
+}